@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+
+	"utile.space/api/infrastructure/auth"
+	"utile.space/api/infrastructure/logging"
+	"utile.space/api/utils"
+)
+
+// @Summary		API keys usage
+// @Description	Lists the number of requests made per day with each API key, requires an API key with the admin scope
+// @Tags			admin
+// @Produce		json,xml,application/yaml,plain
+// @Param			X-API-Key	header		string	true	"API key with the admin scope"
+// @Success		200			{object}	UsageResult
+// @Failure		401			{object}	utils.ErrorResult
+// @Failure		403			{object}	utils.ErrorResult
+// @Router			/admin/usage [get]
+func ListUsage(authenticator *auth.Authenticator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		usage, err := authenticator.Usage(r.Context())
+		if err != nil {
+			logging.FromContext(r.Context()).Errorf("ListUsage error: %v", err)
+			utils.OutputError(w, r.Header["Accept"], http.StatusInternalServerError, "Internal Server Error")
+			return
+		}
+
+		var result UsageResult
+		result.Usage = make([]KeyUsage, len(usage))
+
+		for i, u := range usage {
+			result.Usage[i].KeyID = u.KeyID
+			result.Usage[i].Name = u.Name
+			result.Usage[i].Day = u.Day
+			result.Usage[i].Requests = u.Requests
+			result.Usage[i].Quota = u.Quota
+		}
+
+		utils.Output(w, r.Header["Accept"], result, strconv.Itoa(len(result.Usage)))
+	}
+}
+
+type UsageResult struct {
+	XMLName xml.Name   `json:"-" xml:"usage" yaml:"-"`
+	Usage   []KeyUsage `json:"usage" xml:"key" yaml:"usage"`
+}
+
+type KeyUsage struct {
+	KeyID    string `json:"id" xml:"id" yaml:"id"`
+	Name     string `json:"name" xml:"name" yaml:"name"`
+	Day      string `json:"day" xml:"day" yaml:"day"`
+	Requests int64  `json:"requests" xml:"requests" yaml:"requests"`
+	Quota    int64  `json:"quota" xml:"quota" yaml:"quota"`
+}
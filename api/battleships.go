@@ -3,34 +3,37 @@ package api
 import (
 	"context"
 	"encoding/xml"
-	"fmt"
 	"net/http"
 	"strconv"
 	"sync"
 
 	"github.com/gorilla/websocket"
 	battleships "utile.space/api/domain/entities"
+	"utile.space/api/infrastructure/logging"
 	"utile.space/api/utils"
 )
 
 var (
-	hub *battleships.Hub
+	hub     *battleships.Hub
+	hubOnce sync.Once
 )
 
+// getHub returns the battleships hub, started by the first caller whatever the goroutine
 func getHub(ctx context.Context) *battleships.Hub {
-	if hub == nil {
-		hub = battleships.NewHub()
+	hubOnce.Do(func() {
+		hub = battleships.NewHub(logging.Logger("battleships"))
 		go hub.Run(ctx)
-	}
+	})
 	return hub
 }
 
 var upgraderBattleShips = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
-		return true
+		return checkOrigin(r)
 	},
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
+	EnableCompression: true,
+	ReadBufferSize:    1024,
+	WriteBufferSize:   1024,
 }
 
 // @Summary		BattleshipsWebsocket to play battleships with another player or computer
@@ -39,16 +42,18 @@ var upgraderBattleShips = websocket.Upgrader{
 // @Success		101
 // @Router			/math/ws [get]
 func BattleshipsWebsocket(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
 	c, err := upgraderBattleShips.Upgrade(w, r, nil)
 	if err != nil {
-		fmt.Print("upgrade:", err)
+		logger.Error("upgrade:", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 	defer c.Close()
 
 	hub := getHub(context.Background())
-	client := battleships.NewClient(hub, c)
+	client := battleships.NewClient(hub, c, logger)
 	client.Hub.Register <- client
 
 	var wg sync.WaitGroup
@@ -61,7 +66,7 @@ func BattleshipsWebsocket(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	go func() {
-		client.ReadPump()
+		client.ReadPump(r.Context())
 		wg.Done()
 	}()
 
@@ -72,15 +77,21 @@ func BattleshipsWebsocket(w http.ResponseWriter, r *http.Request) {
 // @Description	To get stats on multiplayer state of the battleships game
 // @Tags			battleships
 // @Success		200 {object}	StatsResult
-// @Router			/math/stats [get]
+// @Failure		503	{object}	utils.ErrorResult
+// @Router			/battleships/stats [get]
 func BattleshipsStats(w http.ResponseWriter, r *http.Request) {
+	counts, err := getHub(context.Background()).Stats(r.Context())
+	if err != nil {
+		utils.OutputError(w, r.Header["Accept"], http.StatusServiceUnavailable, "Hub not answering")
+		return
+	}
+
 	var stats StatsResult
-	hub := getHub(context.Background())
-	stats.OnlinePlayersCount = hub.CountOnlinePlayers()
-	stats.PendingMatchesCount = hub.CountPendingMatches()
-	stats.OngoingMatchesCount = hub.CountOngoingMatches()
-	stats.FinishedMatchesCount = hub.CountFinishedMatches()
-	stats.TotalMatchesCount = hub.CountTotalMatches()
+	stats.OnlinePlayersCount = counts.OnlinePlayers
+	stats.PendingMatchesCount = counts.PendingMatches
+	stats.OngoingMatchesCount = counts.OngoingMatches
+	stats.FinishedMatchesCount = counts.FinishedMatches
+	stats.TotalMatchesCount = counts.TotalMatches
 
 	utils.Output(w, r.Header["Accept"], stats, strconv.Itoa(stats.OnlinePlayersCount))
 }
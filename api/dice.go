@@ -1,15 +1,27 @@
 package api
 
 import (
+	"encoding/csv"
 	"encoding/xml"
-	"math/rand"
+	"errors"
+	"math/big"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"utile.space/api/domain/services/dice"
+	"utile.space/api/domain/services/random"
+	"utile.space/api/infrastructure/metrics"
+	"utile.space/api/infrastructure/ratelimit"
 	"utile.space/api/utils"
 )
 
+// maxClientSeedLength bounds the characters of a client seed
+const maxClientSeedLength = 256
+
 // @Summary		Roll a dice
 // @Description	Endpoint to roll a dice of the given number of faces
 // @Tags			dice
@@ -27,11 +39,324 @@ func RollDice(w http.ResponseWriter, r *http.Request) {
 
 	var roll DieResult
 	roll.Die = dice
-	roll.Result = rand.Intn(dice) + 1
+	roll.Result = random.Crypto.Intn(dice) + 1
 
 	utils.Output(w, r.Header["Accept"], roll, strconv.Itoa(roll.Result))
 }
 
+// @Summary		Roll dice
+// @Description	Roll dice written in the usual notation, like 4d6kh3+2: groups of dice like 2d8, d% for 100 sides or 4dF for Fate dice, and numbers,
+// @Description	added or subtracted. The dice modifiers keep the highest (kh3) or lowest (kl3) dice, drop the highest (dh3) or lowest (dl3) dice,
+// @Description	explode on the highest face (!) or a compare point (!>5), reroll the lowest face (r) or a compare point (r<2), once only with ro,
+// @Description	count the successes (>5) and subtract the failures (f1), < and > including the value.
+// @Description	The dice are drawn with crypto/rand, or from a server seed committed to with /random/seeds and a client seed,
+// @Description	each roll with the seed having the next nonce, so that the rolls can be verified with /roll/verify once the seed is revealed.
+// @Description	Only the client which created the seed can roll with it.
+// @Tags			dice
+// @Produce		json,xml,application/yaml,plain
+// @Param			expr	query		string	true	"Dice notation, up to 1000 dice, + being encoded %2B in the query"
+// @Param			seed	query		string	false	"Commitment of a server seed"
+// @Param			client	query		string	false	"Client seed of up to 256 characters, required with a server seed"
+// @Success		200		{object}	RollResult
+// @Failure		400		{object}	utils.ErrorResult
+// @Failure		403		{object}	utils.ErrorResult
+// @Failure		404		{object}	utils.ErrorResult
+// @Router			/roll [get]
+func RollNotation(seeds *random.Seeds) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accept := r.Header["Accept"]
+		query := r.URL.Query()
+
+		e, ok := parseNotation(w, r)
+		if !ok {
+			return
+		}
+
+		source := dice.DefaultSource
+		var verification *VerificationResult
+		if commitment := query.Get("seed"); commitment != "" {
+			client, ok := clientSeed(w, r)
+			if !ok {
+				return
+			}
+
+			// NOTE: the nonce is only spent on valid notations
+			seeded, nonce, err := seeds.Next(commitment, ratelimit.ClientFromContext(r.Context()), client)
+			if errors.Is(err, random.ErrSeedInUse) {
+				utils.OutputError(w, accept, http.StatusForbidden, "Only the client which created the seed can roll with it")
+				return
+			}
+			if err != nil {
+				utils.OutputError(w, accept, http.StatusNotFound, "Unknown or expired seed")
+				return
+			}
+			source = seeded
+			verification = &VerificationResult{Commitment: commitment, ClientSeed: client, Nonce: nonce}
+		}
+
+		roll := newRollResult(e.Roll(source))
+		roll.Expression = e.Notation
+		roll.Verification = verification
+
+		utils.Output(w, accept, roll, strconv.Itoa(roll.Total))
+	}
+}
+
+// @Summary		Verify a roll
+// @Description	Roll dice again from a revealed server seed, a client seed and a nonce, giving the same dice as /roll did with the commitment of the server seed
+// @Tags			dice
+// @Produce		json,xml,application/yaml,plain
+// @Param			expr		query		string	true	"Dice notation, + being encoded %2B in the query"
+// @Param			serverSeed	query		string	true	"Revealed server seed"
+// @Param			client		query		string	true	"Client seed"
+// @Param			nonce		query		int		true	"Nonce of the roll"
+// @Success		200			{object}	RollResult
+// @Failure		400			{object}	utils.ErrorResult
+// @Router			/roll/verify [get]
+func VerifyRoll(w http.ResponseWriter, r *http.Request) {
+	accept := r.Header["Accept"]
+	query := r.URL.Query()
+
+	e, ok := parseNotation(w, r)
+	if !ok {
+		return
+	}
+
+	serverSeed := query.Get("serverSeed")
+	if serverSeed == "" {
+		utils.OutputError(w, accept, http.StatusBadRequest, "serverSeed is required")
+		return
+	}
+	client, ok := clientSeed(w, r)
+	if !ok {
+		return
+	}
+	nonce, err := strconv.Atoi(query.Get("nonce"))
+	if err != nil || nonce < 0 {
+		utils.OutputError(w, accept, http.StatusBadRequest, "Nonce must be a positive integer")
+		return
+	}
+
+	roll := newRollResult(e.Roll(random.NewSeeded(serverSeed, client, nonce)))
+	roll.Expression = e.Notation
+	roll.Verification = &VerificationResult{
+		Commitment: random.Commit(serverSeed),
+		ServerSeed: serverSeed,
+		ClientSeed: client,
+		Nonce:      nonce,
+	}
+
+	utils.CacheForever(w)
+	utils.Output(w, accept, roll, strconv.Itoa(roll.Total))
+}
+
+// @Summary		Distribution of a roll
+// @Description	Exact probability of each total of dice written in the notation of /roll, computed by convolving the distributions of the dice
+// @Description	rather than by rolling them, along with the mean, the variance and the probability of each total or more.
+// @Description	Exploding dice are followed up to 20 explosions, the probability of the longer ones being the residual, and cannot be kept or dropped.
+// @Description	With Accept: text/csv, the outcomes are given as CSV with the columns total, probability and at_least, to be charted.
+// @Tags			dice
+// @Produce		json,xml,application/yaml,text/csv,plain
+// @Param			expr	query		string	true	"Dice notation, up to 100 dice, + being encoded %2B in the query"
+// @Param			target	query		int		false	"Total to meet or exceed"
+// @Success		200		{object}	DistributionResult
+// @Failure		400		{object}	utils.ErrorResult
+// @Router			/roll/distribution [get]
+func RollDistribution(w http.ResponseWriter, r *http.Request) {
+	accept := r.Header["Accept"]
+	query := r.URL.Query()
+
+	e, ok := parseNotation(w, r)
+	if !ok {
+		return
+	}
+
+	var target *int
+	if t := query.Get("target"); t != "" {
+		v, err := strconv.Atoi(t)
+		if err != nil {
+			utils.OutputError(w, accept, http.StatusBadRequest, "Target must be an integer")
+			return
+		}
+		target = &v
+	}
+
+	start := time.Now()
+	d, err := e.Distribution()
+	metrics.ObserveComputation("distribution", start)
+	if errors.Is(err, dice.ErrTooManyDice) || errors.Is(err, dice.ErrTooComplex) || errors.Is(err, dice.ErrExplodingSelection) {
+		utils.OutputError(w, accept, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		utils.OutputError(w, accept, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result := newDistributionResult(d, target)
+	result.Expression = e.Notation
+
+	utils.CacheForever(w)
+	if slices.Contains(accept, "text/csv") {
+		w.Header().Set("Content-Type", "text/csv")
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"total", "probability", "at_least"})
+		for _, outcome := range result.Outcomes {
+			writer.Write([]string{
+				strconv.Itoa(outcome.Total),
+				strconv.FormatFloat(outcome.Probability, 'g', -1, 64),
+				strconv.FormatFloat(outcome.AtLeast, 'g', -1, 64),
+			})
+		}
+		writer.Flush()
+		return
+	}
+
+	plain := strings.Builder{}
+	for _, outcome := range result.Outcomes {
+		plain.WriteString(strconv.Itoa(outcome.Total) + "\t" + strconv.FormatFloat(outcome.Probability, 'g', -1, 64) + "\n")
+	}
+	utils.Output(w, accept, result, plain.String())
+}
+
+// distributionDigits are the significant digits of the moments of a distribution
+const distributionDigits = 20
+
+func newDistributionResult(d dice.Distribution, target *int) DistributionResult {
+	variance := d.Variance()
+	result := DistributionResult{
+		Mean:     new(big.Float).SetPrec(512).SetRat(d.Mean()).Text('g', distributionDigits),
+		Variance: new(big.Float).SetPrec(512).SetRat(variance).Text('g', distributionDigits),
+		StdDev:   new(big.Float).SetPrec(512).Sqrt(new(big.Float).SetPrec(512).SetRat(variance)).Text('g', distributionDigits),
+		Residual: d.Residual.RatString(),
+	}
+	if target != nil {
+		atLeast, _ := d.AtLeast(*target).Float64()
+		result.Target = target
+		result.AtLeastTarget = &atLeast
+	}
+
+	// NOTE: the probabilities of the totals or more are summed from the highest total
+	atLeast := new(big.Rat)
+	result.Outcomes = make([]OutcomeResult, len(d.Probabilities))
+	for i := len(d.Probabilities) - 1; i >= 0; i-- {
+		p := d.Probabilities[i]
+		atLeast.Add(atLeast, p)
+		probability, _ := p.Float64()
+		cumulative, _ := atLeast.Float64()
+		result.Outcomes[i] = OutcomeResult{Total: d.Min + i, Probability: probability, AtLeast: cumulative, Fraction: p.RatString()}
+	}
+	return result
+}
+
+// parseNotation reads the expr query parameter, answering 400 when it is missing or invalid
+func parseNotation(w http.ResponseWriter, r *http.Request) (dice.Expression, bool) {
+	accept := r.Header["Accept"]
+
+	expr := r.URL.Query().Get("expr")
+	if expr == "" {
+		utils.OutputError(w, accept, http.StatusBadRequest, "expr is required")
+		return dice.Expression{}, false
+	}
+
+	e, err := dice.Parse(expr)
+	if err != nil {
+		utils.OutputError(w, accept, http.StatusBadRequest, err.Error())
+		return dice.Expression{}, false
+	}
+	return e, true
+}
+
+// clientSeed reads the client query parameter, answering 400 when it is missing or too long
+func clientSeed(w http.ResponseWriter, r *http.Request) (string, bool) {
+	client := r.URL.Query().Get("client")
+	if client == "" || len(client) > maxClientSeedLength {
+		utils.OutputError(w, r.Header["Accept"], http.StatusBadRequest, "client must be a seed of 1 to "+strconv.Itoa(maxClientSeedLength)+" characters")
+		return "", false
+	}
+	return client, true
+}
+
+func newRollResult(result dice.Result) RollResult {
+	roll := RollResult{Total: result.Total, Groups: []RollGroupResult{}}
+	for _, group := range result.Groups {
+		g := RollGroupResult{Notation: group.Group.Notation, Total: group.Total, Dice: []RolledDieResult{}}
+
+		die := "d" + strconv.Itoa(group.Group.Sides)
+		if group.Group.Fate {
+			die = "dF"
+		}
+		for _, d := range group.Dice {
+			g.Dice = append(g.Dice, RolledDieResult{
+				Die:      die,
+				Result:   d.Value,
+				Dropped:  d.Dropped,
+				Rerolled: d.Rerolled,
+				Exploded: d.Exploded,
+				Success:  d.Success,
+				Failure:  d.Failure,
+			})
+		}
+		roll.Groups = append(roll.Groups, g)
+	}
+	return roll
+}
+
+type RollResult struct {
+	XMLName      xml.Name            `json:"-" xml:"roll" yaml:"-"`
+	Expression   string              `json:"expression" xml:"expression" yaml:"expression"`
+	Total        int                 `json:"total" xml:"total" yaml:"total"`
+	Groups       []RollGroupResult   `json:"groups" xml:"group" yaml:"groups"`
+	Verification *VerificationResult `json:"verification,omitempty" xml:"verification,omitempty" yaml:"verification,omitempty"`
+}
+
+// VerificationResult tells how to verify a roll, the server seed being given once revealed
+type VerificationResult struct {
+	Commitment string `json:"commitment" xml:"commitment" yaml:"commitment"`
+	ServerSeed string `json:"serverSeed,omitempty" xml:"serverSeed,omitempty" yaml:"serverSeed,omitempty"`
+	ClientSeed string `json:"clientSeed" xml:"clientSeed" yaml:"clientSeed"`
+	Nonce      int    `json:"nonce" xml:"nonce" yaml:"nonce"`
+}
+
+// RollGroupResult is a group of dice or a number, its total being negative when it is subtracted
+type RollGroupResult struct {
+	Notation string            `json:"notation" xml:"notation,attr" yaml:"notation"`
+	Total    int               `json:"total" xml:"total,attr" yaml:"total"`
+	Dice     []RolledDieResult `json:"dice" xml:"die" yaml:"dice"`
+}
+
+// RolledDieResult is a die of a group, which does not count when it was dropped or rerolled
+type RolledDieResult struct {
+	Die      string `json:"die" xml:"die,attr" yaml:"die"`
+	Result   int    `json:"result" xml:"result,attr" yaml:"result"`
+	Dropped  bool   `json:"dropped,omitempty" xml:"dropped,attr,omitempty" yaml:"dropped,omitempty"`
+	Rerolled bool   `json:"rerolled,omitempty" xml:"rerolled,attr,omitempty" yaml:"rerolled,omitempty"`
+	Exploded bool   `json:"exploded,omitempty" xml:"exploded,attr,omitempty" yaml:"exploded,omitempty"`
+	Success  bool   `json:"success,omitempty" xml:"success,attr,omitempty" yaml:"success,omitempty"`
+	Failure  bool   `json:"failure,omitempty" xml:"failure,attr,omitempty" yaml:"failure,omitempty"`
+}
+
+// DistributionResult is the exact distribution of the totals of a roll, the residual being the probability of the
+// explosions left out
+type DistributionResult struct {
+	XMLName       xml.Name        `json:"-" xml:"distribution" yaml:"-"`
+	Expression    string          `json:"expression" xml:"expression" yaml:"expression"`
+	Mean          string          `json:"mean" xml:"mean" yaml:"mean"`
+	Variance      string          `json:"variance" xml:"variance" yaml:"variance"`
+	StdDev        string          `json:"stdDev" xml:"stdDev" yaml:"stdDev"`
+	Residual      string          `json:"residual" xml:"residual" yaml:"residual"`
+	Target        *int            `json:"target,omitempty" xml:"target,omitempty" yaml:"target,omitempty"`
+	AtLeastTarget *float64        `json:"atLeastTarget,omitempty" xml:"atLeastTarget,omitempty" yaml:"atLeastTarget,omitempty"`
+	Outcomes      []OutcomeResult `json:"outcomes" xml:"outcome" yaml:"outcomes"`
+}
+
+// OutcomeResult is the probability of a total, exactly as a fraction, and of that total or more
+type OutcomeResult struct {
+	Total       int     `json:"total" xml:"total,attr" yaml:"total"`
+	Probability float64 `json:"probability" xml:"probability,attr" yaml:"probability"`
+	AtLeast     float64 `json:"atLeast" xml:"atLeast,attr" yaml:"atLeast"`
+	Fraction    string  `json:"fraction" xml:"fraction,attr" yaml:"fraction"`
+}
+
 type DieResult struct {
 	XMLName xml.Name `json:"-" xml:"dieresult" yaml:"-"`
 	Die     int      `json:"die" xml:"die" yaml:"die"`
@@ -1,16 +1,14 @@
 package api
 
 import (
-	"context"
 	"encoding/xml"
 	"fmt"
-	"net"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/miekg/dns"
+	"utile.space/api/infrastructure/dnsclient"
 	"utile.space/api/utils"
 )
 
@@ -31,17 +29,11 @@ func DNSResolve(w http.ResponseWriter, r *http.Request) {
 	domain := mux.Vars(r)["domain"]
 
 	// Details: https://pkg.go.dev/net#Resolver.LookupHost
-	resolver := &net.Resolver{
-		PreferGo: true,
-		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-			d := net.Dialer{
-				Timeout: time.Millisecond * time.Duration(10000),
-			}
-			return d.DialContext(ctx, network, "1.1.1.1:53")
-		},
-	}
+	resolver := dnsclient.Resolver()
 
-	ip, err := resolver.LookupHost(context.Background(), domain)
+	ctx, done := dnsclient.ObserveLookup(r.Context(), "a", domain)
+	ip, err := resolver.LookupHost(ctx, domain)
+	ttl := done(err)
 
 	if err != nil || len(ip) == 0 {
 		http.Error(w, "Domain not found", http.StatusNotFound)
@@ -55,6 +47,7 @@ func DNSResolve(w http.ResponseWriter, r *http.Request) {
 	reply.Type = "dns"
 	reply.Resolution = dns
 
+	utils.CacheFor(w, ttl)
 	utils.Output(w, r.Header["Accept"], reply, dns.Addresses[0])
 }
 
@@ -73,17 +66,11 @@ func MXResolve(w http.ResponseWriter, r *http.Request) {
 	domain := mux.Vars(r)["domain"]
 
 	// Details: https://pkg.go.dev/net#Resolver.LookupMX
-	resolver := &net.Resolver{
-		PreferGo: true,
-		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-			d := net.Dialer{
-				Timeout: time.Millisecond * time.Duration(10000),
-			}
-			return d.DialContext(ctx, network, "1.1.1.1:53")
-		},
-	}
+	resolver := dnsclient.Resolver()
 
-	mx, err := resolver.LookupMX(context.Background(), domain)
+	ctx, done := dnsclient.ObserveLookup(r.Context(), "mx", domain)
+	mx, err := resolver.LookupMX(ctx, domain)
+	ttl := done(err)
 
 	if err != nil || len(mx) == 0 {
 		http.Error(w, "Domain not found", http.StatusNotFound)
@@ -107,6 +94,7 @@ func MXResolve(w http.ResponseWriter, r *http.Request) {
 
 	defaultOutput := fmt.Sprintf("%s %d", dns.Records[0].Host, dns.Records[0].Pref)
 
+	utils.CacheFor(w, ttl)
 	utils.Output(w, r.Header["Accept"], reply, defaultOutput)
 }
 
@@ -130,17 +118,11 @@ func NSResolve(w http.ResponseWriter, r *http.Request) {
 	domain := mux.Vars(r)["domain"]
 
 	// Details: https://pkg.go.dev/net#Resolver.LookupNS
-	resolver := &net.Resolver{
-		PreferGo: true,
-		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-			d := net.Dialer{
-				Timeout: time.Millisecond * time.Duration(10000),
-			}
-			return d.DialContext(ctx, network, "1.1.1.1:53")
-		},
-	}
+	resolver := dnsclient.Resolver()
 
-	ns, err := resolver.LookupNS(context.Background(), domain)
+	ctx, done := dnsclient.ObserveLookup(r.Context(), "ns", domain)
+	ns, err := resolver.LookupNS(ctx, domain)
+	ttl := done(err)
 
 	if err != nil || len(ns) == 0 {
 		http.Error(w, "Domain not found", http.StatusNotFound)
@@ -161,6 +143,7 @@ func NSResolve(w http.ResponseWriter, r *http.Request) {
 	reply.Type = "ns"
 	reply.Resolution = dns
 
+	utils.CacheFor(w, ttl)
 	utils.Output(w, r.Header["Accept"], reply, dns.Hosts[0])
 }
 
@@ -179,17 +162,11 @@ func TXTResolve(w http.ResponseWriter, r *http.Request) {
 	domain := mux.Vars(r)["domain"]
 
 	// Details: https://pkg.go.dev/net#Resolver.LookupTXT
-	resolver := &net.Resolver{
-		PreferGo: true,
-		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-			d := net.Dialer{
-				Timeout: time.Millisecond * time.Duration(10000),
-			}
-			return d.DialContext(ctx, network, "1.1.1.1:53")
-		},
-	}
+	resolver := dnsclient.Resolver()
 
-	txt, err := resolver.LookupTXT(context.Background(), domain)
+	ctx, done := dnsclient.ObserveLookup(r.Context(), "txt", domain)
+	txt, err := resolver.LookupTXT(ctx, domain)
+	ttl := done(err)
 
 	if err != nil || len(txt) == 0 {
 		http.Error(w, "Domain not found", http.StatusNotFound)
@@ -204,6 +181,7 @@ func TXTResolve(w http.ResponseWriter, r *http.Request) {
 	reply.Type = "txt"
 	reply.Resolution = dns
 
+	utils.CacheFor(w, ttl)
 	utils.Output(w, r.Header["Accept"], reply, dns.Values[0])
 }
 
@@ -222,17 +200,11 @@ func CNAMEResolve(w http.ResponseWriter, r *http.Request) {
 	domain := mux.Vars(r)["domain"]
 
 	// Details: https://pkg.go.dev/net#Resolver.LookupCNAME
-	resolver := &net.Resolver{
-		PreferGo: true,
-		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-			d := net.Dialer{
-				Timeout: time.Millisecond * time.Duration(10000),
-			}
-			return d.DialContext(ctx, network, "1.1.1.1:53")
-		},
-	}
+	resolver := dnsclient.Resolver()
 
-	cname, err := resolver.LookupCNAME(context.Background(), domain)
+	ctx, done := dnsclient.ObserveLookup(r.Context(), "cname", domain)
+	cname, err := resolver.LookupCNAME(ctx, domain)
+	ttl := done(err)
 
 	if err != nil || len(cname) == 0 {
 		http.Error(w, "Domain not found", http.StatusNotFound)
@@ -247,6 +219,7 @@ func CNAMEResolve(w http.ResponseWriter, r *http.Request) {
 	reply.Type = "cname"
 	reply.Resolution = dns
 
+	utils.CacheFor(w, ttl)
 	utils.Output(w, r.Header["Accept"], reply, dns.Value)
 }
 
@@ -264,10 +237,9 @@ type CNAMEResolved struct {
 func CAAResolve(w http.ResponseWriter, r *http.Request) {
 	// NOTE: Adding a dot at the end because the dns library is expecting a FQDN
 	domain := mux.Vars(r)["domain"] + "."
-	c := new(dns.Client)
 	m := new(dns.Msg)
 	m.SetQuestion(domain, dns.TypeCAA)
-	result, _, err := c.Exchange(m, "1.1.1.1:53")
+	result, err := dnsclient.Exchange(r.Context(), m, "caa")
 	if err != nil {
 		http.Error(w, "Domain not found", http.StatusNotFound)
 		return
@@ -295,6 +267,7 @@ func CAAResolve(w http.ResponseWriter, r *http.Request) {
 	reply.Type = "caa"
 	reply.Resolution = answer
 
+	utils.CacheFor(w, dnsclient.MinTTL(result.Answer))
 	utils.Output(w, r.Header["Accept"], reply, strconv.Itoa((int)(answer.Records[0].Flag))+" "+answer.Records[0].Tag+" "+answer.Records[0].Value)
 }
 
@@ -318,10 +291,9 @@ type CAARecord struct {
 func AAAAResolve(w http.ResponseWriter, r *http.Request) {
 	// NOTE: Adding a dot at the end because the dns library is expecting a FQDN
 	domain := mux.Vars(r)["domain"] + "."
-	c := new(dns.Client)
 	m := new(dns.Msg)
 	m.SetQuestion(domain, dns.TypeAAAA)
-	result, _, err := c.Exchange(m, "1.1.1.1:53")
+	result, err := dnsclient.Exchange(r.Context(), m, "aaaa")
 	if err != nil {
 		http.Error(w, "Domain not found", http.StatusNotFound)
 		return
@@ -347,6 +319,7 @@ func AAAAResolve(w http.ResponseWriter, r *http.Request) {
 	reply.Type = "aaaa"
 	reply.Resolution = answer
 
+	utils.CacheFor(w, dnsclient.MinTTL(result.Answer))
 	utils.Output(w, r.Header["Accept"], reply, answer.Hosts[0])
 }
 
@@ -365,17 +338,11 @@ func DMARCResolve(w http.ResponseWriter, r *http.Request) {
 	domain := "_dmarc." + mux.Vars(r)["domain"]
 
 	// Details: https://pkg.go.dev/net#Resolver.LookupTXT
-	resolver := &net.Resolver{
-		PreferGo: true,
-		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-			d := net.Dialer{
-				Timeout: time.Millisecond * time.Duration(10000),
-			}
-			return d.DialContext(ctx, network, "1.1.1.1:53")
-		},
-	}
+	resolver := dnsclient.Resolver()
 
-	txt, err := resolver.LookupTXT(context.Background(), domain)
+	ctx, done := dnsclient.ObserveLookup(r.Context(), "dmarc", domain)
+	txt, err := resolver.LookupTXT(ctx, domain)
+	ttl := done(err)
 
 	if err != nil || len(txt) == 0 {
 		http.Error(w, "Domain not found", http.StatusNotFound)
@@ -390,6 +357,7 @@ func DMARCResolve(w http.ResponseWriter, r *http.Request) {
 	reply.Type = "dmarc"
 	reply.Resolution = dns
 
+	utils.CacheFor(w, ttl)
 	utils.Output(w, r.Header["Accept"], reply, dns.Value)
 }
 
@@ -415,10 +383,9 @@ func PTRResolve(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// NOTE: Then lookup the ARPA domain PTR record
-	c := new(dns.Client)
 	m := new(dns.Msg)
 	m.SetQuestion(arpa, dns.TypePTR)
-	result, _, err := c.Exchange(m, "1.1.1.1:53")
+	result, err := dnsclient.Exchange(r.Context(), m, "ptr")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -444,6 +411,7 @@ func PTRResolve(w http.ResponseWriter, r *http.Request) {
 	reply.Type = "ptr"
 	reply.Resolution = answer
 
+	utils.CacheFor(w, dnsclient.MinTTL(result.Answer))
 	utils.Output(w, r.Header["Accept"], reply, answer.Domains[0])
 }
 
@@ -1,14 +1,21 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
-	"log"
+	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"utile.space/api/infrastructure/logging"
+	"utile.space/api/infrastructure/tracing"
 	"utile.space/api/utils"
 )
 
@@ -21,6 +28,8 @@ import (
 // @Success		200		{object}	LinksPage
 // @Router			/links [get]
 func GetLinksPage(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
 	databaseID := os.Getenv("NOTION_DATABASE_ID")
 	notionAPISecret := os.Getenv("NOTION_SECRET")
 
@@ -48,10 +57,15 @@ func GetLinksPage(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	request, err := http.NewRequestWithContext(r.Context(), "POST", "https://api.notion.com/v1/databases/"+databaseID+"/query", strings.NewReader(filter))
+	ctx, span := tracing.Start(r.Context(), "notion.query", attribute.String("notion.database_id", databaseID))
+	defer span.End()
+
+	request, err := http.NewRequestWithContext(ctx, "POST", "https://api.notion.com/v1/databases/"+databaseID+"/query", strings.NewReader(filter))
 
 	if err != nil {
-		log.Fatal(err)
+		logger.Error(err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
 	}
 
 	request.Header.Set("Authorization", "Bearer "+notionAPISecret)
@@ -61,14 +75,18 @@ func GetLinksPage(w http.ResponseWriter, r *http.Request) {
 	resp, err := client.Do(request)
 
 	if err != nil {
-		log.Print(err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		logger.Error(err)
 		return
 	}
 
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+
 	defer func() {
 		err := resp.Body.Close()
 		if err != nil {
-			log.Print(err)
+			logger.Error(err)
 		}
 	}()
 
@@ -76,7 +94,7 @@ func GetLinksPage(w http.ResponseWriter, r *http.Request) {
 
 	err = json.NewDecoder(resp.Body).Decode(&res)
 	if err != nil {
-		log.Print(err)
+		logger.Error(err)
 		return
 	}
 
@@ -182,3 +200,32 @@ type NotionTag struct {
 	Name  string `json:"name"`
 	Color string `json:"color"`
 }
+
+// CheckNotion makes sure the Notion credentials are set and accepted, reading the links database
+func CheckNotion(ctx context.Context) error {
+	databaseID := os.Getenv("NOTION_DATABASE_ID")
+	notionAPISecret := os.Getenv("NOTION_SECRET")
+
+	if databaseID == "" || notionAPISecret == "" {
+		return errors.New("NOTION_DATABASE_ID or NOTION_SECRET is not set")
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "GET", "https://api.notion.com/v1/databases/"+databaseID, nil)
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Authorization", "Bearer "+notionAPISecret)
+	request.Header.Set("Notion-Version", "2021-08-16")
+
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("notion answered %s", resp.Status)
+	}
+	return nil
+}
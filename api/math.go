@@ -2,103 +2,326 @@ package api
 
 import (
 	"encoding/xml"
-	"fmt"
-	"log"
+	"errors"
 	"net/http"
-	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
+	"utile.space/api/domain/mathws"
 	"utile.space/api/domain/services/math"
+	"utile.space/api/infrastructure/logging"
+	"utile.space/api/infrastructure/metrics"
 	"utile.space/api/utils"
 )
 
 // @Summary		Pi Value
-// @Description	Calculate Pi value up to 10K decimals
+// @Description	Calculate Pi value up to 100K decimals, or a range of its digits, in base 2, 10 or 16
 // @Tags			math
 // @Produce		json,xml,application/yaml,plain
-// @Success		200	{object}	BigNumberResult
+// @Param			digits	query		int	false	"Number of digits after the point, 10000 by default"
+// @Param			offset	query		int	false	"First digit after the point to return, requiring length"
+// @Param			length	query		int	false	"Number of digits after the point to return from offset, instead of the value"
+// @Param			base	query		int	false	"Base of the digits: 2, 10 (default) or 16"
+// @Success		200		{object}	BigNumberResult
+// @Failure		400		{object}	utils.ErrorResult
 // @Router			/math/pi [get]
 func CalculatePi(w http.ResponseWriter, r *http.Request) {
-	pi := math.Chudnovsky(10000)
-
-	var answer BigNumberResult
-	answer.Name = "Pi"
-	answer.Value = fmt.Sprintf("%.10000f", pi)
-
-	utils.Output(w, r.Header["Accept"], answer, answer.Value)
+	outputDigits(w, r, "pi", piDigits)
 }
 
 // @Summary		Tau Value
-// @Description	Calculate Tau value up to 10K decimals
+// @Description	Calculate Tau value up to 100K decimals, or a range of its digits, in base 2, 10 or 16
 // @Tags			math
 // @Produce		json,xml,application/yaml,plain
-// @Success		200	{object}	BigNumberResult
+// @Param			digits	query		int	false	"Number of digits after the point, 10000 by default"
+// @Param			offset	query		int	false	"First digit after the point to return, requiring length"
+// @Param			length	query		int	false	"Number of digits after the point to return from offset, instead of the value"
+// @Param			base	query		int	false	"Base of the digits: 2, 10 (default) or 16"
+// @Success		200		{object}	BigNumberResult
+// @Failure		400		{object}	utils.ErrorResult
 // @Router			/math/tau [get]
 func CalculateTau(w http.ResponseWriter, r *http.Request) {
-	tau := math.ChudnovskyTau(10000)
+	outputDigits(w, r, "tau", piDigits)
+}
+
+// @Summary		Constant Value
+// @Description	Calculate e, phi (golden ratio), ln2, catalan (Catalan's constant), zeta3 (Apéry's constant)
+// @Description	or the square root of an integer, like sqrt2, up to 100K decimals (10K for catalan),
+// @Description	or a range of its digits, in base 2, 10 or 16
+// @Tags			math
+// @Produce		json,xml,application/yaml,plain
+// @Param			constant	path		string	true	"e, phi, ln2, catalan, zeta3 or sqrt followed by an integer"
+// @Param			digits		query		int		false	"Number of digits after the point, 1000 by default"
+// @Param			offset		query		int		false	"First digit after the point to return, requiring length"
+// @Param			length		query		int		false	"Number of digits after the point to return from offset, instead of the value"
+// @Param			base		query		int		false	"Base of the digits: 2, 10 (default) or 16"
+// @Success		200			{object}	BigNumberResult
+// @Failure		400			{object}	utils.ErrorResult
+// @Failure		404			{object}	utils.ErrorResult
+// @Router			/math/{constant} [get]
+func CalculateConstant(w http.ResponseWriter, r *http.Request) {
+	outputDigits(w, r, mux.Vars(r)["constant"], defaultDigits)
+}
+
+const (
+	// piDigits have always been given by /math/pi and /math/tau
+	piDigits      = 10000
+	defaultDigits = 1000
+)
+
+// outputDigits answers with the digits of the constant asked by the digits, offset, length and base parameters
+func outputDigits(w http.ResponseWriter, r *http.Request, id string, digits int) {
+	accept := r.Header["Accept"]
+	query := r.URL.Query()
+
+	var offset, length int
+	base := 10
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"digits", &digits}, {"offset", &offset}, {"length", &length}, {"base", &base}} {
+		if value := query.Get(param.name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				utils.OutputError(w, accept, http.StatusBadRequest, "Invalid "+param.name)
+				return
+			}
+			*param.value = parsed
+		}
+	}
+
+	if query.Has("offset") && !query.Has("length") {
+		utils.OutputError(w, accept, http.StatusBadRequest, "Offset must be given along with length")
+		return
+	}
+
+	start := time.Now()
+
+	var name, value string
+	var err error
+	if query.Has("length") {
+		name, value, err = math.DigitsRange(id, offset, length, base)
+	} else {
+		name, value, err = math.Digits(id, digits, base)
+	}
+
+	switch {
+	case errors.Is(err, math.ErrUnknownConstant):
+		utils.OutputError(w, accept, http.StatusNotFound, "Constant not found")
+		return
+	case errors.Is(err, math.ErrUnsupportedBase):
+		utils.OutputError(w, accept, http.StatusBadRequest, "Base must be 2, 10 or 16")
+		return
+	case errors.Is(err, math.ErrDigitsOutOfRange) && query.Has("length"):
+		utils.OutputError(w, accept, http.StatusBadRequest,
+			"Offset and length must be positive, offset + length being at most "+strconv.Itoa(math.MaxDigitsOf(id, base)))
+		return
+	case errors.Is(err, math.ErrDigitsOutOfRange):
+		utils.OutputError(w, accept, http.StatusBadRequest, "Digits must be between 0 and "+strconv.Itoa(math.MaxDigitsOf(id, base)))
+		return
+	}
+	metrics.ObserveComputation(computationLabel(id), start)
 
 	var answer BigNumberResult
-	answer.Name = "Tau"
-	answer.Value = fmt.Sprintf("%.10000f", tau)
+	answer.Name = name
+	answer.Value = value
+
+	utils.CacheForever(w)
+	utils.Output(w, accept, answer, answer.Value)
+}
+
+// computationLabel keeps the metrics labels bounded, whatever the square roots asked
+func computationLabel(id string) string {
+	if strings.HasPrefix(id, "sqrt") {
+		return "sqrt"
+	}
+	return id
+}
+
+// @Summary		Expression evaluation
+// @Description	Evaluate an arithmetic expression with + - * / ^ !, parentheses, the functions sqrt, ln, log, exp, sin, cos, tan,
+// @Description	abs, pow and factorial, and the constants pi, tau, e, phi, ln2, catalan, zeta3 and sqrt followed by an integer,
+// @Description	with up to 1000 significant digits. The syntax and evaluation errors give the position of the character in fault, from 1.
+// @Tags			math
+// @Produce		json,xml,application/yaml,plain
+// @Param			expr	query		string	true	"Expression, like 2^0.5 * sin(pi / 4)"
+// @Param			digits	query		int		false	"Number of significant digits, 50 by default"
+// @Param			tree	query		bool	false	"Add the parse tree of the expression to the result"
+// @Success		200		{object}	BigNumberResult
+// @Failure		400		{object}	utils.ErrorResult
+// @Router			/math/eval [get]
+func EvaluateExpression(w http.ResponseWriter, r *http.Request) {
+	accept := r.Header["Accept"]
+	query := r.URL.Query()
+
+	expr := query.Get("expr")
+	if expr == "" {
+		utils.OutputError(w, accept, http.StatusBadRequest, "expr is required")
+		return
+	}
+
+	digits := defaultEvalDigits
+	if value := query.Get("digits"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			utils.OutputError(w, accept, http.StatusBadRequest, "Invalid digits")
+			return
+		}
+		digits = parsed
+	}
+
+	tree, err := strconv.ParseBool(query.Get("tree"))
+	if err != nil && query.Has("tree") {
+		utils.OutputError(w, accept, http.StatusBadRequest, "Invalid tree")
+		return
+	}
+
+	start := time.Now()
 
-	utils.Output(w, r.Header["Accept"], answer, answer.Value)
+	value, node, err := math.Eval(expr, digits)
+	var exprErr *math.ExprError
+	switch {
+	case errors.Is(err, math.ErrDigitsOutOfRange):
+		utils.OutputError(w, accept, http.StatusBadRequest, "Digits must be between 1 and "+strconv.Itoa(math.MaxEvalDigits))
+		return
+	case errors.As(err, &exprErr):
+		utils.OutputError(w, accept, http.StatusBadRequest, exprErr.Error())
+		return
+	case err != nil:
+		utils.OutputError(w, accept, http.StatusInternalServerError, "Evaluation failed")
+		return
+	}
+	metrics.ObserveComputation("eval", start)
+
+	answer := BigNumberResult{Name: expr, Value: value}
+	if tree {
+		answer.Tree = node
+	}
+
+	utils.CacheForever(w)
+	utils.Output(w, accept, answer, answer.Value)
+}
+
+const defaultEvalDigits = 50
+
+// @Summary		Digits search
+// @Description	Find where a sequence of digits appears among the million digits of pi or tau, the offsets being the ones
+// @Description	of the digits after the point, like for the offset parameter of /math/pi
+// @Tags			math
+// @Produce		json,xml,application/yaml,plain
+// @Param			constant	path		string	true	"pi or tau"
+// @Param			q			query		string	true	"Digits to find"
+// @Param			page		query		int		false	"Page of offsets, from 0"
+// @Param			size		query		int		false	"Number of offsets per page, 100 by default, up to 1000"
+// @Success		200			{object}	SearchResult
+// @Failure		400			{object}	utils.ErrorResult
+// @Failure		404			{object}	utils.ErrorResult
+// @Router			/math/{constant}/search [get]
+func SearchDigits(w http.ResponseWriter, r *http.Request) {
+	accept := r.Header["Accept"]
+	query := r.URL.Query()
+
+	var result SearchResult
+	result.Query = query.Get("q")
+	result.Size = defaultSearchSize
+
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"page", &result.Page}, {"size", &result.Size}} {
+		if value := query.Get(param.name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				utils.OutputError(w, accept, http.StatusBadRequest, "Invalid "+param.name)
+				return
+			}
+			*param.value = parsed
+		}
+	}
+	if result.Size > maxSearchSize {
+		utils.OutputError(w, accept, http.StatusBadRequest, "Size must be at most "+strconv.Itoa(maxSearchSize))
+		return
+	}
+
+	id := mux.Vars(r)["constant"]
+
+	var err error
+	result.Total, result.Offsets, err = math.Search(id, result.Query, result.Page, result.Size)
+	switch {
+	case errors.Is(err, math.ErrNotIndexed):
+		utils.OutputError(w, accept, http.StatusNotFound, "Constant not indexed")
+		return
+	case errors.Is(err, math.ErrInvalidSearch):
+		utils.OutputError(w, accept, http.StatusBadRequest,
+			"q must be 1 to "+strconv.Itoa(math.MaxSearchLength)+" digits, page positive and size strictly positive")
+		return
+	}
+	result.Name = strings.ToUpper(id[:1]) + id[1:]
+
+	utils.CacheForever(w)
+	utils.Output(w, accept, result, joinOffsets(result.Offsets))
+}
+
+const (
+	defaultSearchSize = 100
+	maxSearchSize     = 1000
+)
+
+func joinOffsets(offsets []int) string {
+	values := make([]string, len(offsets))
+	for i, offset := range offsets {
+		values[i] = strconv.Itoa(offset)
+	}
+	return strings.Join(values, ", ")
+}
+
+type SearchResult struct {
+	XMLName xml.Name `json:"-" xml:"search" yaml:"-"`
+	Name    string   `json:"name" xml:"name" yaml:"name"`
+	Query   string   `json:"query" xml:"query" yaml:"query"`
+	Total   int      `json:"total" xml:"total" yaml:"total"`
+	Page    int      `json:"page" xml:"page" yaml:"page"`
+	Size    int      `json:"size" xml:"size" yaml:"size"`
+	Offsets []int    `json:"offsets" xml:"offset" yaml:"offsets"`
 }
 
 type BigNumberResult struct {
 	XMLName xml.Name `json:"-" xml:"bignumber" yaml:"-"`
 	Name    string   `json:"name" xml:"name" yaml:"name"`
 	Value   string   `json:"value" xml:"value" yaml:"value"`
+	// Parse tree of an evaluated expression, when asked.
+	Tree *math.Node `json:"tree,omitempty" xml:"tree,omitempty" yaml:"tree,omitempty"`
 }
 
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
-		return true
+		return checkOrigin(r)
 	},
+	EnableCompression: true,
 }
 
 // @Summary		MathWebsocket to get pi and tau by page up to 1M digits
-// @Description	Websocket to get pi and tau by page up to 1M digits. It will switch protocols as requested.
+// @Description	Websocket answering JSON requests like {"id": "1", "command": "page", "constant": "pi", "page": 0, "size": 1000}
+// @Description	with replies like {"id": "1", "command": "page", "result": {...}} or {"id": "1", "command": "page", "error": {"status": 400, "message": "..."}}.
+// @Description	The commands are page (size up to 10000), stream (the pages from page until the end, or a stop with the same id,
+// @Description	the last reply being done), stop, constant (with digits) and search (with query, page and size).
+// @Description	The text commands "pi <page>, <page size>", "<constant> <digits>" and "search <constant> <digits>" are still answered with text.
+// @Description	It will switch protocols as requested.
 // @Tags			math
 // @Success		101
 // @Router			/math/ws [get]
 func MathWebsocket(w http.ResponseWriter, r *http.Request) {
-	c, err := upgrader.Upgrade(w, r, nil)
+	logger := logging.FromContext(r.Context())
+
+	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		fmt.Print("upgrade:", err)
+		logger.Error("upgrade:", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
-	defer c.Close()
-	for {
-		mt, message, err := c.ReadMessage()
-		if err != nil {
-			log.Println("read:", err)
-			continue
-		}
-		log.Printf("recv: %s", message)
-
-		r := regexp.MustCompile(`^(pi|tau)\s+([0-9]+),\s*([0-9]+)$`)
-		subMatch := r.FindStringSubmatch(string(message))
 
-		// pi or tau
-		if subMatch != nil {
-			page, err := strconv.Atoi(subMatch[2])
-			if err != nil {
-				log.Println("write:", err)
-				continue
-			}
-			pageSize, err := strconv.Atoi(subMatch[3])
-			if err != nil {
-				log.Println("write:", err)
-				continue
-			}
-
-			err = c.WriteMessage(mt, []byte(math.ReadNextPage(subMatch[1], page, pageSize)))
-			if err != nil {
-				log.Println("write:", err)
-				continue
-			}
-		}
-	}
+	mathws.NewClient(conn, math.AssetsDir, logger).Serve(r.Context())
 }
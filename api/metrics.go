@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"math"
+	"time"
+
+	battleships "utile.space/api/domain/entities"
+	"utile.space/api/domain/spectrum"
+	"utile.space/api/infrastructure/metrics"
+)
+
+// statsTimeout bounds the wait for the counts of a hub during a scrape
+const statsTimeout = time.Second
+
+// battleshipsGauge reads a count of the battleships hub through its runner, NaN when it does not answer in time
+func battleshipsGauge(count func(battleships.Stats) int) func() float64 {
+	return func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), statsTimeout)
+		defer cancel()
+
+		stats, err := getHub(context.Background()).Stats(ctx)
+		if err != nil {
+			return math.NaN()
+		}
+		return float64(count(stats))
+	}
+}
+
+// spectrumGauge reads a count of the spectrum hub through its runner, NaN when it does not answer in time
+func spectrumGauge(count func(spectrum.Stats) int) func() float64 {
+	return func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), statsTimeout)
+		defer cancel()
+
+		stats, err := getSpectrumHub(context.Background()).Stats(ctx)
+		if err != nil {
+			return math.NaN()
+		}
+		return float64(count(stats))
+	}
+}
+
+// RegisterMetrics exposes the same live figures as BattleshipsStats and the spectrum hub as gauges
+func RegisterMetrics() {
+	metrics.Gauge("battleships", "online_players", "Number of players currently online.", battleshipsGauge(func(s battleships.Stats) int {
+		return s.OnlinePlayers
+	}))
+	metrics.Gauge("battleships", "pending_matches", "Number of matches waiting for a second player.", battleshipsGauge(func(s battleships.Stats) int {
+		return s.PendingMatches
+	}))
+	metrics.Gauge("battleships", "ongoing_matches", "Number of matches being played.", battleshipsGauge(func(s battleships.Stats) int {
+		return s.OngoingMatches
+	}))
+	metrics.Gauge("battleships", "finished_matches", "Number of matches over.", battleshipsGauge(func(s battleships.Stats) int {
+		return s.FinishedMatches
+	}))
+	metrics.Gauge("spectrum", "online_users", "Number of spectrum users currently online.", spectrumGauge(func(s spectrum.Stats) int {
+		return s.OnlineUsers
+	}))
+	metrics.Gauge("spectrum", "active_rooms", "Number of spectrum rooms still open.", spectrumGauge(func(s spectrum.Stats) int {
+		return s.ActiveRooms
+	}))
+}
@@ -0,0 +1,354 @@
+package api
+
+import (
+	"encoding/xml"
+	"errors"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"utile.space/api/domain/services/math"
+	"utile.space/api/infrastructure/metrics"
+	"utile.space/api/utils"
+)
+
+const (
+	// defaultBudget and maxBudget bound the time spent factorizing or searching primes, in milliseconds
+	defaultBudget = 2000
+	maxBudget     = 10000
+
+	// maxNumbers bounds the numbers of a gcd or lcm
+	maxNumbers = 100
+)
+
+var invalidInteger = "must be an integer of at most " + strconv.Itoa(math.MaxIntegerDigits) + " digits"
+
+// integerParams reads the integer query parameters, answering 400 when one is missing or invalid
+func integerParams(w http.ResponseWriter, r *http.Request, names ...string) ([]*big.Int, bool) {
+	query := r.URL.Query()
+
+	var values []*big.Int
+	for _, name := range names {
+		value, err := math.ParseInteger(query.Get(name))
+		if err != nil {
+			utils.OutputError(w, r.Header["Accept"], http.StatusBadRequest, name+" "+invalidInteger)
+			return nil, false
+		}
+		values = append(values, value)
+	}
+	return values, true
+}
+
+// budgetParam reads the time budget query parameter, answering 400 when it is invalid
+func budgetParam(w http.ResponseWriter, r *http.Request) (time.Duration, bool) {
+	budget := defaultBudget
+	if value := r.URL.Query().Get("budget"); value != "" {
+		var err error
+		budget, err = strconv.Atoi(value)
+		if err != nil || budget <= 0 || budget > maxBudget {
+			utils.OutputError(w, r.Header["Accept"], http.StatusBadRequest, "Budget must be between 1 and "+strconv.Itoa(maxBudget)+" milliseconds")
+			return 0, false
+		}
+	}
+	return time.Duration(budget) * time.Millisecond, true
+}
+
+// @Summary		Primality
+// @Description	Tell whether an integer of up to 1000 digits is prime, with Miller-Rabin and Baillie-PSW tests, along with the previous and next primes
+// @Description	found within a time budget
+// @Tags			math
+// @Produce		json,xml,application/yaml,plain
+// @Param			n		path		string	true	"Integer"
+// @Param			budget	query		int		false	"Time budget in milliseconds of the search of the previous and next primes, 2000 by default, up to 10000"
+// @Success		200		{object}	PrimeResult
+// @Failure		400		{object}	utils.ErrorResult
+// @Router			/math/prime/{n} [get]
+func CheckPrime(w http.ResponseWriter, r *http.Request) {
+	accept := r.Header["Accept"]
+
+	n, err := math.ParseInteger(mux.Vars(r)["n"])
+	if err != nil {
+		utils.OutputError(w, accept, http.StatusBadRequest, "n "+invalidInteger)
+		return
+	}
+
+	budget, ok := budgetParam(w, r)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	deadline := start.Add(budget)
+
+	result := PrimeResult{
+		Number: n.String(),
+		Prime:  math.IsPrime(n),
+	}
+	next, nextErr := math.NextPrime(n, deadline)
+	if nextErr == nil {
+		result.Next = next.String()
+	}
+	previous, previousErr := math.PreviousPrime(n, deadline)
+	if previousErr == nil {
+		result.Previous = previous.String()
+	}
+	metrics.ObserveComputation("prime", start)
+
+	// NOTE: the primes not found within the budget may be found by another request with a larger budget
+	if !errors.Is(nextErr, math.ErrOutOfTime) && !errors.Is(previousErr, math.ErrOutOfTime) {
+		utils.CacheForever(w)
+	}
+	utils.Output(w, accept, result, strconv.FormatBool(result.Prime))
+}
+
+// @Summary		Factorization
+// @Description	Decompose an integer of up to 1000 digits in prime factors by trial division and Pollard's rho within a time budget,
+// @Description	the factors left composite when the budget runs out being given with prime false
+// @Tags			math
+// @Produce		json,xml,application/yaml,plain
+// @Param			n		path		string	true	"Strictly positive integer"
+// @Param			budget	query		int		false	"Time budget in milliseconds, 2000 by default, up to 10000"
+// @Success		200		{object}	FactorizationResult
+// @Failure		400		{object}	utils.ErrorResult
+// @Router			/math/factor/{n} [get]
+func Factorize(w http.ResponseWriter, r *http.Request) {
+	accept := r.Header["Accept"]
+
+	n, err := math.ParseInteger(mux.Vars(r)["n"])
+	if err != nil {
+		utils.OutputError(w, accept, http.StatusBadRequest, "n "+invalidInteger)
+		return
+	}
+
+	budget, ok := budgetParam(w, r)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+
+	factors, complete, err := math.Factorize(n, budget)
+	if errors.Is(err, math.ErrNotPositive) {
+		utils.OutputError(w, accept, http.StatusBadRequest, "n must be strictly positive")
+		return
+	}
+	metrics.ObserveComputation("factor", start)
+
+	result := FactorizationResult{Number: n.String(), Complete: complete, Factors: []FactorResult{}}
+	terms := make([]string, len(factors))
+	for i, factor := range factors {
+		result.Factors = append(result.Factors, FactorResult{
+			Value:    factor.Value.String(),
+			Exponent: factor.Exponent,
+			Prime:    factor.Prime,
+		})
+
+		terms[i] = factor.Value.String()
+		if factor.Exponent > 1 {
+			terms[i] += "^" + strconv.Itoa(factor.Exponent)
+		}
+	}
+
+	// NOTE: an incomplete factorization may be completed by another request with a larger budget
+	if complete {
+		utils.CacheForever(w)
+	}
+	utils.Output(w, accept, result, strings.Join(terms, " * "))
+}
+
+// @Summary		Greatest common divisor
+// @Description	Compute the greatest common divisor of up to 100 integers
+// @Tags			math
+// @Produce		json,xml,application/yaml,plain
+// @Param			n	query		[]string	true	"Integers"	collectionFormat(multi)
+// @Success		200	{object}	BigNumberResult
+// @Failure		400	{object}	utils.ErrorResult
+// @Router			/math/gcd [get]
+func GreatestCommonDivisor(w http.ResponseWriter, r *http.Request) {
+	outputNumbers(w, r, "GCD", math.GCD)
+}
+
+// @Summary		Least common multiple
+// @Description	Compute the least common multiple of up to 100 integers
+// @Tags			math
+// @Produce		json,xml,application/yaml,plain
+// @Param			n	query		[]string	true	"Integers"	collectionFormat(multi)
+// @Success		200	{object}	BigNumberResult
+// @Failure		400	{object}	utils.ErrorResult
+// @Router			/math/lcm [get]
+func LeastCommonMultiple(w http.ResponseWriter, r *http.Request) {
+	outputNumbers(w, r, "LCM", math.LCM)
+}
+
+// outputNumbers answers with the result of the function of the n query parameters
+func outputNumbers(w http.ResponseWriter, r *http.Request, name string, f func(numbers ...*big.Int) *big.Int) {
+	accept := r.Header["Accept"]
+
+	params := r.URL.Query()["n"]
+	if len(params) == 0 || len(params) > maxNumbers {
+		utils.OutputError(w, accept, http.StatusBadRequest, "n must be given 1 to "+strconv.Itoa(maxNumbers)+" times")
+		return
+	}
+
+	numbers := make([]*big.Int, len(params))
+	for i, param := range params {
+		n, err := math.ParseInteger(param)
+		if err != nil {
+			utils.OutputError(w, accept, http.StatusBadRequest, "n "+invalidInteger)
+			return
+		}
+		numbers[i] = n
+	}
+
+	answer := BigNumberResult{Name: name, Value: f(numbers...).String()}
+
+	utils.CacheForever(w)
+	utils.Output(w, accept, answer, answer.Value)
+}
+
+// @Summary		Modular inverse
+// @Description	Compute x between 0 and m - 1 such that a x ≡ 1 mod m
+// @Tags			math
+// @Produce		json,xml,application/yaml,plain
+// @Param			a	query		string	true	"Integer"
+// @Param			m	query		string	true	"Strictly positive modulus"
+// @Success		200	{object}	BigNumberResult
+// @Failure		400	{object}	utils.ErrorResult
+// @Router			/math/modinv [get]
+func ModularInverse(w http.ResponseWriter, r *http.Request) {
+	params, ok := integerParams(w, r, "a", "m")
+	if !ok {
+		return
+	}
+
+	value, err := math.ModInverse(params[0], params[1])
+	outputModular(w, r, "ModInverse", value, err)
+}
+
+// @Summary		Modular exponentiation
+// @Description	Compute base^exponent mod modulus between 0 and modulus - 1, a negative exponent being the one of the modular inverse of base
+// @Tags			math
+// @Produce		json,xml,application/yaml,plain
+// @Param			base		query		string	true	"Integer"
+// @Param			exponent	query		string	true	"Integer"
+// @Param			modulus		query		string	true	"Strictly positive modulus"
+// @Success		200			{object}	BigNumberResult
+// @Failure		400			{object}	utils.ErrorResult
+// @Router			/math/modpow [get]
+func ModularPower(w http.ResponseWriter, r *http.Request) {
+	params, ok := integerParams(w, r, "base", "exponent", "modulus")
+	if !ok {
+		return
+	}
+
+	value, err := math.ModPow(params[0], params[1], params[2])
+	outputModular(w, r, "ModPow", value, err)
+}
+
+func outputModular(w http.ResponseWriter, r *http.Request, name string, value *big.Int, err error) {
+	accept := r.Header["Accept"]
+
+	switch {
+	case errors.Is(err, math.ErrInvalidModulus):
+		utils.OutputError(w, accept, http.StatusBadRequest, "Modulus must be strictly positive")
+		return
+	case errors.Is(err, math.ErrNotInvertible):
+		utils.OutputError(w, accept, http.StatusBadRequest, "Not invertible, the number and the modulus not being coprime")
+		return
+	}
+
+	answer := BigNumberResult{Name: name, Value: value.String()}
+
+	utils.CacheForever(w)
+	utils.Output(w, accept, answer, answer.Value)
+}
+
+// @Summary		Number conversion
+// @Description	Convert an integer or a fraction between the bases from 2 to 36, roman numerals, scientific notation, English words and fractions p/q.
+// @Description	The repeating digits of a fraction are between parentheses, like 0.1(6) for 1/6, both in the value and in the result,
+// @Description	which is exact unless a period is longer than 1000 digits or a number in scientific notation has no finite decimal expansion.
+// @Tags			math
+// @Produce		json,xml,application/yaml,plain
+// @Param			value	query		string	true	"Number, up to 1000 characters"
+// @Param			from	query		string	false	"Format of the value, a base from 2 to 36, binary, octal, decimal, hexadecimal, roman, scientific, words or fraction, 10 by default"
+// @Param			to		query		string	false	"Format of the result, like the one of the value, 10 by default"
+// @Success		200		{object}	ConversionResult
+// @Failure		400		{object}	utils.ErrorResult
+// @Router			/math/convert [get]
+func ConvertNumber(w http.ResponseWriter, r *http.Request) {
+	accept := r.Header["Accept"]
+	query := r.URL.Query()
+
+	from, to := query.Get("from"), query.Get("to")
+	if from == "" {
+		from = "10"
+	}
+	if to == "" {
+		to = "10"
+	}
+	for _, format := range []string{from, to} {
+		if !math.ValidFormat(format) {
+			utils.OutputError(w, accept, http.StatusBadRequest, "Unknown format "+format+", expecting a base from 2 to 36, roman, scientific, words or fraction")
+			return
+		}
+	}
+
+	value := query.Get("value")
+	number, err := math.ParseNumber(value, from)
+	if err != nil {
+		utils.OutputError(w, accept, http.StatusBadRequest, "Value must be a number in format "+from+": "+err.Error())
+		return
+	}
+
+	converted, exact, err := math.FormatNumber(number, to)
+	if err != nil {
+		utils.OutputError(w, accept, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result := ConversionResult{
+		Value:    value,
+		From:     from,
+		To:       to,
+		Result:   converted,
+		Exact:    exact,
+		Rational: number.RatString(),
+	}
+
+	utils.CacheForever(w)
+	utils.Output(w, accept, result, result.Result)
+}
+
+type PrimeResult struct {
+	XMLName  xml.Name `json:"-" xml:"primality" yaml:"-"`
+	Number   string   `json:"number" xml:"number" yaml:"number"`
+	Prime    bool     `json:"prime" xml:"prime" yaml:"prime"`
+	Previous string   `json:"previous,omitempty" xml:"previous,omitempty" yaml:"previous,omitempty"`
+	Next     string   `json:"next,omitempty" xml:"next,omitempty" yaml:"next,omitempty"`
+}
+
+type FactorizationResult struct {
+	XMLName  xml.Name       `json:"-" xml:"factorization" yaml:"-"`
+	Number   string         `json:"number" xml:"number" yaml:"number"`
+	Complete bool           `json:"complete" xml:"complete" yaml:"complete"`
+	Factors  []FactorResult `json:"factors" xml:"factor" yaml:"factors"`
+}
+
+type FactorResult struct {
+	Value    string `json:"value" xml:"value,attr" yaml:"value"`
+	Exponent int    `json:"exponent" xml:"exponent,attr" yaml:"exponent"`
+	Prime    bool   `json:"prime" xml:"prime,attr" yaml:"prime"`
+}
+
+type ConversionResult struct {
+	XMLName  xml.Name `json:"-" xml:"conversion" yaml:"-"`
+	Value    string   `json:"value" xml:"value" yaml:"value"`
+	From     string   `json:"from" xml:"from" yaml:"from"`
+	To       string   `json:"to" xml:"to" yaml:"to"`
+	Result   string   `json:"result" xml:"result" yaml:"result"`
+	Exact    bool     `json:"exact" xml:"exact" yaml:"exact"`
+	Rational string   `json:"rational" xml:"rational" yaml:"rational"`
+}
@@ -0,0 +1,565 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gopkg.in/yaml.v2"
+	"utile.space/api/domain/services/random"
+	"utile.space/api/infrastructure/ratelimit"
+	"utile.space/api/utils"
+)
+
+const (
+	// maxRandomCount bounds the values drawn by a request
+	maxRandomCount = 1000
+
+	// maxShuffleBody bounds the size of the body of a shuffle request, in bytes
+	maxShuffleBody = 1 << 20
+
+	// maxShuffleItems bounds the items of a shuffle request
+	maxShuffleItems = 100000
+
+	// maxCardLength bounds the characters of the name of a card of a custom deck
+	maxCardLength = 64
+)
+
+// randomCount reads the count query parameter, 1 by default, answering 400 when it is not between 1 and maxRandomCount
+func randomCount(w http.ResponseWriter, r *http.Request) (int, bool) {
+	count := 1
+	if c := r.URL.Query().Get("count"); c != "" {
+		var err error
+		if count, err = strconv.Atoi(c); err != nil || count < 1 || count > maxRandomCount {
+			utils.OutputError(w, r.Header["Accept"], http.StatusBadRequest, "Count must be between 1 and "+strconv.Itoa(maxRandomCount))
+			return 0, false
+		}
+	}
+	return count, true
+}
+
+// @Summary		Flip coins
+// @Description	Flip fair coins with crypto/rand
+// @Tags			random
+// @Produce		json,xml,application/yaml,plain
+// @Param			count	query		int	false	"Number of coins, 1 by default, up to 1000"
+// @Success		200		{object}	CoinsResult
+// @Failure		400		{object}	utils.ErrorResult
+// @Router			/random/coins [get]
+func FlipCoins(w http.ResponseWriter, r *http.Request) {
+	count, ok := randomCount(w, r)
+	if !ok {
+		return
+	}
+
+	result := CoinsResult{Flips: make([]string, count)}
+	for i := range result.Flips {
+		if random.Crypto.Intn(2) == 0 {
+			result.Flips[i] = "heads"
+			result.Heads++
+		} else {
+			result.Flips[i] = "tails"
+			result.Tails++
+		}
+	}
+
+	utils.Output(w, r.Header["Accept"], result, strings.Join(result.Flips, " "))
+}
+
+// @Summary		Create a deck
+// @Description	Shuffle a standard deck of 52 cards written like AS for the ace of spades, 10H or QD, with the two jokers JK when asked,
+// @Description	or a custom deck of up to 1000 cards, whose cards are then drawn with /random/decks/{id}/draw without replacement for 24 hours.
+// @Description	A client can create 100 decks at most during these 24 hours.
+// @Tags			random
+// @Produce		json,xml,application/yaml,plain
+// @Param			card	query		[]string	false	"Card of a custom deck, repeated for each card"	collectionFormat(multi)
+// @Param			jokers	query		bool		false	"Add the two jokers to the standard deck"
+// @Success		201		{object}	DeckResult
+// @Failure		400		{object}	utils.ErrorResult
+// @Failure		429		{object}	utils.ErrorResult
+// @Failure		503		{object}	utils.ErrorResult
+// @Router			/random/decks [post]
+func CreateDeck(decks *random.Decks) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accept := r.Header["Accept"]
+		query := r.URL.Query()
+
+		cards := query["card"]
+		for _, card := range cards {
+			if card == "" || len(card) > maxCardLength {
+				utils.OutputError(w, accept, http.StatusBadRequest, "Cards must be named with 1 to "+strconv.Itoa(maxCardLength)+" characters")
+				return
+			}
+		}
+		if len(cards) > random.MaxDeckCards {
+			utils.OutputError(w, accept, http.StatusBadRequest, "A deck has at most "+strconv.Itoa(random.MaxDeckCards)+" cards")
+			return
+		}
+		if len(cards) == 0 {
+			cards = random.StandardDeck(query.Get("jokers") == "true")
+		}
+
+		id, expires, err := decks.Create(ratelimit.ClientFromContext(r.Context()), cards)
+		if errors.Is(err, random.ErrTooManyClientDecks) {
+			utils.OutputError(w, accept, http.StatusTooManyRequests, "Too many decks created, wait for some to expire")
+			return
+		}
+		if err != nil {
+			utils.OutputError(w, accept, http.StatusServiceUnavailable, "No more decks can be created for now")
+			return
+		}
+
+		result := DeckResult{ID: id, Remaining: len(cards), Expires: expires.UTC()}
+
+		w.WriteHeader(http.StatusCreated)
+		utils.Output(w, accept, result, result.ID)
+	}
+}
+
+// @Summary		Draw cards
+// @Description	Draw cards from the top of a shuffled deck, without replacement
+// @Tags			random
+// @Produce		json,xml,application/yaml,plain
+// @Param			id		path		string	true	"ID of the deck"
+// @Param			count	query		int		false	"Number of cards, 1 by default, up to 1000"
+// @Success		200		{object}	DrawResult
+// @Failure		400		{object}	utils.ErrorResult
+// @Failure		404		{object}	utils.ErrorResult
+// @Failure		409		{object}	utils.ErrorResult
+// @Router			/random/decks/{id}/draw [post]
+func DrawCards(decks *random.Decks) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accept := r.Header["Accept"]
+		id := mux.Vars(r)["id"]
+
+		count, ok := randomCount(w, r)
+		if !ok {
+			return
+		}
+
+		cards, remaining, err := decks.Draw(id, count)
+		if errors.Is(err, random.ErrUnknownDeck) {
+			utils.OutputError(w, accept, http.StatusNotFound, "Unknown or expired deck")
+			return
+		} else if errors.Is(err, random.ErrNotEnoughCards) {
+			utils.OutputError(w, accept, http.StatusConflict, "Only "+strconv.Itoa(remaining)+" cards left")
+			return
+		}
+
+		result := DrawResult{ID: id, Cards: cards, Remaining: remaining}
+
+		utils.Output(w, accept, result, strings.Join(result.Cards, " "))
+	}
+}
+
+// item is an item to shuffle, a JSON scalar other than a string being kept as written
+type item string
+
+func (i *item) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case string:
+		*i = item(v)
+	case []interface{}, map[string]interface{}:
+		return errors.New("items must be scalars")
+	default:
+		*i = item(bytes.TrimSpace(data))
+	}
+	return nil
+}
+
+// readItems decodes the body according to its content type, a JSON or YAML list, or lines, the empty ones being skipped
+func readItems(r *http.Request) ([]string, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var items []string
+	switch mediaType {
+	case "application/json":
+		var decoded []item
+		err = json.Unmarshal(body, &decoded)
+		for _, i := range decoded {
+			items = append(items, string(i))
+		}
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		err = yaml.Unmarshal(body, &items)
+	default:
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				items = append(items, line)
+			}
+		}
+		err = scanner.Err()
+	}
+	return items, err
+}
+
+// @Summary		Shuffle a list
+// @Description	Shuffle up to 100000 items given as a JSON or YAML list, or as lines, every order being as likely
+// @Tags			random
+// @Accept			json,application/yaml,plain
+// @Produce		json,xml,application/yaml,plain
+// @Param			items	body		[]string	true	"Items to shuffle"
+// @Success		200		{object}	ShuffleResult
+// @Failure		400		{object}	utils.ErrorResult
+// @Failure		413		{object}	utils.ErrorResult
+// @Router			/random/shuffle [post]
+func ShuffleItems(w http.ResponseWriter, r *http.Request) {
+	accept := r.Header["Accept"]
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxShuffleBody)
+	items, err := readItems(r)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		utils.OutputError(w, accept, http.StatusRequestEntityTooLarge, "Body larger than "+strconv.Itoa(maxShuffleBody)+" bytes")
+		return
+	} else if err != nil {
+		utils.OutputError(w, accept, http.StatusBadRequest, "Items must be a JSON or YAML list, or lines")
+		return
+	}
+	if len(items) == 0 || len(items) > maxShuffleItems {
+		utils.OutputError(w, accept, http.StatusBadRequest, "Between 1 and "+strconv.Itoa(maxShuffleItems)+" items can be shuffled")
+		return
+	}
+
+	random.Shuffle(random.Crypto, items)
+	result := ShuffleResult{Items: items}
+
+	utils.Output(w, accept, result, strings.Join(result.Items, "\n"))
+}
+
+// parseOption reads an option like red:3, the weight after the last colon being 1 when there is none
+func parseOption(option string) (string, float64, error) {
+	if i := strings.LastIndexByte(option, ':'); i >= 0 {
+		weight, err := strconv.ParseFloat(option[i+1:], 64)
+		return option[:i], weight, err
+	}
+	return option, 1, nil
+}
+
+// @Summary		Pick at random
+// @Description	Pick options with a probability proportional to their weight, each pick being drawn among all the options
+// @Tags			random
+// @Produce		json,xml,application/yaml,plain
+// @Param			option	query		[]string	true	"Option, with its weight after a colon like red:3, 1 by default"	collectionFormat(multi)
+// @Param			count	query		int			false	"Number of picks, 1 by default, up to 1000"
+// @Success		200		{object}	PickResult
+// @Failure		400		{object}	utils.ErrorResult
+// @Router			/random/pick [get]
+func PickOptions(w http.ResponseWriter, r *http.Request) {
+	accept := r.Header["Accept"]
+
+	count, ok := randomCount(w, r)
+	if !ok {
+		return
+	}
+
+	options := r.URL.Query()["option"]
+	if len(options) == 0 || len(options) > maxRandomCount {
+		utils.OutputError(w, accept, http.StatusBadRequest, "Between 1 and "+strconv.Itoa(maxRandomCount)+" options can be given")
+		return
+	}
+	names := make([]string, len(options))
+	weights := make([]float64, len(options))
+	for i, option := range options {
+		var err error
+		if names[i], weights[i], err = parseOption(option); err != nil {
+			utils.OutputError(w, accept, http.StatusBadRequest, "Invalid weight of option "+option)
+			return
+		}
+	}
+
+	result := PickResult{Picks: make([]string, count)}
+	for i := range result.Picks {
+		picked, err := random.Pick(random.Crypto, weights)
+		if err != nil {
+			utils.OutputError(w, accept, http.StatusBadRequest, "Weights must be positive or zero, some being positive")
+			return
+		}
+		result.Picks[i] = names[picked]
+	}
+
+	utils.Output(w, accept, result, strings.Join(result.Picks, "\n"))
+}
+
+// @Summary		Random integers
+// @Description	Draw integers between min and max included, every integer being as likely, the bounds being at most 2^53 - 1 in absolute value
+// @Tags			random
+// @Produce		json,xml,application/yaml,plain
+// @Param			min		query		int	false	"Lowest integer, 1 by default"
+// @Param			max		query		int	false	"Highest integer, 100 by default"
+// @Param			count	query		int	false	"Number of integers, 1 by default, up to 1000"
+// @Success		200		{object}	IntegersResult
+// @Failure		400		{object}	utils.ErrorResult
+// @Router			/random/int [get]
+func RandomIntegers(w http.ResponseWriter, r *http.Request) {
+	accept := r.Header["Accept"]
+	query := r.URL.Query()
+
+	count, ok := randomCount(w, r)
+	if !ok {
+		return
+	}
+
+	bounds := [2]int{1, 100}
+	for i, name := range []string{"min", "max"} {
+		if v := query.Get(name); v != "" {
+			bound, err := strconv.Atoi(v)
+			if err != nil || bound < -random.MaxSafeInteger || bound > random.MaxSafeInteger {
+				utils.OutputError(w, accept, http.StatusBadRequest, name+" must be an integer of at most 2^53 - 1 in absolute value")
+				return
+			}
+			bounds[i] = bound
+		}
+	}
+	if bounds[0] > bounds[1] {
+		utils.OutputError(w, accept, http.StatusBadRequest, "min must not be greater than max")
+		return
+	}
+
+	result := IntegersResult{Min: bounds[0], Max: bounds[1], Values: make([]int, count)}
+	plain := make([]string, count)
+	for i := range result.Values {
+		result.Values[i] = random.Int(random.Crypto, result.Min, result.Max)
+		plain[i] = strconv.Itoa(result.Values[i])
+	}
+
+	utils.Output(w, accept, result, strings.Join(plain, "\n"))
+}
+
+// @Summary		Random floats
+// @Description	Draw numbers between min included and max excluded, uniformly with 53 random bits
+// @Tags			random
+// @Produce		json,xml,application/yaml,plain
+// @Param			min		query		number	false	"Lowest number, 0 by default"
+// @Param			max		query		number	false	"Highest number, excluded, 1 by default"
+// @Param			count	query		int		false	"Number of numbers, 1 by default, up to 1000"
+// @Success		200		{object}	FloatsResult
+// @Failure		400		{object}	utils.ErrorResult
+// @Router			/random/float [get]
+func RandomFloats(w http.ResponseWriter, r *http.Request) {
+	accept := r.Header["Accept"]
+	query := r.URL.Query()
+
+	count, ok := randomCount(w, r)
+	if !ok {
+		return
+	}
+
+	bounds := [2]float64{0, 1}
+	for i, name := range []string{"min", "max"} {
+		if v := query.Get(name); v != "" {
+			bound, err := strconv.ParseFloat(v, 64)
+			if err != nil || math.IsNaN(bound) || math.IsInf(bound, 0) {
+				utils.OutputError(w, accept, http.StatusBadRequest, name+" must be a finite number")
+				return
+			}
+			bounds[i] = bound
+		}
+	}
+	if bounds[0] >= bounds[1] || math.IsInf(bounds[1]-bounds[0], 0) {
+		utils.OutputError(w, accept, http.StatusBadRequest, "min must be less than max, within the range of floats")
+		return
+	}
+
+	result := FloatsResult{Min: bounds[0], Max: bounds[1], Values: make([]float64, count)}
+	plain := make([]string, count)
+	for i := range result.Values {
+		// NOTE: the rounding of the product may give max itself, drawn again as it is excluded
+		v := result.Max
+		for v >= result.Max {
+			v = result.Min + random.Float64(random.Crypto)*(result.Max-result.Min)
+		}
+		result.Values[i] = v
+		plain[i] = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	utils.Output(w, accept, result, strings.Join(plain, "\n"))
+}
+
+// @Summary		Generate UUIDs
+// @Description	Generate random UUIDs of version 4, or of version 7 whose first 48 bits are the time in milliseconds so that they sort by creation
+// @Tags			random
+// @Produce		json,xml,application/yaml,plain
+// @Param			version	query		int	false	"Version of the UUIDs, 4 or 7, 4 by default"
+// @Param			count	query		int	false	"Number of UUIDs, 1 by default, up to 1000"
+// @Success		200		{object}	UUIDsResult
+// @Failure		400		{object}	utils.ErrorResult
+// @Failure		503		{object}	utils.ErrorResult
+// @Router			/random/uuid [get]
+func GenerateUUIDs(w http.ResponseWriter, r *http.Request) {
+	accept := r.Header["Accept"]
+
+	count, ok := randomCount(w, r)
+	if !ok {
+		return
+	}
+
+	var generate func() (uuid.UUID, error)
+	version := r.URL.Query().Get("version")
+	switch version {
+	case "", "4":
+		version, generate = "4", uuid.NewRandom
+	case "7":
+		generate = uuid.NewV7
+	default:
+		utils.OutputError(w, accept, http.StatusBadRequest, "Version must be 4 or 7")
+		return
+	}
+
+	result := UUIDsResult{Version: version, UUIDs: make([]string, count)}
+	for i := range result.UUIDs {
+		id, err := generate()
+		if err != nil {
+			utils.OutputError(w, accept, http.StatusServiceUnavailable, "No UUID can be generated for now")
+			return
+		}
+		result.UUIDs[i] = id.String()
+	}
+
+	utils.Output(w, accept, result, strings.Join(result.UUIDs, "\n"))
+}
+
+// @Summary		Commit to a server seed
+// @Description	Draw a secret server seed and answer its SHA-256 commitment, to roll dice with /roll?seed= and a client seed.
+// @Description	The rolls are determined by both seeds and their nonce, yet unpredictable until the server seed is revealed.
+// @Description	The seed can be used until it expires 24 hours later, and revealed for 7 days more. A client can use 100 seeds at most.
+// @Tags			dice
+// @Produce		json,xml,application/yaml,plain
+// @Success		201	{object}	SeedResult
+// @Failure		429	{object}	utils.ErrorResult
+// @Failure		503	{object}	utils.ErrorResult
+// @Router			/random/seeds [post]
+func CreateSeed(seeds *random.Seeds) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		commitment, expires, err := seeds.Create(ratelimit.ClientFromContext(r.Context()))
+		if errors.Is(err, random.ErrTooManyClientSeeds) {
+			utils.OutputError(w, r.Header["Accept"], http.StatusTooManyRequests, "Too many seeds in use, reveal some first")
+			return
+		}
+		if err != nil {
+			utils.OutputError(w, r.Header["Accept"], http.StatusServiceUnavailable, "No more seeds can be created for now")
+			return
+		}
+
+		result := SeedResult{Commitment: commitment, Expires: expires.UTC()}
+
+		w.WriteHeader(http.StatusCreated)
+		utils.Output(w, r.Header["Accept"], result, result.Commitment)
+	}
+}
+
+// @Summary		Reveal a server seed
+// @Description	Reveal the server seed of a commitment, along with the number of rolls done with it, which can then be verified with /roll/verify.
+// @Description	A seed in use can only be revealed by the client which created it, and can then no longer be used.
+// @Description	Once revealed or expired, anyone can reveal it until it is forgotten, 7 days after it expired.
+// @Tags			dice
+// @Produce		json,xml,application/yaml,plain
+// @Param			commitment	path		string	true	"Commitment of the server seed"
+// @Success		200			{object}	RevealResult
+// @Failure		403			{object}	utils.ErrorResult
+// @Failure		404			{object}	utils.ErrorResult
+// @Router			/random/seeds/{commitment}/reveal [post]
+func RevealSeed(seeds *random.Seeds) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		commitment := mux.Vars(r)["commitment"]
+
+		seed, rolls, err := seeds.Reveal(commitment, ratelimit.ClientFromContext(r.Context()))
+		if errors.Is(err, random.ErrSeedInUse) {
+			utils.OutputError(w, r.Header["Accept"], http.StatusForbidden, "Only the client which created the seed can reveal it before it expires")
+			return
+		}
+		if errors.Is(err, random.ErrUnknownSeed) {
+			utils.OutputError(w, r.Header["Accept"], http.StatusNotFound, "Unknown or forgotten seed")
+			return
+		}
+
+		result := RevealResult{Commitment: commitment, ServerSeed: seed, Rolls: rolls}
+
+		utils.Output(w, r.Header["Accept"], result, result.ServerSeed+" "+strconv.Itoa(result.Rolls))
+	}
+}
+
+type SeedResult struct {
+	XMLName    xml.Name  `json:"-" xml:"seed" yaml:"-"`
+	Commitment string    `json:"commitment" xml:"commitment" yaml:"commitment"`
+	Expires    time.Time `json:"expires" xml:"expires" yaml:"expires"`
+}
+
+type RevealResult struct {
+	XMLName    xml.Name `json:"-" xml:"reveal" yaml:"-"`
+	Commitment string   `json:"commitment" xml:"commitment" yaml:"commitment"`
+	ServerSeed string   `json:"serverSeed" xml:"serverSeed" yaml:"serverSeed"`
+	Rolls      int      `json:"rolls" xml:"rolls" yaml:"rolls"`
+}
+
+type CoinsResult struct {
+	XMLName xml.Name `json:"-" xml:"coins" yaml:"-"`
+	Flips   []string `json:"flips" xml:"flip" yaml:"flips"`
+	Heads   int      `json:"heads" xml:"heads" yaml:"heads"`
+	Tails   int      `json:"tails" xml:"tails" yaml:"tails"`
+}
+
+type DeckResult struct {
+	XMLName   xml.Name  `json:"-" xml:"deck" yaml:"-"`
+	ID        string    `json:"id" xml:"id" yaml:"id"`
+	Remaining int       `json:"remaining" xml:"remaining" yaml:"remaining"`
+	Expires   time.Time `json:"expires" xml:"expires" yaml:"expires"`
+}
+
+type DrawResult struct {
+	XMLName   xml.Name `json:"-" xml:"draw" yaml:"-"`
+	ID        string   `json:"id" xml:"id" yaml:"id"`
+	Cards     []string `json:"cards" xml:"card" yaml:"cards"`
+	Remaining int      `json:"remaining" xml:"remaining" yaml:"remaining"`
+}
+
+type ShuffleResult struct {
+	XMLName xml.Name `json:"-" xml:"shuffle" yaml:"-"`
+	Items   []string `json:"items" xml:"item" yaml:"items"`
+}
+
+type PickResult struct {
+	XMLName xml.Name `json:"-" xml:"pick" yaml:"-"`
+	Picks   []string `json:"picks" xml:"pick" yaml:"picks"`
+}
+
+type IntegersResult struct {
+	XMLName xml.Name `json:"-" xml:"integers" yaml:"-"`
+	Min     int      `json:"min" xml:"min" yaml:"min"`
+	Max     int      `json:"max" xml:"max" yaml:"max"`
+	Values  []int    `json:"values" xml:"value" yaml:"values"`
+}
+
+type FloatsResult struct {
+	XMLName xml.Name  `json:"-" xml:"floats" yaml:"-"`
+	Min     float64   `json:"min" xml:"min" yaml:"min"`
+	Max     float64   `json:"max" xml:"max" yaml:"max"`
+	Values  []float64 `json:"values" xml:"value" yaml:"values"`
+}
+
+type UUIDsResult struct {
+	XMLName xml.Name `json:"-" xml:"uuids" yaml:"-"`
+	Version string   `json:"version" xml:"version" yaml:"version"`
+	UUIDs   []string `json:"uuids" xml:"uuid" yaml:"uuids"`
+}
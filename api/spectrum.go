@@ -6,46 +6,65 @@ import (
 	"sync"
 
 	"github.com/gorilla/websocket"
-	log "github.com/sirupsen/logrus"
 	"utile.space/api/domain/spectrum"
+	"utile.space/api/infrastructure/history"
+	"utile.space/api/infrastructure/logging"
 )
 
 var (
-	spectrumHub *spectrum.Hub
+	spectrumHub     *spectrum.Hub
+	spectrumHubOnce sync.Once
+
+	// rollHistory keeps the rolls of the spectrum rooms, in memory until set
+	rollHistory history.Store
 )
 
+// SetRollHistory makes the spectrum rooms record their rolls in the store
+func SetRollHistory(store history.Store) {
+	rollHistory = store
+}
+
+// getSpectrumHub returns the spectrum hub, started by the first caller whatever the goroutine
 func getSpectrumHub(ctx context.Context) *spectrum.Hub {
-	if spectrumHub == nil {
-		spectrumHub = spectrum.NewHub()
+	spectrumHubOnce.Do(func() {
+		spectrumHub = spectrum.NewHub(logging.Logger("spectrum"))
+		if rollHistory != nil {
+			spectrumHub.SetHistory(rollHistory)
+		}
 		go spectrumHub.Run(ctx)
-	}
+	})
 	return spectrumHub
 }
 
 var upgraderSpectrum = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
-		return true
+		return checkOrigin(r)
 	},
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
+	EnableCompression: true,
+	ReadBufferSize:    1024,
+	WriteBufferSize:   1024,
 }
 
 // @Summary		SpectrumWebsocket to run spectrum with a party of 2 to 6 players
-// @Description	Websocket to open to run spectrums
+// @Description	Websocket to open to run spectrums, whose participants can also roll dice with roll followed by a notation like 4d6kh3+2,
+// @Description	every participant receiving rolled followed by the roll as JSON with the nickname and color of the roller and the time,
+// @Description	and get the last rolls of the room with history, also sent when joining
 // @Tags			spectrum
 // @Success		101
 // @Router			/spectrum/ws [get]
 func SpectrumWebsocket(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
 	c, err := upgraderSpectrum.Upgrade(w, r, nil)
 	if err != nil {
-		log.Error("upgrade:", err)
+		logger.Error("upgrade:", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 	defer c.Close()
 
 	hub := getSpectrumHub(context.Background())
-	client := spectrum.NewClient(hub, c)
+	client := spectrum.NewClient(hub, c, logger)
 	hub.Register <- client
 
 	var wg sync.WaitGroup
@@ -58,7 +77,7 @@ func SpectrumWebsocket(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	go func() {
-		client.ReadPump()
+		client.ReadPump(r.Context())
 		wg.Done()
 	}()
 
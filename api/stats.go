@@ -0,0 +1,371 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math/big"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+	"utile.space/api/domain/services/math"
+	"utile.space/api/infrastructure/metrics"
+	"utile.space/api/utils"
+)
+
+const (
+	// maxStatsBody bounds the size of the body of a statistics request, in bytes
+	maxStatsBody = 1 << 20
+
+	// maxStatsValues bounds the values of a statistics request
+	maxStatsValues = 100000
+
+	// maxStatsBits bounds the bits of the numerator and the denominator of each value, like 1e300 or 1.5e-300,
+	// the exact arithmetic being slow beyond
+	maxStatsBits = 2048
+
+	// defaultStatsDigits and maxStatsDigits bound the significant digits of the statistics
+	defaultStatsDigits = 20
+	maxStatsDigits     = 100
+)
+
+var outOfTime = "The values could not be described within the time budget, up to " + strconv.Itoa(maxBudget) + " milliseconds"
+
+// sample is a value or a (x, y) pair, as written in the request
+type sample []string
+
+func (s *sample) UnmarshalJSON(data []byte) error {
+	var value json.Number
+	if err := json.Unmarshal(data, &value); err == nil {
+		*s = sample{value.String()}
+		return nil
+	}
+
+	var pair []json.Number
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	*s = make(sample, len(pair))
+	for i, v := range pair {
+		(*s)[i] = v.String()
+	}
+	return nil
+}
+
+func (s *sample) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err == nil {
+		*s = sample{value}
+		return nil
+	}
+
+	var pair []string
+	if err := unmarshal(&pair); err != nil {
+		return err
+	}
+	*s = pair
+	return nil
+}
+
+// readSamples decodes the body according to its content type, a JSON or YAML list of numbers or of pairs,
+// CSV records of one or two columns with an optional header, or lines of one or two numbers
+func readSamples(r *http.Request) ([]sample, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var samples []sample
+	switch mediaType {
+	case "application/json":
+		err = json.Unmarshal(body, &samples)
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		err = yaml.Unmarshal(body, &samples)
+	case "text/csv":
+		reader := csv.NewReader(bytes.NewReader(body))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+
+		var records [][]string
+		records, err = reader.ReadAll()
+		for i, record := range records {
+			// NOTE: the first record is a header when it is not a number
+			if _, err := math.ParseNumber(record[0], math.ScientificFormat); i == 0 && err != nil {
+				continue
+			}
+			samples = append(samples, record)
+		}
+	default:
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for scanner.Scan() {
+			fields := strings.FieldsFunc(scanner.Text(), func(r rune) bool {
+				return unicode.IsSpace(r) || r == ',' || r == ';'
+			})
+			if len(fields) > 0 {
+				samples = append(samples, fields)
+			}
+		}
+		err = scanner.Err()
+	}
+	return samples, err
+}
+
+// @Summary		Descriptive statistics
+// @Description	Describe up to 100000 numbers with exact rational arithmetic within a time budget: count, sum, mean, median, modes, population and sample variance
+// @Description	and standard deviation, percentiles interpolated between the closest ranks, minimum, maximum and a histogram of equal width buckets.
+// @Description	The numbers are a JSON or YAML list, CSV records or lines, and (x, y) pairs instead of numbers add the least squares regression of y on x,
+// @Description	the other statistics describing y.
+// @Tags			math
+// @Accept			json,application/yaml,text/csv,plain
+// @Produce		json,xml,application/yaml,plain
+// @Param			values		body		[]number	true	"Numbers like [1, 2.5, 3e2], or pairs like [[1, 2], [2, 4.1]]"
+// @Param			percentile	query		[]string	false	"Percentiles between 0 and 100, 5, 25, 75, 95 and 99 by default"	collectionFormat(multi)
+// @Param			buckets		query		int			false	"Buckets of the histogram up to 1000, following Sturges' rule by default"
+// @Param			digits		query		int			false	"Significant digits of the statistics, 20 by default, up to 100"
+// @Param			budget		query		int			false	"Time budget in milliseconds, 2000 by default, up to 10000"
+// @Success		200			{object}	StatisticsResult
+// @Failure		400			{object}	utils.ErrorResult
+// @Failure		413			{object}	utils.ErrorResult
+// @Router			/math/stats [post]
+func DescribeNumbers(w http.ResponseWriter, r *http.Request) {
+	accept := r.Header["Accept"]
+	query := r.URL.Query()
+
+	percentiles := math.DefaultPercentiles
+	if params := query["percentile"]; len(params) > 0 {
+		percentiles = make([]*big.Rat, len(params))
+		for i, param := range params {
+			p, err := math.ParseNumber(param, math.ScientificFormat)
+			if err != nil {
+				utils.OutputError(w, accept, http.StatusBadRequest, "Invalid percentile "+param)
+				return
+			}
+			percentiles[i] = p
+		}
+	}
+
+	buckets := 0
+	if value := query.Get("buckets"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			utils.OutputError(w, accept, http.StatusBadRequest, "Buckets must be between 1 and "+strconv.Itoa(math.MaxBuckets))
+			return
+		}
+		buckets = parsed
+	}
+
+	digits := defaultStatsDigits
+	if value := query.Get("digits"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxStatsDigits {
+			utils.OutputError(w, accept, http.StatusBadRequest, "Digits must be between 1 and "+strconv.Itoa(maxStatsDigits))
+			return
+		}
+		digits = parsed
+	}
+
+	budget, ok := budgetParam(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxStatsBody)
+	samples, err := readSamples(r)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		utils.OutputError(w, accept, http.StatusRequestEntityTooLarge, "Body must be at most "+strconv.Itoa(maxStatsBody)+" bytes")
+		return
+	case err != nil:
+		utils.OutputError(w, accept, http.StatusBadRequest, "Invalid body: "+err.Error())
+		return
+	case len(samples) > maxStatsValues:
+		utils.OutputError(w, accept, http.StatusBadRequest, "At most "+strconv.Itoa(maxStatsValues)+" values are described")
+		return
+	}
+
+	// NOTE: the pairs are (x, y) points, y being described
+	var xs, ys []*big.Rat
+	pairs := len(samples) > 0 && len(samples[0]) == 2
+	for i, s := range samples {
+		if len(s) == 0 || len(s) > 2 || len(s) != len(samples[0]) {
+			utils.OutputError(w, accept, http.StatusBadRequest, "Value "+strconv.Itoa(i+1)+" must be a number or a pair like the first one")
+			return
+		}
+
+		values := make([]*big.Rat, len(s))
+		for j, field := range s {
+			values[j], err = math.ParseNumber(field, math.ScientificFormat)
+			if err != nil {
+				utils.OutputError(w, accept, http.StatusBadRequest, "Value "+strconv.Itoa(i+1)+" is not a number: "+field)
+				return
+			}
+			if values[j].Num().BitLen()+values[j].Denom().BitLen() > maxStatsBits {
+				utils.OutputError(w, accept, http.StatusBadRequest, "Value "+strconv.Itoa(i+1)+" is too large or too precise: "+field)
+				return
+			}
+		}
+		if pairs {
+			xs = append(xs, values[0])
+		}
+		ys = append(ys, values[len(values)-1])
+	}
+
+	start := time.Now()
+	deadline := start.Add(budget)
+
+	stats, err := math.Describe(ys, percentiles, buckets, deadline)
+	switch {
+	case errors.Is(err, math.ErrOutOfTime):
+		utils.OutputError(w, accept, http.StatusBadRequest, outOfTime)
+		return
+	case errors.Is(err, math.ErrNoValues):
+		utils.OutputError(w, accept, http.StatusBadRequest, "No values to describe")
+		return
+	case errors.Is(err, math.ErrInvalidPercentile):
+		utils.OutputError(w, accept, http.StatusBadRequest, "Percentiles must be between 0 and 100")
+		return
+	case errors.Is(err, math.ErrInvalidBuckets):
+		utils.OutputError(w, accept, http.StatusBadRequest, "Buckets must be between 1 and "+strconv.Itoa(math.MaxBuckets))
+		return
+	}
+
+	var regression *math.Regression
+	if pairs {
+		fit, err := math.Regress(xs, ys, deadline)
+		if errors.Is(err, math.ErrOutOfTime) {
+			utils.OutputError(w, accept, http.StatusBadRequest, outOfTime)
+			return
+		} else if err != nil {
+			utils.OutputError(w, accept, http.StatusBadRequest, "The regression needs at least two distinct x")
+			return
+		}
+		regression = &fit
+	}
+	metrics.ObserveComputation("stats", start)
+
+	result := newStatisticsResult(stats, regression, digits)
+	utils.Output(w, accept, result, result.text())
+}
+
+// formatRat writes the number with at most digits significant digits, 512 bits being more than maxStatsDigits need
+func formatRat(r *big.Rat, digits int) string {
+	return new(big.Float).SetPrec(512).SetRat(r).Text('g', digits)
+}
+
+func newStatisticsResult(stats math.Statistics, regression *math.Regression, digits int) StatisticsResult {
+	result := StatisticsResult{
+		Count:       stats.Count,
+		Sum:         formatRat(stats.Sum, digits),
+		Mean:        formatRat(stats.Mean, digits),
+		Median:      formatRat(stats.Median, digits),
+		Modes:       []string{},
+		Min:         formatRat(stats.Min, digits),
+		Max:         formatRat(stats.Max, digits),
+		Variance:    formatRat(stats.Variance, digits),
+		StdDev:      stats.StdDev.Text('g', digits),
+		Percentiles: []PercentileResult{},
+		Histogram:   []BucketResult{},
+	}
+	for _, mode := range stats.Modes {
+		result.Modes = append(result.Modes, formatRat(mode, digits))
+	}
+	if stats.SampleVariance != nil {
+		result.SampleVariance = formatRat(stats.SampleVariance, digits)
+		result.SampleStdDev = stats.SampleStdDev.Text('g', digits)
+	}
+	for _, p := range stats.Percentiles {
+		result.Percentiles = append(result.Percentiles, PercentileResult{Rank: formatRat(p.Rank, digits), Value: formatRat(p.Value, digits)})
+	}
+	for _, b := range stats.Histogram {
+		result.Histogram = append(result.Histogram, BucketResult{Start: formatRat(b.Start, digits), End: formatRat(b.End, digits), Count: b.Count})
+	}
+
+	if regression != nil {
+		result.Regression = &RegressionResult{
+			Slope:     formatRat(regression.Slope, digits),
+			Intercept: formatRat(regression.Intercept, digits),
+		}
+		if regression.Correlation != nil {
+			result.Regression.Correlation = regression.Correlation.Text('g', digits)
+		}
+	}
+	return result
+}
+
+// text writes the statistics one per line, like "mean: 4.5"
+func (s StatisticsResult) text() string {
+	lines := []string{
+		"count: " + strconv.Itoa(s.Count),
+		"sum: " + s.Sum,
+		"mean: " + s.Mean,
+		"median: " + s.Median,
+		"modes: " + strings.Join(s.Modes, ", "),
+		"min: " + s.Min,
+		"max: " + s.Max,
+		"variance: " + s.Variance,
+		"stddev: " + s.StdDev,
+	}
+	if s.SampleVariance != "" {
+		lines = append(lines, "sample variance: "+s.SampleVariance, "sample stddev: "+s.SampleStdDev)
+	}
+	for _, p := range s.Percentiles {
+		lines = append(lines, "p"+p.Rank+": "+p.Value)
+	}
+	for _, b := range s.Histogram {
+		lines = append(lines, "["+b.Start+", "+b.End+"]: "+strconv.Itoa(b.Count))
+	}
+	if s.Regression != nil {
+		lines = append(lines, "slope: "+s.Regression.Slope, "intercept: "+s.Regression.Intercept)
+		if s.Regression.Correlation != "" {
+			lines = append(lines, "correlation: "+s.Regression.Correlation)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+type StatisticsResult struct {
+	XMLName        xml.Name           `json:"-" xml:"statistics" yaml:"-"`
+	Count          int                `json:"count" xml:"count" yaml:"count"`
+	Sum            string             `json:"sum" xml:"sum" yaml:"sum"`
+	Mean           string             `json:"mean" xml:"mean" yaml:"mean"`
+	Median         string             `json:"median" xml:"median" yaml:"median"`
+	Modes          []string           `json:"modes" xml:"mode" yaml:"modes"`
+	Min            string             `json:"min" xml:"min" yaml:"min"`
+	Max            string             `json:"max" xml:"max" yaml:"max"`
+	Variance       string             `json:"variance" xml:"variance" yaml:"variance"`
+	StdDev         string             `json:"stddev" xml:"stddev" yaml:"stddev"`
+	SampleVariance string             `json:"sampleVariance,omitempty" xml:"sampleVariance,omitempty" yaml:"sampleVariance,omitempty"`
+	SampleStdDev   string             `json:"sampleStddev,omitempty" xml:"sampleStddev,omitempty" yaml:"sampleStddev,omitempty"`
+	Percentiles    []PercentileResult `json:"percentiles" xml:"percentile" yaml:"percentiles"`
+	Histogram      []BucketResult     `json:"histogram" xml:"bucket" yaml:"histogram"`
+	Regression     *RegressionResult  `json:"regression,omitempty" xml:"regression,omitempty" yaml:"regression,omitempty"`
+}
+
+type PercentileResult struct {
+	Rank  string `json:"rank" xml:"rank,attr" yaml:"rank"`
+	Value string `json:"value" xml:"value,attr" yaml:"value"`
+}
+
+type BucketResult struct {
+	Start string `json:"start" xml:"start,attr" yaml:"start"`
+	End   string `json:"end" xml:"end,attr" yaml:"end"`
+	Count int    `json:"count" xml:"count,attr" yaml:"count"`
+}
+
+type RegressionResult struct {
+	Slope       string `json:"slope" xml:"slope" yaml:"slope"`
+	Intercept   string `json:"intercept" xml:"intercept" yaml:"intercept"`
+	Correlation string `json:"correlation,omitempty" xml:"correlation,omitempty" yaml:"correlation,omitempty"`
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"os"
+
+	"utile.space/api/infrastructure/health"
+	"utile.space/api/utils"
+)
+
+// @Summary		Healthcheck
+// @Description	Get the status of the API
+// @Tags			health
+// @Produce		json,xml,application/yaml,plain
+// @Success		200	{object}	Health
+// @Router			/status [get]
+func HealthCheck(w http.ResponseWriter, r *http.Request) {
+	var health Health
+	health.Status = "up"
+
+	version, present := os.LookupEnv("API_VERSION")
+	if present {
+		health.Version = version
+	}
+
+	utils.Output(w, r.Header["Accept"], health, health.Status)
+}
+
+type Health struct {
+	XMLName xml.Name `json:"-" xml:"health" yaml:"-"`
+	Version string   `json:"version,omitempty" xml:"version,omitempty" yaml:"version,omitempty"`
+	Status  string   `json:"status" xml:"status" yaml:"status"`
+}
+
+// StartHubs starts the websocket hubs, before the probes check them and the first connections
+func StartHubs(ctx context.Context) {
+	getHub(ctx)
+	getSpectrumHub(ctx)
+}
+
+// PingBattleshipsHub checks that the battleships hub is still running
+func PingBattleshipsHub(ctx context.Context) error {
+	return getHub(context.Background()).Ping(ctx)
+}
+
+// PingSpectrumHub checks that the spectrum hub is still running
+func PingSpectrumHub(ctx context.Context) error {
+	return getSpectrumHub(context.Background()).Ping(ctx)
+}
+
+// @Summary		Readiness probe
+// @Description	Checks the dependencies of the API: the upstream DNS server, Notion and the digits files.
+// @Description	Answers 503 when a critical check fails, the other failures only degrading the status.
+// @Tags			health
+// @Produce		json,xml,application/yaml,plain
+// @Success		200	{object}	ProbeResult
+// @Failure		503	{object}	ProbeResult
+// @Router			/status/ready [get]
+func Readiness(probe *health.Probe) http.HandlerFunc {
+	return probeHandler(probe)
+}
+
+// @Summary		Liveness probe
+// @Description	Checks that the websocket hubs are still running, answering 503 otherwise
+// @Tags			health
+// @Produce		json,xml,application/yaml,plain
+// @Success		200	{object}	ProbeResult
+// @Failure		503	{object}	ProbeResult
+// @Router			/status/live [get]
+func Liveness(probe *health.Probe) http.HandlerFunc {
+	return probeHandler(probe)
+}
+
+func probeHandler(probe *health.Probe) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := probe.Run(r.Context())
+		build := health.Build()
+
+		var result ProbeResult
+		result.Status = string(report.Status)
+		result.Checks = make([]CheckResult, len(report.Checks))
+		result.Build.Version = build.Version
+		result.Build.Commit = build.Commit
+		result.Build.GoVersion = build.GoVersion
+		result.Build.Uptime = int64(build.Uptime.Seconds())
+
+		for i, check := range report.Checks {
+			result.Checks[i].Name = check.Name
+			result.Checks[i].Status = string(check.Status)
+			result.Checks[i].Duration = check.Duration.Milliseconds()
+			result.Checks[i].Error = check.Error
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		if report.Status == health.Down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		utils.Output(w, r.Header["Accept"], result, result.Status)
+	}
+}
+
+type ProbeResult struct {
+	XMLName xml.Name      `json:"-" xml:"health" yaml:"-"`
+	Status  string        `json:"status" xml:"status" yaml:"status"`
+	Checks  []CheckResult `json:"checks" xml:"check" yaml:"checks"`
+	Build   BuildResult   `json:"build" xml:"build" yaml:"build"`
+}
+
+type CheckResult struct {
+	Name   string `json:"name" xml:"name" yaml:"name"`
+	Status string `json:"status" xml:"status" yaml:"status"`
+	// Milliseconds the check took.
+	Duration int64  `json:"duration" xml:"duration" yaml:"duration"`
+	Error    string `json:"error,omitempty" xml:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type BuildResult struct {
+	Version   string `json:"version,omitempty" xml:"version,omitempty" yaml:"version,omitempty"`
+	Commit    string `json:"commit,omitempty" xml:"commit,omitempty" yaml:"commit,omitempty"`
+	GoVersion string `json:"goVersion" xml:"goVersion" yaml:"goVersion"`
+	// Seconds since the API started.
+	Uptime int64 `json:"uptime" xml:"uptime" yaml:"uptime"`
+}
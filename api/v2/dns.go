@@ -0,0 +1,288 @@
+package v2
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/miekg/dns"
+	"utile.space/api/infrastructure/dnsclient"
+	"utile.space/api/infrastructure/logging"
+	"utile.space/api/utils"
+)
+
+// DNSAnswer is the envelope of every DNS resolution
+type DNSAnswer struct {
+	XMLName xml.Name `json:"-" xml:"dns" yaml:"-"`
+	Name    string   `json:"name" xml:"name" yaml:"name"`
+	Type    string   `json:"type" xml:"type" yaml:"type"`
+	// Lowest TTL of the records, in seconds.
+	TTL     uint32      `json:"ttl" xml:"ttl" yaml:"ttl"`
+	Records []DNSRecord `json:"records" xml:"record" yaml:"records"`
+}
+
+type DNSRecord struct {
+	Type  string `json:"type" xml:"type" yaml:"type"`
+	Value string `json:"value" xml:"value" yaml:"value"`
+	TTL   uint32 `json:"ttl" xml:"ttl" yaml:"ttl"`
+	// MX records only.
+	Preference *uint16 `json:"preference,omitempty" xml:"preference,omitempty" yaml:"preference,omitempty"`
+	// CAA records only.
+	Flag *uint8 `json:"flag,omitempty" xml:"flag,omitempty" yaml:"flag,omitempty"`
+	Tag  string `json:"tag,omitempty" xml:"tag,omitempty" yaml:"tag,omitempty"`
+}
+
+func (d DNSRecord) String() string {
+	switch {
+	case d.Preference != nil:
+		return strconv.Itoa(int(*d.Preference)) + " " + d.Value
+	case d.Flag != nil:
+		return strconv.Itoa(int(*d.Flag)) + " " + d.Tag + " " + d.Value
+	default:
+		return d.Value
+	}
+}
+
+func newDNSRecord(rr dns.RR) DNSRecord {
+	record := DNSRecord{Type: dns.TypeToString[rr.Header().Rrtype], TTL: rr.Header().Ttl}
+
+	switch v := rr.(type) {
+	case *dns.A:
+		record.Value = v.A.String()
+	case *dns.AAAA:
+		record.Value = v.AAAA.String()
+	case *dns.MX:
+		preference := v.Preference
+		record.Value = strings.TrimSuffix(v.Mx, ".")
+		record.Preference = &preference
+	case *dns.NS:
+		record.Value = strings.TrimSuffix(v.Ns, ".")
+	case *dns.CNAME:
+		record.Value = strings.TrimSuffix(v.Target, ".")
+	case *dns.PTR:
+		record.Value = strings.TrimSuffix(v.Ptr, ".")
+	case *dns.TXT:
+		// NOTE: long values are split in strings of 255 characters at most, to be concatenated
+		record.Value = strings.Join(v.Txt, "")
+	case *dns.CAA:
+		flag := v.Flag
+		record.Flag = &flag
+		record.Tag = v.Tag
+		record.Value = v.Value
+	}
+
+	return record
+}
+
+// resolve queries the records of the given types for name, answering 404 when there is none,
+// and 502 when the upstream server cannot answer
+func resolve(w http.ResponseWriter, r *http.Request, label string, name string, types ...uint16) {
+	accept := r.Header["Accept"]
+
+	if _, ok := dns.IsDomainName(name); !ok {
+		utils.OutputError(w, accept, http.StatusBadRequest, "Invalid domain name")
+		return
+	}
+
+	var answers []dns.RR
+
+	for _, recordType := range types {
+		m := new(dns.Msg)
+		m.SetQuestion(dns.Fqdn(name), recordType)
+
+		result, err := dnsclient.Exchange(r.Context(), m, strings.ToLower(dns.TypeToString[recordType]))
+		if err != nil {
+			logging.FromContext(r.Context()).Warnf("DNS exchange error: %v", err)
+			utils.OutputError(w, accept, http.StatusBadGateway, "DNS server unreachable")
+			return
+		}
+
+		switch result.Rcode {
+		case dns.RcodeSuccess:
+		case dns.RcodeNameError:
+			utils.OutputError(w, accept, http.StatusNotFound, "Domain not found")
+			return
+		default:
+			utils.OutputError(w, accept, http.StatusBadGateway, "DNS server answered "+dns.RcodeToString[result.Rcode])
+			return
+		}
+
+		// NOTE: the answer also holds the CNAME records followed to get there
+		for _, rr := range result.Answer {
+			if rr.Header().Rrtype == recordType {
+				answers = append(answers, rr)
+			}
+		}
+	}
+
+	if len(answers) == 0 {
+		utils.OutputError(w, accept, http.StatusNotFound, "No "+strings.ToUpper(label)+" record found")
+		return
+	}
+
+	ttl := dnsclient.MinTTL(answers)
+
+	answer := DNSAnswer{
+		Name:    strings.TrimSuffix(dns.Fqdn(name), "."),
+		Type:    label,
+		TTL:     uint32(ttl.Seconds()),
+		Records: make([]DNSRecord, len(answers)),
+	}
+
+	lines := make([]string, len(answers))
+	for i, rr := range answers {
+		answer.Records[i] = newDNSRecord(rr)
+		lines[i] = answer.Records[i].String()
+	}
+
+	utils.CacheFor(w, ttl)
+	utils.Output(w, accept, answer, strings.Join(lines, "\n"))
+}
+
+// @Summary		DNS resolution
+// @Description	Resolves the IPv4 and IPv6 addresses of a given domain name
+// @Tags			dns
+// @Produce		json,xml,application/yaml,plain
+// @Param			domain	path		string	true	"Domain to resolve"
+// @Success		200		{object}	DNSAnswer
+// @Failure		400		{object}	utils.ErrorResult
+// @Failure		404		{object}	utils.ErrorResult
+// @Failure		502		{object}	utils.ErrorResult
+// @Router			/dns/{domain} [get]
+func DNSResolve(w http.ResponseWriter, r *http.Request) {
+	resolve(w, r, "host", mux.Vars(r)["domain"], dns.TypeA, dns.TypeAAAA)
+}
+
+// @Summary		A resolution
+// @Description	Resolves A records (IPv4) of a given domain name
+// @Tags			dns
+// @Produce		json,xml,application/yaml,plain
+// @Param			domain	path		string	true	"Domain to resolve"
+// @Success		200		{object}	DNSAnswer
+// @Failure		400		{object}	utils.ErrorResult
+// @Failure		404		{object}	utils.ErrorResult
+// @Failure		502		{object}	utils.ErrorResult
+// @Router			/dns/a/{domain} [get]
+func AResolve(w http.ResponseWriter, r *http.Request) {
+	resolve(w, r, "a", mux.Vars(r)["domain"], dns.TypeA)
+}
+
+// @Summary		AAAA resolution
+// @Description	Resolves AAAA records (IPv6) of a given domain name
+// @Tags			dns
+// @Produce		json,xml,application/yaml,plain
+// @Param			domain	path		string	true	"Domain to resolve"
+// @Success		200		{object}	DNSAnswer
+// @Failure		400		{object}	utils.ErrorResult
+// @Failure		404		{object}	utils.ErrorResult
+// @Failure		502		{object}	utils.ErrorResult
+// @Router			/dns/aaaa/{domain} [get]
+func AAAAResolve(w http.ResponseWriter, r *http.Request) {
+	resolve(w, r, "aaaa", mux.Vars(r)["domain"], dns.TypeAAAA)
+}
+
+// @Summary		MX resolution
+// @Description	Resolves MX records of a given domain name
+// @Tags			dns
+// @Produce		json,xml,application/yaml,plain
+// @Param			domain	path		string	true	"Domain to resolve"
+// @Success		200		{object}	DNSAnswer
+// @Failure		400		{object}	utils.ErrorResult
+// @Failure		404		{object}	utils.ErrorResult
+// @Failure		502		{object}	utils.ErrorResult
+// @Router			/dns/mx/{domain} [get]
+func MXResolve(w http.ResponseWriter, r *http.Request) {
+	resolve(w, r, "mx", mux.Vars(r)["domain"], dns.TypeMX)
+}
+
+// @Summary		NS resolution
+// @Description	Resolves the name servers of a given domain name
+// @Tags			dns
+// @Produce		json,xml,application/yaml,plain
+// @Param			domain	path		string	true	"Domain to resolve"
+// @Success		200		{object}	DNSAnswer
+// @Failure		400		{object}	utils.ErrorResult
+// @Failure		404		{object}	utils.ErrorResult
+// @Failure		502		{object}	utils.ErrorResult
+// @Router			/dns/ns/{domain} [get]
+func NSResolve(w http.ResponseWriter, r *http.Request) {
+	resolve(w, r, "ns", mux.Vars(r)["domain"], dns.TypeNS)
+}
+
+// @Summary		TXT resolution
+// @Description	Resolves TXT records of a given domain name
+// @Tags			dns
+// @Produce		json,xml,application/yaml,plain
+// @Param			domain	path		string	true	"Domain to resolve"
+// @Success		200		{object}	DNSAnswer
+// @Failure		400		{object}	utils.ErrorResult
+// @Failure		404		{object}	utils.ErrorResult
+// @Failure		502		{object}	utils.ErrorResult
+// @Router			/dns/txt/{domain} [get]
+func TXTResolve(w http.ResponseWriter, r *http.Request) {
+	resolve(w, r, "txt", mux.Vars(r)["domain"], dns.TypeTXT)
+}
+
+// @Summary		CNAME resolution
+// @Description	Resolves CNAME records of a given domain name
+// @Tags			dns
+// @Produce		json,xml,application/yaml,plain
+// @Param			domain	path		string	true	"Domain to resolve"
+// @Success		200		{object}	DNSAnswer
+// @Failure		400		{object}	utils.ErrorResult
+// @Failure		404		{object}	utils.ErrorResult
+// @Failure		502		{object}	utils.ErrorResult
+// @Router			/dns/cname/{domain} [get]
+func CNAMEResolve(w http.ResponseWriter, r *http.Request) {
+	resolve(w, r, "cname", mux.Vars(r)["domain"], dns.TypeCNAME)
+}
+
+// @Summary		CAA resolution
+// @Description	Resolves CAA records of a given domain name
+// @Tags			dns
+// @Produce		json,xml,application/yaml,plain
+// @Param			domain	path		string	true	"Domain to resolve"
+// @Success		200		{object}	DNSAnswer
+// @Failure		400		{object}	utils.ErrorResult
+// @Failure		404		{object}	utils.ErrorResult
+// @Failure		502		{object}	utils.ErrorResult
+// @Router			/dns/caa/{domain} [get]
+func CAAResolve(w http.ResponseWriter, r *http.Request) {
+	resolve(w, r, "caa", mux.Vars(r)["domain"], dns.TypeCAA)
+}
+
+// @Summary		DMARC resolution
+// @Description	Resolves DMARC TXT records of a given domain name
+// @Tags			dns
+// @Produce		json,xml,application/yaml,plain
+// @Param			domain	path		string	true	"Domain to resolve"
+// @Success		200		{object}	DNSAnswer
+// @Failure		400		{object}	utils.ErrorResult
+// @Failure		404		{object}	utils.ErrorResult
+// @Failure		502		{object}	utils.ErrorResult
+// @Router			/dns/dmarc/{domain} [get]
+func DMARCResolve(w http.ResponseWriter, r *http.Request) {
+	resolve(w, r, "dmarc", "_dmarc."+mux.Vars(r)["domain"], dns.TypeTXT)
+}
+
+// @Summary		PTR resolution
+// @Description	Resolves the domain names of a given IP address
+// @Tags			dns
+// @Produce		json,xml,application/yaml,plain
+// @Param			ip	path		string	true	"IP address"
+// @Success		200	{object}	DNSAnswer
+// @Failure		400	{object}	utils.ErrorResult
+// @Failure		404	{object}	utils.ErrorResult
+// @Failure		502	{object}	utils.ErrorResult
+// @Router			/dns/ptr/{ip} [get]
+func PTRResolve(w http.ResponseWriter, r *http.Request) {
+	arpa, err := dns.ReverseAddr(mux.Vars(r)["ip"])
+	if err != nil {
+		utils.OutputError(w, r.Header["Accept"], http.StatusBadRequest, "Invalid IP address")
+		return
+	}
+
+	resolve(w, r, "ptr", arpa, dns.TypePTR)
+}
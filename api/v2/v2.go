@@ -0,0 +1,92 @@
+// Package v2 holds the handlers of the second version of the API, the others being shared with v1
+package v2
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"utile.space/api/utils"
+)
+
+// @title			utile.space Open API
+// @version		2.0
+// @description	The collection of free API from utile.space, the Swiss Army Knife webtool.
+// @description	Every error is answered with the same error model, in the format negotiated with the Accept header.
+//
+// @contact.name	API Support
+// @contact.email	api@utile.space
+//
+// @license.name	utile.space API License
+// @license.url	https://utile.space/api/
+//
+// @BasePath		/api/v2
+
+// ErrorModel is a middleware turning the plain text errors written with http.Error by the handlers shared with v1
+// into utils.ErrorResult
+func ErrorModel(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ew := &errorWriter{ResponseWriter: w}
+		next.ServeHTTP(ew, r)
+
+		if ew.capturing {
+			utils.OutputError(w, r.Header["Accept"], ew.status, strings.TrimSpace(ew.body.String()))
+		}
+	})
+}
+
+type errorWriter struct {
+	http.ResponseWriter
+	decided   bool
+	capturing bool
+	status    int
+	body      bytes.Buffer
+}
+
+func (e *errorWriter) WriteHeader(status int) {
+	if e.decided {
+		return
+	}
+	e.decided = true
+
+	// NOTE: http.Error is recognized by the headers it sets
+	header := e.Header()
+	if status >= http.StatusBadRequest && header.Get("X-Content-Type-Options") == "nosniff" &&
+		strings.HasPrefix(header.Get("Content-Type"), "text/plain") {
+		e.capturing = true
+		e.status = status
+		header.Del("Content-Type")
+		header.Del("X-Content-Type-Options")
+		return
+	}
+
+	e.ResponseWriter.WriteHeader(status)
+}
+
+func (e *errorWriter) Write(p []byte) (int, error) {
+	if !e.decided {
+		e.WriteHeader(http.StatusOK)
+	}
+	if e.capturing {
+		return e.body.Write(p)
+	}
+	return e.ResponseWriter.Write(p)
+}
+
+func (e *errorWriter) Flush() {
+	if flusher, ok := e.ResponseWriter.(http.Flusher); ok && !e.capturing {
+		flusher.Flush()
+	}
+}
+
+func (e *errorWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := e.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking not supported")
+	}
+	e.decided = true
+	return hijacker.Hijack()
+}
@@ -0,0 +1,63 @@
+package v2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ErrorModel(t *testing.T) {
+	tt := map[string]struct {
+		handler        http.HandlerFunc
+		expectedStatus int
+		expectedBody   string
+	}{
+		"plain text error": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Die not found", http.StatusNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":404,"message":"Die not found"}`,
+		},
+		"error model untouched": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"status":400,"message":"Invalid"}`))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":400,"message":"Invalid"}`,
+		},
+		"success": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"result":1}`))
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"result":1}`,
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept", "application/json")
+			w := httptest.NewRecorder()
+
+			ErrorModel(tc.handler).ServeHTTP(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+			assert.Empty(t, w.Header().Get("X-Content-Type-Options"))
+		})
+	}
+}
+
+func Test_DNSRecordString(t *testing.T) {
+	preference := uint16(10)
+	flag := uint8(0)
+
+	assert.Equal(t, "192.0.2.1", DNSRecord{Type: "A", Value: "192.0.2.1"}.String())
+	assert.Equal(t, "10 mx.example.com", DNSRecord{Type: "MX", Value: "mx.example.com", Preference: &preference}.String())
+	assert.Equal(t, "0 issue letsencrypt.org", DNSRecord{Type: "CAA", Value: "letsencrypt.org", Flag: &flag, Tag: "issue"}.String())
+}
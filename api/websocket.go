@@ -0,0 +1,13 @@
+package api
+
+import "net/http"
+
+// checkOrigin is the origin policy shared by the websocket upgraders, any origin being accepted until set
+var checkOrigin = func(r *http.Request) bool {
+	return true
+}
+
+// SetOriginPolicy makes the websocket upgraders accept the same origins as the CORS policy
+func SetOriginPolicy(check func(r *http.Request) bool) {
+	checkOrigin = check
+}
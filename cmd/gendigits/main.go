@@ -0,0 +1,53 @@
+// Command gendigits writes the digits files served by the math websocket, along with their checksums manifest.
+//
+//	go run ./cmd/gendigits -constants pi,tau -digits 1000000
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"utile.space/api/domain/services/math"
+)
+
+func main() {
+	constants := flag.String("constants", "pi,tau", "Comma separated constants to compute, like pi,tau,e,phi,ln2,catalan,zeta3,sqrt2")
+	digits := flag.Int("digits", math.AssetSize-2, "Number of digits after the decimal point")
+	dir := flag.String("dir", math.AssetsDir, "Directory to write the digits files and the manifest to")
+	flag.Parse()
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		log.Fatal(err)
+	}
+
+	var files []string
+	for _, id := range strings.Split(*constants, ",") {
+		id = strings.TrimSpace(id)
+
+		start := time.Now()
+		value, err := math.Generate(id, *digits)
+		if err != nil {
+			log.Fatalf("%s: %v", id, err)
+		}
+
+		if err := os.WriteFile(filepath.Join(*dir, id), []byte(value), 0o644); err != nil {
+			log.Fatal(err)
+		}
+		files = append(files, id)
+
+		log.WithFields(log.Fields{
+			"constant": id,
+			"digits":   *digits,
+			"duration": time.Since(start).String(),
+		}).Info("Digits written")
+	}
+
+	if err := math.UpdateManifest(*dir, files...); err != nil {
+		log.Fatal(err)
+	}
+	log.Info("Manifest updated")
+}
@@ -0,0 +1,2867 @@
+// Package v2 Code generated by swaggo/swag. DO NOT EDIT
+package v2
+
+import "github.com/swaggo/swag"
+
+const docTemplatev2 = `{
+    "schemes": {{ marshal .Schemes }},
+    "swagger": "2.0",
+    "info": {
+        "description": "{{escape .Description}}",
+        "title": "{{.Title}}",
+        "contact": {
+            "name": "API Support",
+            "email": "api@utile.space"
+        },
+        "license": {
+            "name": "utile.space API License",
+            "url": "https://utile.space/api/"
+        },
+        "version": "{{.Version}}"
+    },
+    "host": "{{.Host}}",
+    "basePath": "{{.BasePath}}",
+    "paths": {
+        "/admin/usage": {
+            "get": {
+                "description": "Lists the number of requests made per day with each API key, requires an API key with the admin scope",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "API keys usage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key with the admin scope",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.UsageResult"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/battleships/stats": {
+            "get": {
+                "description": "To get stats on multiplayer state of the battleships game",
+                "tags": [
+                    "battleships"
+                ],
+                "summary": "BattleshipsStats to get stats on the multiplayer state of the game",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.StatsResult"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/dns/a/{domain}": {
+            "get": {
+                "description": "Resolves A records (IPv4) of a given domain name",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "dns"
+                ],
+                "summary": "A resolution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Domain to resolve",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.DNSAnswer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/dns/aaaa/{domain}": {
+            "get": {
+                "description": "Resolves AAAA records (IPv6) of a given domain name",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "dns"
+                ],
+                "summary": "AAAA resolution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Domain to resolve",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.DNSAnswer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/dns/caa/{domain}": {
+            "get": {
+                "description": "Resolves CAA records of a given domain name",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "dns"
+                ],
+                "summary": "CAA resolution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Domain to resolve",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.DNSAnswer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/dns/cname/{domain}": {
+            "get": {
+                "description": "Resolves CNAME records of a given domain name",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "dns"
+                ],
+                "summary": "CNAME resolution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Domain to resolve",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.DNSAnswer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/dns/dmarc/{domain}": {
+            "get": {
+                "description": "Resolves DMARC TXT records of a given domain name",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "dns"
+                ],
+                "summary": "DMARC resolution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Domain to resolve",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.DNSAnswer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/dns/mx/{domain}": {
+            "get": {
+                "description": "Resolves MX records of a given domain name",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "dns"
+                ],
+                "summary": "MX resolution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Domain to resolve",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.DNSAnswer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/dns/ns/{domain}": {
+            "get": {
+                "description": "Resolves the name servers of a given domain name",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "dns"
+                ],
+                "summary": "NS resolution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Domain to resolve",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.DNSAnswer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/dns/ptr/{ip}": {
+            "get": {
+                "description": "Resolves the domain names of a given IP address",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "dns"
+                ],
+                "summary": "PTR resolution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "IP address",
+                        "name": "ip",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.DNSAnswer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/dns/txt/{domain}": {
+            "get": {
+                "description": "Resolves TXT records of a given domain name",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "dns"
+                ],
+                "summary": "TXT resolution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Domain to resolve",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.DNSAnswer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/dns/{domain}": {
+            "get": {
+                "description": "Resolves the IPv4 and IPv6 addresses of a given domain name",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "dns"
+                ],
+                "summary": "DNS resolution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Domain to resolve",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.DNSAnswer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/d{dice}": {
+            "get": {
+                "description": "Endpoint to roll a dice of the given number of faces",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "dice"
+                ],
+                "summary": "Roll a dice",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of faces of the dice between 2 and 100",
+                        "name": "dice",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.DieResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/links": {
+            "get": {
+                "description": "Returns a page of recommended links by SonnyAD",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Get Recommended Links Page",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start cursor for pagination",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search filter",
+                        "name": "search",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.LinksPage"
+                        }
+                    }
+                }
+            }
+        },
+        "/math/convert": {
+            "get": {
+                "description": "Convert an integer or a fraction between the bases from 2 to 36, roman numerals, scientific notation, English words and fractions p/q.\nThe repeating digits of a fraction are between parentheses, like 0.1(6) for 1/6, both in the value and in the result,\nwhich is exact unless a period is longer than 1000 digits or a number in scientific notation has no finite decimal expansion.",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "math"
+                ],
+                "summary": "Number conversion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Number, up to 1000 characters",
+                        "name": "value",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Format of the value, a base from 2 to 36, binary, octal, decimal, hexadecimal, roman, scientific, words or fraction, 10 by default",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Format of the result, like the one of the value, 10 by default",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ConversionResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/math/eval": {
+            "get": {
+                "description": "Evaluate an arithmetic expression with + - * / ^ !, parentheses, the functions sqrt, ln, log, exp, sin, cos, tan,\nabs, pow and factorial, and the constants pi, tau, e, phi, ln2, catalan, zeta3 and sqrt followed by an integer,\nwith up to 1000 significant digits. The syntax and evaluation errors give the position of the character in fault, from 1.",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "math"
+                ],
+                "summary": "Expression evaluation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Expression, like 2^0.5 * sin(pi / 4)",
+                        "name": "expr",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of significant digits, 50 by default",
+                        "name": "digits",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Add the parse tree of the expression to the result",
+                        "name": "tree",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.BigNumberResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/math/factor/{n}": {
+            "get": {
+                "description": "Decompose an integer of up to 1000 digits in prime factors by trial division and Pollard's rho within a time budget,\nthe factors left composite when the budget runs out being given with prime false",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "math"
+                ],
+                "summary": "Factorization",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Strictly positive integer",
+                        "name": "n",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Time budget in milliseconds, 2000 by default, up to 10000",
+                        "name": "budget",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.FactorizationResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/math/gcd": {
+            "get": {
+                "description": "Compute the greatest common divisor of up to 100 integers",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "math"
+                ],
+                "summary": "Greatest common divisor",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Integers",
+                        "name": "n",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.BigNumberResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/math/lcm": {
+            "get": {
+                "description": "Compute the least common multiple of up to 100 integers",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "math"
+                ],
+                "summary": "Least common multiple",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Integers",
+                        "name": "n",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.BigNumberResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/math/modinv": {
+            "get": {
+                "description": "Compute x between 0 and m - 1 such that a x ≡ 1 mod m",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "math"
+                ],
+                "summary": "Modular inverse",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Integer",
+                        "name": "a",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Strictly positive modulus",
+                        "name": "m",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.BigNumberResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/math/modpow": {
+            "get": {
+                "description": "Compute base^exponent mod modulus between 0 and modulus - 1, a negative exponent being the one of the modular inverse of base",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "math"
+                ],
+                "summary": "Modular exponentiation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Integer",
+                        "name": "base",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Integer",
+                        "name": "exponent",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Strictly positive modulus",
+                        "name": "modulus",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.BigNumberResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/math/pi": {
+            "get": {
+                "description": "Calculate Pi value up to 100K decimals, or a range of its digits, in base 2, 10 or 16",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "math"
+                ],
+                "summary": "Pi Value",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of digits after the point, 10000 by default",
+                        "name": "digits",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "First digit after the point to return, requiring length",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of digits after the point to return from offset, instead of the value",
+                        "name": "length",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Base of the digits: 2, 10 (default) or 16",
+                        "name": "base",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.BigNumberResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/math/prime/{n}": {
+            "get": {
+                "description": "Tell whether an integer of up to 1000 digits is prime, with Miller-Rabin and Baillie-PSW tests, along with the previous and next primes\nfound within a time budget",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "math"
+                ],
+                "summary": "Primality",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Integer",
+                        "name": "n",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Time budget in milliseconds of the search of the previous and next primes, 2000 by default, up to 10000",
+                        "name": "budget",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.PrimeResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/math/stats": {
+            "post": {
+                "description": "Describe up to 100000 numbers with exact rational arithmetic within a time budget: count, sum, mean, median, modes, population and sample variance\nand standard deviation, percentiles interpolated between the closest ranks, minimum, maximum and a histogram of equal width buckets.\nThe numbers are a JSON or YAML list, CSV records or lines, and (x, y) pairs instead of numbers add the least squares regression of y on x,\nthe other statistics describing y.",
+                "consumes": [
+                    "application/json",
+                    "application/yaml",
+                    "text/csv",
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "math"
+                ],
+                "summary": "Descriptive statistics",
+                "parameters": [
+                    {
+                        "description": "Numbers like [1, 2.5, 3e2], or pairs like [[1, 2], [2, 4.1]]",
+                        "name": "values",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "number"
+                            }
+                        }
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Percentiles between 0 and 100, 5, 25, 75, 95 and 99 by default",
+                        "name": "percentile",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Buckets of the histogram up to 1000, following Sturges' rule by default",
+                        "name": "buckets",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Significant digits of the statistics, 20 by default, up to 100",
+                        "name": "digits",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Time budget in milliseconds, 2000 by default, up to 10000",
+                        "name": "budget",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.StatisticsResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/math/tau": {
+            "get": {
+                "description": "Calculate Tau value up to 100K decimals, or a range of its digits, in base 2, 10 or 16",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "math"
+                ],
+                "summary": "Tau Value",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of digits after the point, 10000 by default",
+                        "name": "digits",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "First digit after the point to return, requiring length",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of digits after the point to return from offset, instead of the value",
+                        "name": "length",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Base of the digits: 2, 10 (default) or 16",
+                        "name": "base",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.BigNumberResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/math/ws": {
+            "get": {
+                "description": "Websocket answering JSON requests like {\"id\": \"1\", \"command\": \"page\", \"constant\": \"pi\", \"page\": 0, \"size\": 1000}\nwith replies like {\"id\": \"1\", \"command\": \"page\", \"result\": {...}} or {\"id\": \"1\", \"command\": \"page\", \"error\": {\"status\": 400, \"message\": \"...\"}}.\nThe commands are page (size up to 10000), stream (the pages from page until the end, or a stop with the same id,\nthe last reply being done), stop, constant (with digits) and search (with query, page and size).\nThe text commands \"pi \u003cpage\u003e, \u003cpage size\u003e\", \"\u003cconstant\u003e \u003cdigits\u003e\" and \"search \u003cconstant\u003e \u003cdigits\u003e\" are still answered with text.\nIt will switch protocols as requested.",
+                "tags": [
+                    "math"
+                ],
+                "summary": "MathWebsocket to get pi and tau by page up to 1M digits",
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols"
+                    }
+                }
+            }
+        },
+        "/math/{constant}": {
+            "get": {
+                "description": "Calculate e, phi (golden ratio), ln2, catalan (Catalan's constant), zeta3 (Apéry's constant)\nor the square root of an integer, like sqrt2, up to 100K decimals (10K for catalan),\nor a range of its digits, in base 2, 10 or 16",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "math"
+                ],
+                "summary": "Constant Value",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "e, phi, ln2, catalan, zeta3 or sqrt followed by an integer",
+                        "name": "constant",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of digits after the point, 1000 by default",
+                        "name": "digits",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "First digit after the point to return, requiring length",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of digits after the point to return from offset, instead of the value",
+                        "name": "length",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Base of the digits: 2, 10 (default) or 16",
+                        "name": "base",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.BigNumberResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/math/{constant}/search": {
+            "get": {
+                "description": "Find where a sequence of digits appears among the million digits of pi or tau, the offsets being the ones\nof the digits after the point, like for the offset parameter of /math/pi",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "math"
+                ],
+                "summary": "Digits search",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pi or tau",
+                        "name": "constant",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Digits to find",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page of offsets, from 0",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of offsets per page, 100 by default, up to 1000",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SearchResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/random/coins": {
+            "get": {
+                "description": "Flip fair coins with crypto/rand",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "random"
+                ],
+                "summary": "Flip coins",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of coins, 1 by default, up to 1000",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.CoinsResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/random/decks": {
+            "post": {
+                "description": "Shuffle a standard deck of 52 cards written like AS for the ace of spades, 10H or QD, with the two jokers JK when asked,\nor a custom deck of up to 1000 cards, whose cards are then drawn with /random/decks/{id}/draw without replacement for 24 hours.\nA client can create 100 decks at most during these 24 hours.",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "random"
+                ],
+                "summary": "Create a deck",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Card of a custom deck, repeated for each card",
+                        "name": "card",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Add the two jokers to the standard deck",
+                        "name": "jokers",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.DeckResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/random/decks/{id}/draw": {
+            "post": {
+                "description": "Draw cards from the top of a shuffled deck, without replacement",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "random"
+                ],
+                "summary": "Draw cards",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the deck",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of cards, 1 by default, up to 1000",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.DrawResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/random/float": {
+            "get": {
+                "description": "Draw numbers between min included and max excluded, uniformly with 53 random bits",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "random"
+                ],
+                "summary": "Random floats",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Lowest number, 0 by default",
+                        "name": "min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Highest number, excluded, 1 by default",
+                        "name": "max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of numbers, 1 by default, up to 1000",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.FloatsResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/random/int": {
+            "get": {
+                "description": "Draw integers between min and max included, every integer being as likely, the bounds being at most 2^53 - 1 in absolute value",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "random"
+                ],
+                "summary": "Random integers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Lowest integer, 1 by default",
+                        "name": "min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Highest integer, 100 by default",
+                        "name": "max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of integers, 1 by default, up to 1000",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.IntegersResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/random/pick": {
+            "get": {
+                "description": "Pick options with a probability proportional to their weight, each pick being drawn among all the options",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "random"
+                ],
+                "summary": "Pick at random",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Option, with its weight after a colon like red:3, 1 by default",
+                        "name": "option",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of picks, 1 by default, up to 1000",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.PickResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/random/seeds": {
+            "post": {
+                "description": "Draw a secret server seed and answer its SHA-256 commitment, to roll dice with /roll?seed= and a client seed.\nThe rolls are determined by both seeds and their nonce, yet unpredictable until the server seed is revealed.\nThe seed can be used until it expires 24 hours later, and revealed for 7 days more. A client can use 100 seeds at most.",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "dice"
+                ],
+                "summary": "Commit to a server seed",
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.SeedResult"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/random/seeds/{commitment}/reveal": {
+            "post": {
+                "description": "Reveal the server seed of a commitment, along with the number of rolls done with it, which can then be verified with /roll/verify.\nA seed in use can only be revealed by the client which created it, and can then no longer be used.\nOnce revealed or expired, anyone can reveal it until it is forgotten, 7 days after it expired.",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "dice"
+                ],
+                "summary": "Reveal a server seed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Commitment of the server seed",
+                        "name": "commitment",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.RevealResult"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/random/shuffle": {
+            "post": {
+                "description": "Shuffle up to 100000 items given as a JSON or YAML list, or as lines, every order being as likely",
+                "consumes": [
+                    "application/json",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "random"
+                ],
+                "summary": "Shuffle a list",
+                "parameters": [
+                    {
+                        "description": "Items to shuffle",
+                        "name": "items",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ShuffleResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/random/uuid": {
+            "get": {
+                "description": "Generate random UUIDs of version 4, or of version 7 whose first 48 bits are the time in milliseconds so that they sort by creation",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "random"
+                ],
+                "summary": "Generate UUIDs",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Version of the UUIDs, 4 or 7, 4 by default",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of UUIDs, 1 by default, up to 1000",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.UUIDsResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/roll": {
+            "get": {
+                "description": "Roll dice written in the usual notation, like 4d6kh3+2: groups of dice like 2d8, d% for 100 sides or 4dF for Fate dice, and numbers,\nadded or subtracted. The dice modifiers keep the highest (kh3) or lowest (kl3) dice, drop the highest (dh3) or lowest (dl3) dice,\nexplode on the highest face (!) or a compare point (!\u003e5), reroll the lowest face (r) or a compare point (r\u003c2), once only with ro,\ncount the successes (\u003e5) and subtract the failures (f1), \u003c and \u003e including the value.\nThe dice are drawn with crypto/rand, or from a server seed committed to with /random/seeds and a client seed,\neach roll with the seed having the next nonce, so that the rolls can be verified with /roll/verify once the seed is revealed.\nOnly the client which created the seed can roll with it.",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "dice"
+                ],
+                "summary": "Roll dice",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dice notation, up to 1000 dice, + being encoded %2B in the query",
+                        "name": "expr",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Commitment of a server seed",
+                        "name": "seed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client seed of up to 256 characters, required with a server seed",
+                        "name": "client",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.RollResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/roll/distribution": {
+            "get": {
+                "description": "Exact probability of each total of dice written in the notation of /roll, computed by convolving the distributions of the dice\nrather than by rolling them, along with the mean, the variance and the probability of each total or more.\nExploding dice are followed up to 20 explosions, the probability of the longer ones being the residual, and cannot be kept or dropped.\nWith Accept: text/csv, the outcomes are given as CSV with the columns total, probability and at_least, to be charted.",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/csv",
+                    "text/plain"
+                ],
+                "tags": [
+                    "dice"
+                ],
+                "summary": "Distribution of a roll",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dice notation, up to 100 dice, + being encoded %2B in the query",
+                        "name": "expr",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Total to meet or exceed",
+                        "name": "target",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.DistributionResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/roll/verify": {
+            "get": {
+                "description": "Roll dice again from a revealed server seed, a client seed and a nonce, giving the same dice as /roll did with the commitment of the server seed",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "dice"
+                ],
+                "summary": "Verify a roll",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dice notation, + being encoded %2B in the query",
+                        "name": "expr",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Revealed server seed",
+                        "name": "serverSeed",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client seed",
+                        "name": "client",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Nonce of the roll",
+                        "name": "nonce",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.RollResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/spectrum/ws": {
+            "get": {
+                "description": "Websocket to open to run spectrums, whose participants can also roll dice with roll followed by a notation like 4d6kh3+2,\nevery participant receiving rolled followed by the roll as JSON with the nickname and color of the roller and the time,\nand get the last rolls of the room with history, also sent when joining",
+                "tags": [
+                    "spectrum"
+                ],
+                "summary": "SpectrumWebsocket to run spectrum with a party of 2 to 6 players",
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols"
+                    }
+                }
+            }
+        },
+        "/status": {
+            "get": {
+                "description": "Get the status of the API",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Healthcheck",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Health"
+                        }
+                    }
+                }
+            }
+        },
+        "/status/live": {
+            "get": {
+                "description": "Checks that the websocket hubs are still running, answering 503 otherwise",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ProbeResult"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.ProbeResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/status/ready": {
+            "get": {
+                "description": "Checks the dependencies of the API: the upstream DNS server, Notion and the digits files.\nAnswers 503 when a critical check fails, the other failures only degrading the status.",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ProbeResult"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.ProbeResult"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.BigNumberResult": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "tree": {
+                    "description": "Parse tree of an evaluated expression, when asked.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/math.Node"
+                        }
+                    ]
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.BucketResult": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.BuildResult": {
+            "type": "object",
+            "properties": {
+                "commit": {
+                    "type": "string"
+                },
+                "goVersion": {
+                    "type": "string"
+                },
+                "uptime": {
+                    "description": "Seconds since the API started.",
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.CheckResult": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "description": "Milliseconds the check took.",
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.CoinsResult": {
+            "type": "object",
+            "properties": {
+                "flips": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "heads": {
+                    "type": "integer"
+                },
+                "tails": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ConversionResult": {
+            "type": "object",
+            "properties": {
+                "exact": {
+                    "type": "boolean"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "rational": {
+                    "type": "string"
+                },
+                "result": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.DNSResolution": {
+            "type": "object",
+            "properties": {
+                "resolution": {},
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.DeckResult": {
+            "type": "object",
+            "properties": {
+                "expires": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "remaining": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.DieResult": {
+            "type": "object",
+            "properties": {
+                "die": {
+                    "type": "integer"
+                },
+                "result": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.DistributionResult": {
+            "type": "object",
+            "properties": {
+                "atLeastTarget": {
+                    "type": "number"
+                },
+                "expression": {
+                    "type": "string"
+                },
+                "mean": {
+                    "type": "string"
+                },
+                "outcomes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.OutcomeResult"
+                    }
+                },
+                "residual": {
+                    "type": "string"
+                },
+                "stdDev": {
+                    "type": "string"
+                },
+                "target": {
+                    "type": "integer"
+                },
+                "variance": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.DrawResult": {
+            "type": "object",
+            "properties": {
+                "cards": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "remaining": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.FactorResult": {
+            "type": "object",
+            "properties": {
+                "exponent": {
+                    "type": "integer"
+                },
+                "prime": {
+                    "type": "boolean"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.FactorizationResult": {
+            "type": "object",
+            "properties": {
+                "complete": {
+                    "type": "boolean"
+                },
+                "factors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.FactorResult"
+                    }
+                },
+                "number": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.FloatsResult": {
+            "type": "object",
+            "properties": {
+                "max": {
+                    "type": "number"
+                },
+                "min": {
+                    "type": "number"
+                },
+                "values": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                }
+            }
+        },
+        "api.Health": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.IntegersResult": {
+            "type": "object",
+            "properties": {
+                "max": {
+                    "type": "integer"
+                },
+                "min": {
+                    "type": "integer"
+                },
+                "values": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "api.KeyUsage": {
+            "type": "object",
+            "properties": {
+                "day": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "quota": {
+                    "type": "integer"
+                },
+                "requests": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.Link": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Tag"
+                    }
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.LinksPage": {
+            "type": "object",
+            "properties": {
+                "links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Link"
+                    }
+                },
+                "next": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.OutcomeResult": {
+            "type": "object",
+            "properties": {
+                "atLeast": {
+                    "type": "number"
+                },
+                "fraction": {
+                    "type": "string"
+                },
+                "probability": {
+                    "type": "number"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.PercentileResult": {
+            "type": "object",
+            "properties": {
+                "rank": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.PickResult": {
+            "type": "object",
+            "properties": {
+                "picks": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.PrimeResult": {
+            "type": "object",
+            "properties": {
+                "next": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "previous": {
+                    "type": "string"
+                },
+                "prime": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.ProbeResult": {
+            "type": "object",
+            "properties": {
+                "build": {
+                    "$ref": "#/definitions/api.BuildResult"
+                },
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.CheckResult"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.RegressionResult": {
+            "type": "object",
+            "properties": {
+                "correlation": {
+                    "type": "string"
+                },
+                "intercept": {
+                    "type": "string"
+                },
+                "slope": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.RevealResult": {
+            "type": "object",
+            "properties": {
+                "commitment": {
+                    "type": "string"
+                },
+                "rolls": {
+                    "type": "integer"
+                },
+                "serverSeed": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.RollGroupResult": {
+            "type": "object",
+            "properties": {
+                "dice": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.RolledDieResult"
+                    }
+                },
+                "notation": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.RollResult": {
+            "type": "object",
+            "properties": {
+                "expression": {
+                    "type": "string"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.RollGroupResult"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "verification": {
+                    "$ref": "#/definitions/api.VerificationResult"
+                }
+            }
+        },
+        "api.RolledDieResult": {
+            "type": "object",
+            "properties": {
+                "die": {
+                    "type": "string"
+                },
+                "dropped": {
+                    "type": "boolean"
+                },
+                "exploded": {
+                    "type": "boolean"
+                },
+                "failure": {
+                    "type": "boolean"
+                },
+                "rerolled": {
+                    "type": "boolean"
+                },
+                "result": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.SearchResult": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "offsets": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.SeedResult": {
+            "type": "object",
+            "properties": {
+                "commitment": {
+                    "type": "string"
+                },
+                "expires": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ShuffleResult": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.StatisticsResult": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "histogram": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.BucketResult"
+                    }
+                },
+                "max": {
+                    "type": "string"
+                },
+                "mean": {
+                    "type": "string"
+                },
+                "median": {
+                    "type": "string"
+                },
+                "min": {
+                    "type": "string"
+                },
+                "modes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "percentiles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.PercentileResult"
+                    }
+                },
+                "regression": {
+                    "$ref": "#/definitions/api.RegressionResult"
+                },
+                "sampleStddev": {
+                    "type": "string"
+                },
+                "sampleVariance": {
+                    "type": "string"
+                },
+                "stddev": {
+                    "type": "string"
+                },
+                "sum": {
+                    "type": "string"
+                },
+                "variance": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.StatsResult": {
+            "type": "object",
+            "properties": {
+                "finishedMatches": {
+                    "type": "integer"
+                },
+                "ongoingMatches": {
+                    "type": "integer"
+                },
+                "onlinePlayers": {
+                    "type": "integer"
+                },
+                "pendingMatches": {
+                    "type": "integer"
+                },
+                "totalMatches": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.Tag": {
+            "type": "object",
+            "properties": {
+                "color": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.UUIDsResult": {
+            "type": "object",
+            "properties": {
+                "uuids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.UsageResult": {
+            "type": "object",
+            "properties": {
+                "usage": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.KeyUsage"
+                    }
+                }
+            }
+        },
+        "api.VerificationResult": {
+            "type": "object",
+            "properties": {
+                "clientSeed": {
+                    "type": "string"
+                },
+                "commitment": {
+                    "type": "string"
+                },
+                "nonce": {
+                    "type": "integer"
+                },
+                "serverSeed": {
+                    "type": "string"
+                }
+            }
+        },
+        "math.Node": {
+            "type": "object",
+            "properties": {
+                "args": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/math.Node"
+                    }
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "utils.ErrorResult": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v2.DNSAnswer": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "records": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.DNSRecord"
+                    }
+                },
+                "ttl": {
+                    "description": "Lowest TTL of the records, in seconds.",
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2.DNSRecord": {
+            "type": "object",
+            "properties": {
+                "flag": {
+                    "description": "CAA records only.",
+                    "type": "integer"
+                },
+                "preference": {
+                    "description": "MX records only.",
+                    "type": "integer"
+                },
+                "tag": {
+                    "type": "string"
+                },
+                "ttl": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        }
+    }
+}`
+
+// SwaggerInfov2 holds exported Swagger Info so clients can modify it
+var SwaggerInfov2 = &swag.Spec{
+	Version:          "2.0",
+	Host:             "",
+	BasePath:         "/api/v2",
+	Schemes:          []string{},
+	Title:            "utile.space Open API",
+	Description:      "The collection of free API from utile.space, the Swiss Army Knife webtool.\nEvery error is answered with the same error model, in the format negotiated with the Accept header.",
+	InfoInstanceName: "v2",
+	SwaggerTemplate:  docTemplatev2,
+	LeftDelim:        "{{",
+	RightDelim:       "}}",
+}
+
+func init() {
+	swag.Register(SwaggerInfov2.InstanceName(), SwaggerInfov2)
+}
@@ -2,10 +2,12 @@ package entities
 
 import (
 	"bytes"
+	"context"
 	"time"
 
 	"github.com/gorilla/websocket"
 	log "github.com/sirupsen/logrus"
+	"utile.space/api/infrastructure/metrics"
 )
 
 const (
@@ -20,6 +22,9 @@ const (
 
 	// Maximum message size allowed from peer.
 	maxMessageSize = 512
+
+	// Label of the endpoint in the websocket metrics.
+	metricsEndpoint = "battleships"
 )
 
 var (
@@ -42,18 +47,30 @@ type Client struct {
 	id int
 
 	PlayerID string
+
+	// Logger of the request which opened the connection.
+	logger *log.Entry
 }
 
-func NewClient(hub *Hub, conn *websocket.Conn) *Client {
+func NewClient(hub *Hub, conn *websocket.Conn, logger *log.Entry) *Client {
 	lastClientId = lastClientId + 1
 	return &Client{
-		Hub:  hub,
-		conn: conn,
-		Send: make(chan []byte, 256),
-		id:   lastClientId,
+		Hub:    hub,
+		conn:   conn,
+		Send:   make(chan []byte, 256),
+		id:     lastClientId,
+		logger: logger,
 	}
 }
 
+// Logger returns the logger of the connection, with the client and player identifiers
+func (c *Client) Logger() *log.Entry {
+	return c.logger.WithFields(log.Fields{
+		"client": c.id,
+		"player": c.PlayerID,
+	})
+}
+
 func (c *Client) SetPlayerID(playerID string) {
 	c.PlayerID = playerID
 }
@@ -63,7 +80,7 @@ func (c *Client) SetPlayerID(playerID string) {
 // The application runs ReadPump in a per-connection goroutine. The application
 // ensures that there is at most one reader on a connection by executing all
 // reads from this goroutine.
-func (c *Client) ReadPump() {
+func (c *Client) ReadPump(ctx context.Context) {
 	defer func() {
 		c.Hub.unregister <- c
 		c.conn.Close()
@@ -72,7 +89,7 @@ func (c *Client) ReadPump() {
 	c.conn.SetReadLimit(maxMessageSize)
 	err := c.conn.SetReadDeadline(time.Now().Add(pongWait))
 	if err != nil {
-		log.Warnf("ReadPump error: %v", err)
+		c.Logger().Warnf("ReadPump error: %v", err)
 	}
 	c.conn.SetPongHandler(func(string) error { err := c.conn.SetReadDeadline(time.Now().Add(pongWait)); return err })
 
@@ -80,15 +97,16 @@ func (c *Client) ReadPump() {
 		_, message, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Warnf("ReadPump error: %v", err)
+				c.Logger().Warnf("ReadPump error: %v", err)
 			}
 			break
 		}
 		message = bytes.TrimSpace(bytes.Replace(message, newline, space, -1))
+		metrics.WebsocketMessage(metricsEndpoint, metrics.In)
 
-		err = c.EvaluateRPC(string(message))
+		err = c.EvaluateRPC(ctx, string(message))
 		if err != nil {
-			log.Debugf("ReadPump read error: %v", err)
+			c.Logger().Debugf("ReadPump read error: %v", err)
 		}
 	}
 }
@@ -109,12 +127,12 @@ func (c *Client) WritePump() {
 		case message, ok := <-c.Send:
 			err := c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err != nil {
-				log.Warnf("WritePump error: %v", err)
+				c.Logger().Warnf("WritePump error: %v", err)
 			}
 			if !ok {
 				// The hub closed the channel.
 				err = c.conn.WriteMessage(websocket.CloseMessage, []byte{})
-				log.Warnf("WritePump channel closed error: %v", err)
+				c.Logger().Warnf("WritePump channel closed error: %v", err)
 				return
 			}
 
@@ -125,22 +143,24 @@ func (c *Client) WritePump() {
 
 			n, err := w.Write(message)
 			if n != len(message) {
-				log.Warn("Different length written and expected in the websocket")
+				c.Logger().Warn("Different length written and expected in the websocket")
 			}
 			if err != nil {
-				log.Warnf("WritePump error: %v", err)
+				c.Logger().Warnf("WritePump error: %v", err)
 			}
+			metrics.WebsocketMessage(metricsEndpoint, metrics.Out)
 
 			// Add queued chat messages to the current websocket message.
 			n = len(c.Send)
 			for i := 0; i < n; i++ {
 				if _, err = w.Write(newline); err != nil {
-					log.Warnf("WritePump error: %v", err)
+					c.Logger().Warnf("WritePump error: %v", err)
 				}
 
 				if _, err = w.Write(<-c.Send); err != nil {
-					log.Warnf("WritePump error: %v", err)
+					c.Logger().Warnf("WritePump error: %v", err)
 				}
+				metrics.WebsocketMessage(metricsEndpoint, metrics.Out)
 			}
 
 			if err := w.Close(); err != nil {
@@ -148,7 +168,7 @@ func (c *Client) WritePump() {
 			}
 		case <-ticker.C:
 			if err := c.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
-				log.Warnf("WritePump error: %v", err)
+				c.Logger().Warnf("WritePump error: %v", err)
 			}
 
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
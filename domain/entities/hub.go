@@ -7,12 +7,12 @@ package entities
 import (
 	"context"
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 	"utile.space/api/domain/valueobjects"
+	"utile.space/api/infrastructure/metrics"
 	"utile.space/api/utils"
 )
 
@@ -35,17 +35,37 @@ type Hub struct {
 
 	// Unregister requests from clients.
 	unregister chan *Client
+
+	// Liveness probes, closed by the runner.
+	pings chan chan struct{}
+
+	// Requests of the counts, answered by the runner.
+	stats chan chan Stats
+
+	logger *log.Entry
+}
+
+// Stats are the counts of the players and matches of the hub
+type Stats struct {
+	OnlinePlayers   int
+	PendingMatches  int
+	OngoingMatches  int
+	FinishedMatches int
+	TotalMatches    int
 }
 
-func NewHub() *Hub {
+func NewHub(logger *log.Entry) *Hub {
 	return &Hub{
 		messages:                make(chan *valueobjects.Message),
 		Register:                make(chan *Client),
 		unregister:              make(chan *Client),
+		pings:                   make(chan chan struct{}),
+		stats:                   make(chan chan Stats),
 		clients:                 make(map[*Client]bool),
 		players:                 make(map[string]*Player),
 		mappingPlayerIDToClient: make(map[string]*Client),
 		matches:                 make(map[string]*Match),
+		logger:                  logger,
 	}
 }
 
@@ -136,7 +156,7 @@ func (h *Hub) JoinPrivateMatch(player string, password string) (string, error) {
 
 func (h *Hub) JoinMatch(matchID string, player2 string) {
 	if err := h.matches[matchID].Player2Join(player2); err != nil {
-		fmt.Println(err)
+		h.logger.Warnf("JoinMatch error: %v", err)
 	}
 
 	h.MessagePlayer(h.matches[matchID].players[0].playerID, matchID, "joined")
@@ -199,10 +219,10 @@ func (h *Hub) EndMatch(player string, reason string) {
 
 		err := h.MessageOpponent(player, matchID, reason)
 		if err != nil {
-			log.Warnf("EndMatch error: %v", err)
+			h.logger.Warnf("EndMatch error: %v", err)
 		}
 	} else {
-		log.Warnf("EndMatch warning: match not found")
+		h.logger.Warnf("EndMatch warning: match not found")
 	}
 }
 
@@ -225,7 +245,7 @@ func (h *Hub) QuickMatch(player2 string) (string, error) {
 		if match.IsPendingPlayer() {
 			err := match.Player2Join(player2)
 			if err != nil {
-				log.Warnf("QuickMatch error: %v", err)
+				h.logger.Warnf("QuickMatch error: %v", err)
 				continue
 			}
 			h.MessagePlayer("", match.players[0].playerID, "joined")
@@ -234,23 +254,60 @@ func (h *Hub) QuickMatch(player2 string) (string, error) {
 		}
 	}
 
-	fmt.Println("no match found")
+	h.logger.Debug("QuickMatch: no match found")
 
 	return "", errors.New("no match found")
 }
 
+// Ping checks that the runner is still processing the channels, failing when it does not answer before ctx is done
+func (h *Hub) Ping(ctx context.Context) error {
+	pong := make(chan struct{})
+
+	select {
+	case h.pings <- pong:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-pong:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the counts of the hub, read by the runner so that they do not race with its changes
+func (h *Hub) Stats(ctx context.Context) (Stats, error) {
+	// NOTE: buffered so that the runner never waits for a caller which gave up
+	reply := make(chan Stats, 1)
+
+	select {
+	case h.stats <- reply:
+	case <-ctx.Done():
+		return Stats{}, ctx.Err()
+	}
+
+	select {
+	case stats := <-reply:
+		return stats, nil
+	case <-ctx.Done():
+		return Stats{}, ctx.Err()
+	}
+}
+
 func (h *Hub) Run(ctx context.Context) {
-	log.Debug("Hub runner starting...")
+	h.logger.Debug("Hub runner starting...")
 	for {
 		select {
 		case client := <-h.Register:
 			h.clients[client] = true
-			log.WithFields(log.Fields{
+			h.logger.WithFields(log.Fields{
 				"connectionsOpened": len(h.clients),
 			}).Debug("New player connected")
 		case client := <-h.unregister:
 			if _, ok := h.clients[client]; ok {
-				log.WithFields(log.Fields{
+				h.logger.WithFields(log.Fields{
 					"player": client.PlayerID,
 				}).Debug("Unregistering client")
 				delete(h.clients, client)
@@ -263,6 +320,7 @@ func (h *Hub) Run(ctx context.Context) {
 					select {
 					case client.Send <- message.Content():
 					default:
+						metrics.WebsocketDrop(metricsEndpoint)
 						delete(h.clients, client)
 						delete(h.mappingPlayerIDToClient, client.PlayerID)
 						close(client.Send)
@@ -273,8 +331,18 @@ func (h *Hub) Run(ctx context.Context) {
 					client.Send <- message.Content()
 				}
 			}
+		case pong := <-h.pings:
+			close(pong)
+		case reply := <-h.stats:
+			reply <- Stats{
+				OnlinePlayers:   h.CountOnlinePlayers(),
+				PendingMatches:  h.CountPendingMatches(),
+				OngoingMatches:  h.CountOngoingMatches(),
+				FinishedMatches: h.CountFinishedMatches(),
+				TotalMatches:    h.CountTotalMatches(),
+			}
 		case <-ctx.Done():
-			log.Info("Hub runner terminated...")
+			h.logger.Info("Hub runner terminated...")
 			return
 		}
 	}
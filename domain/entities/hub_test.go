@@ -6,14 +6,16 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_CountOnlinePlayers(t *testing.T) {
 	t.Parallel()
 
-	client := NewClient(nil, nil)
-	client2 := NewClient(nil, nil)
+	logger := log.NewEntry(log.StandardLogger())
+	client := NewClient(nil, nil, logger)
+	client2 := NewClient(nil, nil, logger)
 	uuid1 := uuid.NewString()
 	uuid2 := uuid.NewString()
 
@@ -48,7 +50,7 @@ func Test_CountOnlinePlayers(t *testing.T) {
 			ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 			defer cancel()
 
-			hub := NewHub()
+			hub := NewHub(logger)
 			go hub.Run(ctx)
 
 			for i, client := range tc.givenClients {
@@ -62,3 +64,44 @@ func Test_CountOnlinePlayers(t *testing.T) {
 		})
 	}
 }
+
+func Test_Ping(t *testing.T) {
+	t.Parallel()
+
+	hub := NewHub(log.NewEntry(log.StandardLogger()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, hub.Ping(ctx), context.DeadlineExceeded)
+
+	runCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go hub.Run(runCtx)
+
+	assert.NoError(t, hub.Ping(context.Background()))
+}
+
+func Test_Stats(t *testing.T) {
+	t.Parallel()
+
+	logger := log.NewEntry(log.StandardLogger())
+	hub := NewHub(logger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := hub.Stats(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	runCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go hub.Run(runCtx)
+
+	client := NewClient(nil, nil, logger)
+	client.SetPlayerID(uuid.NewString())
+	hub.RecordPlayer(client.PlayerID, client)
+	hub.NewMatch(client.PlayerID)
+
+	stats, err := hub.Stats(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, Stats{OnlinePlayers: 1, PendingMatches: 1, TotalMatches: 1}, stats)
+}
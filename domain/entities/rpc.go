@@ -1,13 +1,15 @@
 package entities
 
 import (
+	"context"
 	"errors"
 	"regexp"
 	"strconv"
 	"strings"
 
-	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 	"utile.space/api/domain/valueobjects"
+	"utile.space/api/infrastructure/tracing"
 )
 
 var (
@@ -22,13 +24,21 @@ var (
 )
 
 //nolint:gocyclo
-func (c *Client) EvaluateRPC(command string) error {
+func (c *Client) EvaluateRPC(ctx context.Context, command string) (err error) {
+	_, span := tracing.Start(ctx, "battleships.rpc", attribute.Int("client.id", c.id))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
 	subMatch := r.FindStringSubmatch(command)
 	if subMatch == nil {
 		return errors.Join(ErrCommandNotRecognized, errors.New(command))
 	}
 
-	log.Debug("RPC " + subMatch[0])
+	span.SetName("battleships.rpc " + subMatch[1])
+	span.SetAttributes(attribute.String("rpc.method", subMatch[1]), attribute.String("player.id", c.PlayerID))
+
+	c.Logger().Debug("RPC " + subMatch[0])
 
 	hub := c.Hub
 
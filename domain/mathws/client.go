@@ -0,0 +1,275 @@
+package mathws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+	"utile.space/api/infrastructure/metrics"
+)
+
+const (
+	// Time allowed to write a message to the peer.
+	writeWait = 10 * time.Second
+
+	// Time allowed to read the next pong message from the peer.
+	pongWait = 60 * time.Second
+
+	// Send pings to peer with this period. Must be less than pongWait.
+	pingPeriod = (pongWait * 9) / 10
+
+	// Maximum message size allowed from peer.
+	maxMessageSize = 512
+
+	// Label of the endpoint in the websocket metrics.
+	metricsEndpoint = "math"
+)
+
+// Client answers the requests of a math websocket connection
+type Client struct {
+	// The websocket connection.
+	conn *websocket.Conn
+
+	// Buffered channel of outbound messages.
+	send chan []byte
+
+	// Directory of the digits files the pages are read from.
+	dir string
+
+	// Streams running, by request ID.
+	mu      sync.Mutex
+	streams map[string]*stream
+
+	// Logger of the request which opened the connection.
+	logger *log.Entry
+}
+
+// stream pushes pages until it is stopped
+type stream struct {
+	stop context.CancelFunc
+}
+
+func NewClient(conn *websocket.Conn, dir string, logger *log.Entry) *Client {
+	return &Client{
+		conn:    conn,
+		send:    make(chan []byte, 256),
+		dir:     dir,
+		streams: make(map[string]*stream),
+		logger:  logger,
+	}
+}
+
+// Serve answers the requests until the connection is closed by the peer, by an error or by the context
+func (c *Client) Serve(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go c.WritePump(ctx, cancel)
+	c.ReadPump(ctx, cancel)
+}
+
+// ReadPump reads the requests and answers them, until the connection is closed.
+//
+// The streams run in their own goroutines, the other requests being answered in turn.
+func (c *Client) ReadPump(ctx context.Context, cancel context.CancelFunc) {
+	defer cancel()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	err := c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	if err != nil {
+		c.logger.Warnf("ReadPump error: %v", err)
+	}
+	c.conn.SetPongHandler(func(string) error { err := c.conn.SetReadDeadline(time.Now().Add(pongWait)); return err })
+
+	for {
+		_, message, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.CloseNormalClosure) {
+				c.logger.Warnf("ReadPump error: %v", err)
+			}
+			return
+		}
+		metrics.WebsocketMessage(metricsEndpoint, metrics.In)
+
+		request, err := parse(bytes.TrimSpace(message))
+		if err != nil {
+			c.reply(ctx, request, Reply{ID: request.ID, Command: request.Command, Error: asError(err)})
+			continue
+		}
+		c.logger.Debugf("ReadPump request %s %s", request.Command, request.ID)
+
+		c.evaluate(ctx, request)
+	}
+}
+
+// WritePump writes the replies and pings the peer, until the connection fails or the context is done.
+func (c *Client) WritePump(ctx context.Context, cancel context.CancelFunc) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		cancel()
+		c.conn.Close()
+	}()
+	for {
+		select {
+		case message := <-c.send:
+			if err := c.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+				c.logger.Warnf("WritePump error: %v", err)
+			}
+
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				c.logger.Warnf("WritePump error: %v", err)
+				return
+			}
+			metrics.WebsocketMessage(metricsEndpoint, metrics.Out)
+		case <-ticker.C:
+			if err := c.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+				c.logger.Warnf("WritePump error: %v", err)
+			}
+
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			err := c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeWait))
+			if err != nil && !errors.Is(err, websocket.ErrCloseSent) {
+				c.logger.Debugf("WritePump close error: %v", err)
+			}
+			return
+		}
+	}
+}
+
+// evaluate answers the request, starting or stopping a stream
+func (c *Client) evaluate(ctx context.Context, request Request) {
+	reply := Reply{ID: request.ID, Command: request.Command}
+
+	var err error
+	switch request.Command {
+	case PageCommand:
+		reply.Result, err = readPage(c.dir, request, request.Page)
+	case ConstantCommand:
+		reply.Result, err = constant(request)
+	case SearchCommand:
+		reply.Result, err = search(request)
+	case StreamCommand:
+		if err = c.startStream(ctx, request); err == nil {
+			return
+		}
+	case StopCommand:
+		if err = c.stopStream(request.ID); err == nil {
+			return
+		}
+	default:
+		err = failure(http.StatusBadRequest, "Unknown command")
+	}
+
+	if err != nil {
+		reply.Result, reply.Error = nil, asError(err)
+	}
+	c.reply(ctx, request, reply)
+}
+
+// startStream checks the first page of the stream can be read, then pushes the pages in a goroutine
+func (c *Client) startStream(ctx context.Context, request Request) error {
+	first, err := readPage(c.dir, request, request.Page)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.streams[request.ID]; ok {
+		return failure(http.StatusConflict, "A stream with this id is running")
+	}
+	if len(c.streams) >= MaxStreams {
+		return failure(http.StatusTooManyRequests, "Too many streams")
+	}
+
+	streamCtx, stop := context.WithCancel(ctx)
+	s := &stream{stop: stop}
+	c.streams[request.ID] = s
+
+	go c.stream(ctx, streamCtx, s, request, first)
+	return nil
+}
+
+// stopStream stops the stream with the ID, which replies it is done
+func (c *Client) stopStream(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.streams[id]
+	if !ok {
+		return failure(http.StatusNotFound, "No stream with this id")
+	}
+	s.stop()
+	delete(c.streams, id)
+	return nil
+}
+
+// stream pushes the pages from first until the end of the digits file or a stop, then replies it is done
+func (c *Client) stream(ctx context.Context, streamCtx context.Context, s *stream, request Request, first PageResult) {
+	defer func() {
+		c.mu.Lock()
+		if c.streams[request.ID] == s {
+			delete(c.streams, request.ID)
+		}
+		c.mu.Unlock()
+		s.stop()
+	}()
+
+	reply := Reply{ID: request.ID, Command: StreamCommand}
+	next := first
+	for {
+		reply.Result = next
+		if !c.reply(streamCtx, request, reply) {
+			break
+		}
+
+		var err error
+		next, err = readPage(c.dir, request, next.Page+1)
+		if err != nil {
+			// NOTE: the first page was read when the stream started, so being out of range is the end of the file
+			if !errors.Is(err, errPageOutOfRange) {
+				reply.Error = asError(err)
+			}
+			break
+		}
+	}
+
+	reply.Result, reply.Done = nil, true
+	c.reply(ctx, request, reply)
+}
+
+// reply queues the reply to the request for the WritePump, waiting while the buffer is full.
+// It returns false when the context is done before.
+func (c *Client) reply(ctx context.Context, request Request, reply Reply) bool {
+	message, err := reply.encode(request.legacy)
+	if err != nil {
+		c.logger.Warnf("reply error: %v", err)
+		return true
+	}
+
+	select {
+	case c.send <- message:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func asError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return failure(http.StatusInternalServerError, err.Error())
+}
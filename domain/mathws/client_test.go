@@ -0,0 +1,241 @@
+package mathws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"utile.space/api/domain/services/math"
+)
+
+// serve starts a math websocket serving the digits files of dir, done being closed when the client returns
+func serve(t *testing.T, dir string) (conn *websocket.Conn, done chan struct{}) {
+	done = make(chan struct{})
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		NewClient(c, dir, log.NewEntry(log.StandardLogger())).Serve(context.Background())
+		close(done)
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn, done
+}
+
+func receive(t *testing.T, conn *websocket.Conn) Reply {
+	assert.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, message, err := conn.ReadMessage()
+	assert.NoError(t, err)
+
+	var reply struct {
+		Reply
+		Result json.RawMessage `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(message, &reply))
+	if len(reply.Result) > 0 {
+		var result PageResult
+		assert.NoError(t, json.Unmarshal(reply.Result, &result))
+		reply.Reply.Result = result
+	}
+	return reply.Reply
+}
+
+func digits(t *testing.T, value string) string {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "pi"), []byte(value), 0o644))
+	return dir
+}
+
+func Test_Requests(t *testing.T) {
+	conn, _ := serve(t, digits(t, "3.14159265"))
+
+	tt := map[string]struct {
+		request         string
+		expectedReply   string
+		expectedCommand string
+	}{
+		"page": {
+			request:       `{"id":"1","command":"page","constant":"pi","page":1,"size":4}`,
+			expectedReply: `{"id":"1","command":"page","result":{"constant":"pi","page":1,"size":4,"value":"1592"}}`,
+		},
+		"last page": {
+			request:       `{"id":"2","command":"page","constant":"pi","page":2,"size":4}`,
+			expectedReply: `{"id":"2","command":"page","result":{"constant":"pi","page":2,"size":4,"value":"65"}}`,
+		},
+		"default page size": {
+			request:       `{"id":"3","command":"page","constant":"pi"}`,
+			expectedReply: `{"id":"3","command":"page","result":{"constant":"pi","page":0,"size":1000,"value":"3.14159265"}}`,
+		},
+		"constant": {
+			request:       `{"id":"4","command":"constant","constant":"e","digits":5}`,
+			expectedReply: `{"id":"4","command":"constant","result":{"name":"e","value":"2.71828"}}`,
+		},
+		"page too large": {
+			request:       `{"id":"5","command":"page","constant":"pi","size":10001}`,
+			expectedReply: `{"id":"5","command":"page","error":{"status":400,"message":"Size must be between 1 and 10000"}}`,
+		},
+		"page out of range": {
+			request:       `{"id":"6","command":"page","constant":"pi","page":3,"size":4}`,
+			expectedReply: `{"id":"6","command":"page","error":{"status":400,"message":"Page out of range"}}`,
+		},
+		"unknown constant": {
+			request:       `{"id":"7","command":"page","constant":"../pi"}`,
+			expectedReply: `{"id":"7","command":"page","error":{"status":404,"message":"Constant not found"}}`,
+		},
+		"constant not stored": {
+			request:       `{"id":"8","command":"page","constant":"e"}`,
+			expectedReply: `{"id":"8","command":"page","error":{"status":404,"message":"Constant not stored"}}`,
+		},
+		"digits out of range": {
+			request:       `{"id":"9","command":"constant","constant":"e","digits":-1}`,
+			expectedReply: `{"id":"9","command":"constant","error":{"status":400,"message":"Digits must be between 0 and 100000"}}`,
+		},
+		"unknown command": {
+			request:       `{"id":"10","command":"tau"}`,
+			expectedReply: `{"id":"10","command":"tau","error":{"status":400,"message":"Unknown command"}}`,
+		},
+		"invalid request": {
+			request:       `{"id":11}`,
+			expectedReply: `{"id":"","command":"","error":{"status":400,"message":"Invalid request"}}`,
+		},
+		"stop without stream": {
+			request:       `{"id":"12","command":"stop"}`,
+			expectedReply: `{"id":"12","command":"stop","error":{"status":404,"message":"No stream with this id"}}`,
+		},
+		"legacy page": {
+			request:       "pi 1, 4",
+			expectedReply: "1592",
+		},
+		"legacy constant": {
+			request:       "e 0",
+			expectedReply: "2",
+		},
+		"legacy page too large": {
+			request:       "pi 0, 100000",
+			expectedReply: "Size must be between 1 and 10000",
+		},
+		"legacy unknown command": {
+			request:       "hello",
+			expectedReply: "Unknown command",
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(tc.request)))
+
+			_, message, err := conn.ReadMessage()
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedReply, string(message))
+		})
+	}
+}
+
+func Test_Search(t *testing.T) {
+	dir := digits(t, "3.14159265358979323846264338327950288419716939937510")
+	assert.NoError(t, math.IndexAssets(dir, "pi"))
+	conn, _ := serve(t, dir)
+
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"id":"s","command":"search","constant":"pi","query":"9","page":1,"size":3}`)))
+	_, message, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":"s","command":"search","result":{"constant":"pi","query":"9","total":8,"page":1,"size":3,"offsets":[29,37,41]}}`, string(message))
+
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("search pi 99")))
+	_, message, err = conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "43", string(message))
+}
+
+func Test_Stream(t *testing.T) {
+	conn, _ := serve(t, digits(t, "3.14159265"))
+
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"id":"s","command":"stream","constant":"pi","size":4}`)))
+
+	var values []string
+	for {
+		reply := receive(t, conn)
+		assert.Equal(t, "s", reply.ID)
+		assert.Equal(t, StreamCommand, reply.Command)
+		assert.Nil(t, reply.Error)
+		if reply.Done {
+			break
+		}
+		values = append(values, reply.Result.(PageResult).Value)
+	}
+
+	assert.Equal(t, []string{"3.14", "1592", "65"}, values)
+}
+
+func Test_StopStream(t *testing.T) {
+	conn, _ := serve(t, digits(t, "3."+strings.Repeat("1", 100000)))
+
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"id":"s","command":"stream","constant":"pi","size":1}`)))
+	assert.Equal(t, 0, receive(t, conn).Result.(PageResult).Page)
+
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"id":"s","command":"stream","constant":"pi","size":1}`)))
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"id":"s","command":"stop"}`)))
+
+	pages, conflict := 1, false
+	for {
+		reply := receive(t, conn)
+		if reply.Error != nil {
+			assert.Equal(t, &Error{Status: http.StatusConflict, Message: "A stream with this id is running"}, reply.Error)
+			conflict = true
+			continue
+		}
+		if reply.Done {
+			break
+		}
+		pages++
+	}
+
+	assert.True(t, conflict)
+	assert.Less(t, pages, 100002)
+}
+
+func Test_TooManyStreams(t *testing.T) {
+	conn, _ := serve(t, digits(t, "3."+strings.Repeat("1", 100000)))
+
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"id":"`+id+`","command":"stream","constant":"pi","size":1}`)))
+	}
+
+	for {
+		reply := receive(t, conn)
+		if reply.Error != nil {
+			assert.Equal(t, "5", reply.ID)
+			assert.Equal(t, http.StatusTooManyRequests, reply.Error.Status)
+			break
+		}
+	}
+}
+
+func Test_Close(t *testing.T) {
+	conn, done := serve(t, digits(t, "3."+strings.Repeat("1", 100000)))
+
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"id":"s","command":"stream","constant":"pi","size":1}`)))
+	assert.NoError(t, conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")))
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "the client is still serving a closed connection")
+	}
+}
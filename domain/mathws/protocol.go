@@ -0,0 +1,277 @@
+package mathws
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"utile.space/api/domain/services/math"
+)
+
+const (
+	// DefaultPageSize is the number of digits of a page when the request does not give its size.
+	DefaultPageSize = 1000
+
+	// MaxPageSize bounds the number of digits of a page.
+	MaxPageSize = 10000
+
+	// DefaultDigits is the number of digits after the point of a constant when the request does not give it.
+	DefaultDigits = 1000
+
+	// DefaultSearchSize is the number of offsets of a search page when the request does not give its size.
+	DefaultSearchSize = 100
+
+	// MaxSearchSize bounds the number of offsets of a search page.
+	MaxSearchSize = 1000
+
+	// MaxStreams bounds the streams running at the same time on a connection.
+	MaxStreams = 4
+)
+
+// Commands of the requests
+const (
+	// PageCommand reads a page of the digits file of a constant.
+	PageCommand = "page"
+	// StreamCommand pushes the pages of a constant from the requested one until the end of the file or a stop.
+	StreamCommand = "stream"
+	// StopCommand stops the stream which has the ID of the request.
+	StopCommand = "stop"
+	// ConstantCommand computes a constant with a number of digits after the point.
+	ConstantCommand = "constant"
+	// SearchCommand finds the offsets of a sequence of digits in a constant.
+	SearchCommand = "search"
+)
+
+// Request is a command sent by the client as a JSON object, the replies carrying its ID
+type Request struct {
+	ID       string `json:"id"`
+	Command  string `json:"command"`
+	Constant string `json:"constant,omitempty"`
+	Page     int    `json:"page,omitempty"`
+	Query    string `json:"query,omitempty"`
+	// Size and Digits have a default when omitted.
+	Size   *int `json:"size,omitempty"`
+	Digits *int `json:"digits,omitempty"`
+
+	// The request was a text command, answered with the value only.
+	legacy bool
+}
+
+// Reply answers a request, a stream sending one reply per page then a last one being done
+type Reply struct {
+	ID      string `json:"id"`
+	Command string `json:"command"`
+	Result  result `json:"result,omitempty"`
+	Done    bool   `json:"done,omitempty"`
+	Error   *Error `json:"error,omitempty"`
+}
+
+// Error is the reply to a request which failed, with the status the HTTP API would have answered
+type Error struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func failure(status int, message string) *Error {
+	return &Error{Status: status, Message: message}
+}
+
+// result is written as JSON, or as text to the legacy commands
+type result interface {
+	text() string
+}
+
+type PageResult struct {
+	Constant string `json:"constant"`
+	Page     int    `json:"page"`
+	Size     int    `json:"size"`
+	Value    string `json:"value"`
+}
+
+func (r PageResult) text() string {
+	return r.Value
+}
+
+type ConstantResult struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func (r ConstantResult) text() string {
+	return r.Value
+}
+
+type SearchResult struct {
+	Constant string `json:"constant"`
+	Query    string `json:"query"`
+	Total    int    `json:"total"`
+	Page     int    `json:"page"`
+	Size     int    `json:"size"`
+	Offsets  []int  `json:"offsets"`
+}
+
+func (r SearchResult) text() string {
+	values := make([]string, len(r.Offsets))
+	for i, offset := range r.Offsets {
+		values[i] = strconv.Itoa(offset)
+	}
+	return strings.Join(values, ", ")
+}
+
+// encode writes the reply as JSON, or as the text the legacy commands have always been answered with
+func (reply Reply) encode(legacy bool) ([]byte, error) {
+	if !legacy {
+		return json.Marshal(reply)
+	}
+	if reply.Error != nil {
+		return []byte(reply.Error.Message), nil
+	}
+	if reply.Result == nil {
+		return []byte{}, nil
+	}
+	return []byte(reply.Result.text()), nil
+}
+
+// NOTE: the text commands came before the JSON requests and are still understood for the existing clients
+var (
+	// pageText asks a page of the digits of a constant, like "pi 3, 1000"
+	pageText = regexp.MustCompile(`^(pi|tau|e|phi|ln2|catalan|zeta3|sqrt[0-9]+)\s+([0-9]+),\s*([0-9]+)$`)
+
+	// constantText asks the value of a constant with a number of digits, like "e 1000"
+	constantText = regexp.MustCompile(`^(e|phi|ln2|catalan|zeta3|sqrt[0-9]+)\s+([0-9]+)$`)
+
+	// searchText asks the first offsets of a digits sequence in a constant, like "search pi 999999"
+	searchText = regexp.MustCompile(`^search\s+([a-z0-9]+)\s+([0-9]+)$`)
+)
+
+// parse reads a JSON request, or a text command
+func parse(message []byte) (Request, error) {
+	if len(message) > 0 && message[0] == '{' {
+		var request Request
+		if err := json.Unmarshal(message, &request); err != nil {
+			return Request{}, failure(http.StatusBadRequest, "Invalid request")
+		}
+		return request, nil
+	}
+
+	request := Request{legacy: true}
+	var err error
+	text := string(message)
+
+	switch {
+	case searchText.MatchString(text):
+		subMatch := searchText.FindStringSubmatch(text)
+		request.Command, request.Constant, request.Query = SearchCommand, subMatch[1], subMatch[2]
+	case constantText.MatchString(text):
+		subMatch := constantText.FindStringSubmatch(text)
+		request.Command, request.Constant = ConstantCommand, subMatch[1]
+		if request.Digits, err = atoi(subMatch[2]); err != nil {
+			return request, err
+		}
+	case pageText.MatchString(text):
+		subMatch := pageText.FindStringSubmatch(text)
+		request.Command, request.Constant = PageCommand, subMatch[1]
+		if request.Page, err = strconv.Atoi(subMatch[2]); err != nil {
+			return request, failure(http.StatusBadRequest, "Invalid page")
+		}
+		if request.Size, err = atoi(subMatch[3]); err != nil {
+			return request, err
+		}
+	default:
+		return request, failure(http.StatusBadRequest, "Unknown command")
+	}
+
+	return request, nil
+}
+
+func atoi(s string) (*int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, failure(http.StatusBadRequest, "Invalid number")
+	}
+	return &n, nil
+}
+
+// valueOr returns the value given in the request, or the default one when omitted
+func valueOr(value *int, defaultValue int) int {
+	if value == nil {
+		return defaultValue
+	}
+	return *value
+}
+
+var errPageOutOfRange = failure(http.StatusBadRequest, "Page out of range")
+
+// readPage reads the page of the request, or the page-th one of a stream
+func readPage(dir string, request Request, page int) (PageResult, error) {
+	size := valueOr(request.Size, DefaultPageSize)
+	if size <= 0 || size > MaxPageSize {
+		return PageResult{}, failure(http.StatusBadRequest, "Size must be between 1 and "+strconv.Itoa(MaxPageSize))
+	}
+
+	value, err := math.ReadPage(dir, request.Constant, page, size)
+	switch {
+	case errors.Is(err, math.ErrUnknownConstant):
+		return PageResult{}, failure(http.StatusNotFound, "Constant not found")
+	case errors.Is(err, os.ErrNotExist):
+		return PageResult{}, failure(http.StatusNotFound, "Constant not stored")
+	case errors.Is(err, math.ErrPageOutOfRange):
+		return PageResult{}, errPageOutOfRange
+	case err != nil:
+		return PageResult{}, failure(http.StatusInternalServerError, "Digits not readable")
+	}
+
+	return PageResult{Constant: request.Constant, Page: page, Size: size, Value: string(value)}, nil
+}
+
+// constant computes the constant of the request
+func constant(request Request) (ConstantResult, error) {
+	name, value, err := math.Constant(request.Constant, valueOr(request.Digits, DefaultDigits))
+	switch {
+	case errors.Is(err, math.ErrUnknownConstant):
+		return ConstantResult{}, failure(http.StatusNotFound, "Constant not found")
+	case errors.Is(err, math.ErrDigitsOutOfRange):
+		return ConstantResult{}, failure(http.StatusBadRequest,
+			"Digits must be between 0 and "+strconv.Itoa(math.MaxDigitsOf(request.Constant, 10)))
+	case err != nil:
+		return ConstantResult{}, failure(http.StatusInternalServerError, err.Error())
+	}
+
+	return ConstantResult{Name: name, Value: value}, nil
+}
+
+// search finds the digits sequence of the request
+func search(request Request) (SearchResult, error) {
+	size := valueOr(request.Size, DefaultSearchSize)
+	if size > MaxSearchSize {
+		return SearchResult{}, failure(http.StatusBadRequest, "Size must be at most "+strconv.Itoa(MaxSearchSize))
+	}
+
+	total, offsets, err := math.Search(request.Constant, request.Query, request.Page, size)
+	switch {
+	case errors.Is(err, math.ErrNotIndexed):
+		return SearchResult{}, failure(http.StatusNotFound, "Constant not indexed")
+	case errors.Is(err, math.ErrInvalidSearch):
+		return SearchResult{}, failure(http.StatusBadRequest,
+			"Query must be 1 to "+strconv.Itoa(math.MaxSearchLength)+" digits, page positive and size strictly positive")
+	case err != nil:
+		return SearchResult{}, failure(http.StatusInternalServerError, err.Error())
+	}
+
+	return SearchResult{
+		Constant: request.Constant,
+		Query:    request.Query,
+		Total:    total,
+		Page:     request.Page,
+		Size:     size,
+		Offsets:  offsets,
+	}, nil
+}
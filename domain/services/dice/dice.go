@@ -0,0 +1,494 @@
+package dice
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"utile.space/api/domain/services/random"
+)
+
+const (
+	// MaxNotationLength bounds the characters of a notation
+	MaxNotationLength = 200
+
+	// MaxGroups bounds the dice groups and numbers added up by a notation
+	MaxGroups = 20
+
+	// MaxDice bounds the dice of a notation before explosions and rerolls
+	MaxDice = 1000
+
+	// MaxSides bounds the sides of a die
+	MaxSides = 1000
+
+	// MaxRolls bounds the dice rolled with the explosions and rerolls, which stop beyond
+	MaxRolls = 10000
+
+	// maxNumberDigits bounds the digits of the numbers of a notation
+	maxNumberDigits = 7
+)
+
+// Source draws a number between 0 and n - 1
+type Source interface {
+	Intn(n int) int
+}
+
+// DefaultSource draws the dice with crypto/rand
+var DefaultSource Source = random.Crypto
+
+// NotationError is a syntax error of a notation, at a position counted in characters from 1
+type NotationError struct {
+	Position int
+	Message  string
+}
+
+func (e *NotationError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Position)
+}
+
+func notationError(position int, format string, a ...any) *NotationError {
+	return &NotationError{Position: position + 1, Message: fmt.Sprintf(format, a...)}
+}
+
+// Compare is a compare point like >5, < and > including the value like in most dice rollers, which also read <= and >=
+type Compare struct {
+	Operator byte
+	Value    int
+}
+
+func (c Compare) Matches(value int) bool {
+	switch c.Operator {
+	case '<':
+		return value <= c.Value
+	case '>':
+		return value >= c.Value
+	default:
+		return value == c.Value
+	}
+}
+
+// Selection keeps or drops the highest or lowest dice of a group
+type Selection struct {
+	Drop    bool
+	Highest bool
+	Count   int
+}
+
+// Group is a number, or dice like 4d6kh3 with their modifiers, added or subtracted according to Sign
+type Group struct {
+	Notation   string
+	Sign       int
+	Constant   int
+	Count      int
+	Sides      int
+	Fate       bool
+	Selection  *Selection
+	Explode    *Compare
+	Reroll     *Compare
+	RerollOnce bool
+	Success    *Compare
+	Failure    *Compare
+}
+
+// Dice tells whether the group is dice rather than a number
+func (g Group) Dice() bool {
+	return g.Count > 0
+}
+
+// faces returns the lowest and highest faces of the dice
+func (g Group) faces() (int, int) {
+	if g.Fate {
+		return -1, 1
+	}
+	return 1, g.Sides
+}
+
+// Expression is a dice notation like 4d6kh3+d8+2
+type Expression struct {
+	Notation string
+	Groups   []Group
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) spaces() {
+	for p.peek() == ' ' {
+		p.pos++
+	}
+}
+
+// number reads digits, telling whether there were some
+func (p *parser) number() (int, bool, error) {
+	start := p.pos
+	for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+		p.pos++
+	}
+	if p.pos == start {
+		return 0, false, nil
+	}
+	if p.pos-start > maxNumberDigits {
+		return 0, false, notationError(start, "number too large")
+	}
+	n, _ := strconv.Atoi(p.s[start:p.pos])
+	return n, true, nil
+}
+
+// Parse reads a notation made of dice groups and numbers separated by + and -. A dice group is the count of dice,
+// 1 by default, d, the sides, % for 100 or F for Fate dice, then the modifiers:
+//   - kh3 or k3 keeps the 3 highest dice, kl3 the 3 lowest, dh3 drops the 3 highest and dl3 or d3 the 3 lowest
+//   - ! explodes the dice on their highest face, adding a die, or on a compare point like !>5
+//   - r rerolls the dice on their lowest face, or on a compare point like r<2, until they do not match, ro only once
+//   - a compare point like >5 counts the successes instead of adding up the dice, and f<2 subtracts the failures
+func Parse(notation string) (Expression, error) {
+	if len(notation) > MaxNotationLength {
+		return Expression{}, notationError(MaxNotationLength, "notation longer than %d characters", MaxNotationLength)
+	}
+
+	lower := []byte(notation)
+	for i, c := range lower {
+		if c >= 'A' && c <= 'Z' {
+			lower[i] = c + 'a' - 'A'
+		}
+	}
+	p := &parser{s: string(lower)}
+
+	e := Expression{Notation: notation}
+	sign := 1
+	p.spaces()
+	switch p.peek() {
+	case '-':
+		sign = -1
+		fallthrough
+	case '+':
+		p.pos++
+	}
+
+	dice := 0
+	for {
+		p.spaces()
+		start := p.pos
+		g, err := p.group(sign)
+		if err != nil {
+			return Expression{}, err
+		}
+		g.Notation = p.s[start:p.pos]
+		if sign < 0 {
+			g.Notation = "-" + g.Notation
+		}
+		if dice += g.Count; dice > MaxDice {
+			return Expression{}, notationError(p.pos-1, "more than %d dice", MaxDice)
+		}
+		if e.Groups = append(e.Groups, g); len(e.Groups) > MaxGroups {
+			return Expression{}, notationError(p.pos-1, "more than %d groups", MaxGroups)
+		}
+
+		p.spaces()
+		switch p.peek() {
+		case 0:
+			return e, nil
+		case '+':
+			sign = 1
+		case '-':
+			sign = -1
+		default:
+			return Expression{}, notationError(p.pos, "unexpected %q", p.s[p.pos])
+		}
+		p.pos++
+	}
+}
+
+func (p *parser) group(sign int) (Group, error) {
+	start := p.pos
+	count, hasCount, err := p.number()
+	if err != nil {
+		return Group{}, err
+	}
+
+	g := Group{Sign: sign}
+	if p.peek() != 'd' {
+		if !hasCount {
+			return Group{}, notationError(p.pos, "expecting dice or a number")
+		}
+		g.Constant = count
+		return g, nil
+	}
+	p.pos++
+
+	switch {
+	case hasCount && count == 0:
+		return Group{}, notationError(start, "expecting at least one die")
+	case hasCount:
+		g.Count = count
+	default:
+		g.Count = 1
+	}
+
+	switch p.peek() {
+	case '%':
+		p.pos++
+		g.Sides = 100
+	case 'f':
+		p.pos++
+		g.Fate = true
+	default:
+		sides, ok, err := p.number()
+		if err != nil {
+			return Group{}, err
+		}
+		if !ok || sides < 2 || sides > MaxSides {
+			return Group{}, notationError(p.pos, "expecting sides between 2 and %d, %% or F", MaxSides)
+		}
+		g.Sides = sides
+	}
+
+	if err := p.modifiers(&g); err != nil {
+		return Group{}, err
+	}
+	return g, nil
+}
+
+func (p *parser) modifiers(g *Group) error {
+	low, high := g.faces()
+	for {
+		position := p.pos
+		switch c := p.peek(); c {
+		case 'k', 'd':
+			p.pos++
+			if g.Selection != nil {
+				return notationError(position, "dice kept or dropped twice")
+			}
+			g.Selection = &Selection{Drop: c == 'd', Highest: c == 'k'}
+			switch p.peek() {
+			case 'h':
+				p.pos++
+				g.Selection.Highest = true
+			case 'l':
+				p.pos++
+				g.Selection.Highest = false
+			}
+
+			n, ok, err := p.number()
+			if err != nil {
+				return err
+			}
+			g.Selection.Count = 1
+			if ok {
+				g.Selection.Count = n
+			}
+		case '!':
+			p.pos++
+			compare, err := p.compare(&Compare{Operator: '=', Value: high})
+			if err != nil {
+				return err
+			}
+			if compare.Matches(low) && compare.Matches(high) {
+				return notationError(position, "dice exploding on every face")
+			}
+			g.Explode = &compare
+		case 'r':
+			p.pos++
+			if p.peek() == 'o' {
+				p.pos++
+				g.RerollOnce = true
+			}
+			compare, err := p.compare(&Compare{Operator: '=', Value: low})
+			if err != nil {
+				return err
+			}
+			if compare.Matches(low) && compare.Matches(high) {
+				return notationError(position, "dice rerolled on every face")
+			}
+			g.Reroll = &compare
+		case 'f':
+			p.pos++
+			compare, err := p.compare(nil)
+			if err != nil {
+				return err
+			}
+			g.Failure = &compare
+		case '<', '>', '=':
+			compare, err := p.compare(nil)
+			if err != nil {
+				return err
+			}
+			g.Success = &compare
+		default:
+			if g.Failure != nil && g.Success == nil {
+				return notationError(position, "failures counted without successes")
+			}
+			return nil
+		}
+	}
+}
+
+// compare reads a compare point, the operator being = by default, and the whole compare point when there is no number
+func (p *parser) compare(fallback *Compare) (Compare, error) {
+	compare := Compare{Operator: '='}
+	explicit := false
+	if c := p.peek(); c == '<' || c == '>' || c == '=' {
+		p.pos++
+		compare.Operator = c
+		explicit = true
+
+		// NOTE: >= and <= are the same as > and <
+		if c != '=' && p.peek() == '=' {
+			p.pos++
+		}
+	}
+
+	negative := explicit && p.peek() == '-'
+	if negative {
+		p.pos++
+	}
+	n, ok, err := p.number()
+	if err != nil {
+		return Compare{}, err
+	}
+	if !ok {
+		if explicit || fallback == nil {
+			return Compare{}, notationError(p.pos, "expecting a compare point like >5")
+		}
+		return *fallback, nil
+	}
+
+	compare.Value = n
+	if negative {
+		compare.Value = -n
+	}
+	return compare, nil
+}
+
+// Die is a rolled die, not counted when it was rerolled or dropped
+type Die struct {
+	Value    int
+	Dropped  bool
+	Rerolled bool
+	Exploded bool
+	Success  bool
+	Failure  bool
+}
+
+func (d Die) counted() bool {
+	return !d.Dropped && !d.Rerolled
+}
+
+// GroupResult is the result of a group, its total being negative when the group is subtracted
+type GroupResult struct {
+	Group Group
+	Dice  []Die
+	Total int
+}
+
+type Result struct {
+	Total  int
+	Groups []GroupResult
+}
+
+type roller struct {
+	source Source
+	rolls  int
+}
+
+func (r *roller) die(g Group) int {
+	r.rolls++
+	low, high := g.faces()
+	return low + r.source.Intn(high-low+1)
+}
+
+// Roll rolls the dice of the expression with the source
+func (e Expression) Roll(source Source) Result {
+	r := &roller{source: source}
+
+	var result Result
+	for _, g := range e.Groups {
+		group := r.group(g)
+		result.Total += group.Total
+		result.Groups = append(result.Groups, group)
+	}
+	return result
+}
+
+func (r *roller) group(g Group) GroupResult {
+	result := GroupResult{Group: g, Dice: []Die{}}
+	if !g.Dice() {
+		result.Total = g.Sign * g.Constant
+		return result
+	}
+
+	for i := 0; i < g.Count; i++ {
+		value := r.die(g)
+		for g.Reroll != nil && g.Reroll.Matches(value) && r.rolls < MaxRolls {
+			result.Dice = append(result.Dice, Die{Value: value, Rerolled: true})
+			value = r.die(g)
+			if g.RerollOnce {
+				break
+			}
+		}
+		// NOTE: every explosion is a die of its own, which may be kept or dropped
+		for g.Explode != nil && g.Explode.Matches(value) && r.rolls < MaxRolls {
+			result.Dice = append(result.Dice, Die{Value: value, Exploded: true})
+			value = r.die(g)
+		}
+		result.Dice = append(result.Dice, Die{Value: value})
+	}
+
+	if g.Selection != nil {
+		selectDice(result.Dice, *g.Selection)
+	}
+
+	for i := range result.Dice {
+		die := &result.Dice[i]
+		if !die.counted() {
+			continue
+		}
+		switch {
+		case g.Success == nil:
+			result.Total += die.Value
+		case g.Success.Matches(die.Value):
+			die.Success = true
+			result.Total++
+		case g.Failure != nil && g.Failure.Matches(die.Value):
+			die.Failure = true
+			result.Total--
+		}
+	}
+	result.Total *= g.Sign
+	return result
+}
+
+// selectDice drops the dice the selection does not keep among the ones not rerolled
+func selectDice(dice []Die, selection Selection) {
+	var order []int
+	for i, die := range dice {
+		if !die.Rerolled {
+			order = append(order, i)
+		}
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return dice[a].Value - dice[b].Value
+	})
+
+	// NOTE: keeping the highest is dropping the lowest, and the other way around
+	dropped := min(selection.Count, len(order))
+	lowest := !selection.Highest
+	if !selection.Drop {
+		dropped = len(order) - dropped
+		lowest = !lowest
+	}
+	if !lowest {
+		slices.Reverse(order)
+	}
+	for _, i := range order[:dropped] {
+		dice[i].Dropped = true
+	}
+}
@@ -0,0 +1,158 @@
+package dice
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sequence draws the faces in order, as offsets from the lowest face
+type sequence []int
+
+func (s *sequence) Intn(n int) int {
+	v := (*s)[0] % n
+	*s = (*s)[1:]
+	return v
+}
+
+func values(dice []Die) []int {
+	values := make([]int, len(dice))
+	for i, die := range dice {
+		values[i] = die.Value
+	}
+	return values
+}
+
+func Test_Roll(t *testing.T) {
+	tt := map[string]struct {
+		notation       string
+		draws          sequence
+		expectedTotal  int
+		expectedValues []int
+		expectedGroups int
+	}{
+		"single die":          {notation: "d20", draws: sequence{16}, expectedTotal: 17, expectedValues: []int{17}, expectedGroups: 1},
+		"keep highest":        {notation: "4d6kh3+2", draws: sequence{5, 0, 3, 2}, expectedTotal: 6 + 4 + 3 + 2, expectedValues: []int{6, 1, 4, 3}, expectedGroups: 2},
+		"keep lowest":         {notation: "2d20kl1", draws: sequence{14, 3}, expectedTotal: 4, expectedValues: []int{15, 4}, expectedGroups: 1},
+		"drop lowest":         {notation: "4d6d1", draws: sequence{0, 0, 5, 2}, expectedTotal: 1 + 6 + 3, expectedValues: []int{1, 1, 6, 3}, expectedGroups: 1},
+		"drop highest":        {notation: "3d6dh", draws: sequence{5, 1, 2}, expectedTotal: 2 + 3, expectedValues: []int{6, 2, 3}, expectedGroups: 1},
+		"keep more than all":  {notation: "2d6k5", draws: sequence{1, 2}, expectedTotal: 5, expectedValues: []int{2, 3}, expectedGroups: 1},
+		"exploding":           {notation: "2d6!", draws: sequence{5, 5, 1, 3}, expectedTotal: 6 + 6 + 2 + 4, expectedValues: []int{6, 6, 2, 4}, expectedGroups: 1},
+		"exploding above":     {notation: "d10!>9", draws: sequence{8, 9, 0}, expectedTotal: 9 + 10 + 1, expectedValues: []int{9, 10, 1}, expectedGroups: 1},
+		"reroll":              {notation: "2d6r", draws: sequence{0, 0, 4, 2}, expectedTotal: 5 + 3, expectedValues: []int{1, 1, 5, 3}, expectedGroups: 1},
+		"reroll once":         {notation: "d6ro<2", draws: sequence{0, 1}, expectedTotal: 2, expectedValues: []int{1, 2}, expectedGroups: 1},
+		"successes":           {notation: "5d10>8", draws: sequence{7, 8, 9, 0, 4}, expectedTotal: 3, expectedValues: []int{8, 9, 10, 1, 5}, expectedGroups: 1},
+		"successes, failures": {notation: "4d10>8f1", draws: sequence{7, 8, 9, 0}, expectedTotal: 2, expectedValues: []int{8, 9, 10, 1}, expectedGroups: 1},
+		"fate":                {notation: "4dF", draws: sequence{0, 1, 2, 2}, expectedTotal: 1, expectedValues: []int{-1, 0, 1, 1}, expectedGroups: 1},
+		"percentile":          {notation: "D%", draws: sequence{99}, expectedTotal: 100, expectedValues: []int{100}, expectedGroups: 1},
+		"groups":              {notation: " -1d4 + 2d8 - 3 ", draws: sequence{3, 7, 0}, expectedTotal: -4 + 8 + 1 - 3, expectedValues: []int{4}, expectedGroups: 3},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			e, err := Parse(tc.notation)
+			assert.NoError(t, err)
+
+			result := e.Roll(&tc.draws)
+			assert.Equal(t, tc.expectedTotal, result.Total)
+			assert.Equal(t, tc.expectedValues, values(result.Groups[0].Dice))
+			assert.Len(t, result.Groups, tc.expectedGroups)
+			assert.Empty(t, tc.draws)
+		})
+	}
+}
+
+func Test_RollFlags(t *testing.T) {
+	e, err := Parse("4d6r1!kh2>5")
+	assert.NoError(t, err)
+
+	draws := sequence{0, 5, 2, 3, 1, 4}
+	result := e.Roll(&draws)
+	assert.Equal(t, []Die{
+		{Value: 1, Rerolled: true},
+		{Value: 6, Exploded: true, Success: true},
+		{Value: 3, Dropped: true},
+		{Value: 4, Dropped: true},
+		{Value: 2, Dropped: true},
+		{Value: 5, Success: true},
+	}, result.Groups[0].Dice)
+	assert.Equal(t, 2, result.Total)
+}
+
+func Test_RollLimit(t *testing.T) {
+	e, err := Parse("d2!")
+	assert.NoError(t, err)
+
+	// NOTE: a source always drawing the highest face would explode forever
+	result := e.Roll(highest{})
+	assert.Len(t, result.Groups[0].Dice, MaxRolls)
+	assert.Equal(t, 2*MaxRolls, result.Total)
+}
+
+type highest struct{}
+
+func (highest) Intn(n int) int {
+	return n - 1
+}
+
+func Test_ParseGroups(t *testing.T) {
+	e, err := Parse("3d8ro<2!>7kl2+4DF>=1-12")
+	assert.NoError(t, err)
+	assert.Equal(t, []Group{
+		{
+			Notation:   "3d8ro<2!>7kl2",
+			Sign:       1,
+			Count:      3,
+			Sides:      8,
+			Selection:  &Selection{Count: 2},
+			Explode:    &Compare{Operator: '>', Value: 7},
+			Reroll:     &Compare{Operator: '<', Value: 2},
+			RerollOnce: true,
+		},
+		{
+			Notation: "4df>=1",
+			Sign:     1,
+			Count:    4,
+			Fate:     true,
+			Success:  &Compare{Operator: '>', Value: 1},
+		},
+		{Notation: "-12", Sign: -1, Constant: 12},
+	}, e.Groups)
+}
+
+func Test_ParseErrors(t *testing.T) {
+	tt := map[string]struct {
+		notation         string
+		expectedMessage  string
+		expectedPosition int
+	}{
+		"empty":              {notation: "", expectedMessage: "expecting dice or a number", expectedPosition: 1},
+		"no sides":           {notation: "2d", expectedMessage: "expecting sides between 2 and 1000, % or F", expectedPosition: 3},
+		"one side":           {notation: "d1", expectedMessage: "expecting sides between 2 and 1000, % or F", expectedPosition: 3},
+		"zero dice":          {notation: "0d6", expectedMessage: "expecting at least one die", expectedPosition: 1},
+		"too many dice":      {notation: "600d6+600d6", expectedMessage: "more than 1000 dice", expectedPosition: 11},
+		"too many groups":    {notation: strings.Repeat("1+", MaxGroups) + "1", expectedMessage: "more than 20 groups", expectedPosition: 41},
+		"too long":           {notation: strings.Repeat("1", MaxNotationLength+1), expectedMessage: "notation longer than 200 characters", expectedPosition: 201},
+		"large number":       {notation: "12345678", expectedMessage: "number too large", expectedPosition: 1},
+		"unexpected":         {notation: "2d6*2", expectedMessage: "unexpected '*'", expectedPosition: 4},
+		"kept twice":         {notation: "4d6kh3dl1", expectedMessage: "dice kept or dropped twice", expectedPosition: 7},
+		"exploding forever":  {notation: "d6!>1", expectedMessage: "dice exploding on every face", expectedPosition: 3},
+		"space in a group":   {notation: "dF r<1", expectedMessage: "unexpected 'r'", expectedPosition: 4},
+		"rerolling forever":  {notation: "dFr<1", expectedMessage: "dice rerolled on every face", expectedPosition: 3},
+		"no compare point":   {notation: "d6f", expectedMessage: "expecting a compare point like >5", expectedPosition: 4},
+		"failures only":      {notation: "d6f<2", expectedMessage: "failures counted without successes", expectedPosition: 6},
+		"trailing operator":  {notation: "d6+", expectedMessage: "expecting dice or a number", expectedPosition: 4},
+		"operator no number": {notation: "d6>", expectedMessage: "expecting a compare point like >5", expectedPosition: 4},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(tc.notation)
+
+			var notationErr *NotationError
+			assert.ErrorAs(t, err, &notationErr)
+			assert.Equal(t, &NotationError{Position: tc.expectedPosition, Message: tc.expectedMessage}, notationErr)
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package dice
+
+import (
+	"errors"
+	"math/big"
+)
+
+const (
+	// MaxExplosions bounds the explosions followed by the distribution of a die, the probability of the longer ones being left out
+	MaxExplosions = 20
+
+	// MaxDistributionDice bounds the dice of a notation whose distribution is computed
+	MaxDistributionDice = 100
+
+	// maxWork bounds the products of probabilities computed for a distribution
+	maxWork = 2000000
+)
+
+var (
+	ErrTooManyDice        = errors.New("too many dice for a distribution")
+	ErrTooComplex         = errors.New("distribution too complex to compute")
+	ErrExplodingSelection = errors.New("distribution of exploding dice kept or dropped not supported")
+)
+
+// distribution gives the probability of each score from offset as weights over a common denominator, the
+// probabilities being reduced only once computed
+type distribution struct {
+	offset int
+	w      []*big.Int
+	denom  *big.Int
+}
+
+func point(score int) distribution {
+	return distribution{offset: score, w: []*big.Int{big.NewInt(1)}, denom: big.NewInt(1)}
+}
+
+// add adds the weights of the distribution times weight, growing to cover its scores
+func (d *distribution) add(e distribution, weight *big.Int) {
+	if len(e.w) == 0 || weight.Sign() == 0 {
+		return
+	}
+	if len(d.w) == 0 {
+		d.offset = e.offset
+	}
+	low, high := min(d.offset, e.offset), max(d.offset+len(d.w), e.offset+len(e.w))
+	if low < d.offset || high > d.offset+len(d.w) {
+		w := make([]*big.Int, high-low)
+		copy(w[d.offset-low:], d.w)
+		d.offset, d.w = low, w
+	}
+
+	for i, q := range e.w {
+		if q == nil || q.Sign() == 0 {
+			continue
+		}
+		j := e.offset + i - d.offset
+		if d.w[j] == nil {
+			d.w[j] = new(big.Int)
+		}
+		d.w[j].Add(d.w[j], new(big.Int).Mul(q, weight))
+	}
+}
+
+// shift moves the scores by delta
+func (d distribution) shift(delta int) distribution {
+	return distribution{offset: d.offset + delta, w: d.w, denom: d.denom}
+}
+
+// negate gives the distribution of the opposite scores
+func (d distribution) negate() distribution {
+	w := make([]*big.Int, len(d.w))
+	for i, q := range d.w {
+		w[len(w)-1-i] = q
+	}
+	return distribution{offset: -(d.offset + len(d.w) - 1), w: w, denom: d.denom}
+}
+
+// convolver computes the distributions of sums, within the work budget
+type convolver struct {
+	work int
+}
+
+func (c *convolver) spend(work int) error {
+	if c.work += work; c.work > maxWork {
+		return ErrTooComplex
+	}
+	return nil
+}
+
+// convolve returns the distribution of the sum of independent scores of d and e
+func (c *convolver) convolve(d distribution, e distribution) (distribution, error) {
+	if err := c.spend(len(d.w) * len(e.w)); err != nil {
+		return distribution{}, err
+	}
+
+	sum := distribution{denom: new(big.Int).Mul(d.denom, e.denom)}
+	for i, q := range d.w {
+		if q != nil {
+			sum.add(e.shift(d.offset+i), q)
+		}
+	}
+	return sum, nil
+}
+
+// Distribution is the exact probability of each total of an expression, except for the explosions beyond MaxExplosions
+// whose probability is Residual
+type Distribution struct {
+	Min           int
+	Probabilities []*big.Rat
+	Residual      *big.Rat
+}
+
+// Distribution computes the probabilities of the totals of the expression: the dice of a group are independent,
+// so that the distributions of their scores, with their rerolls and explosions, are convolved, except when dice are kept
+// or dropped, whose scores depend on the order of the faces
+func (e Expression) Distribution() (Distribution, error) {
+	count := 0
+	for _, g := range e.Groups {
+		if g.Dice() {
+			count += g.Count
+		}
+	}
+	if count > MaxDistributionDice {
+		return Distribution{}, ErrTooManyDice
+	}
+
+	c := &convolver{}
+
+	total := point(0)
+	for _, g := range e.Groups {
+		group, err := c.group(g)
+		if err != nil {
+			return Distribution{}, err
+		}
+		if g.Sign < 0 {
+			group = group.negate()
+		}
+		if total, err = c.convolve(total, group); err != nil {
+			return Distribution{}, err
+		}
+	}
+
+	// NOTE: the scores may have no probability at both ends, like the failures of dice without any
+	first, last := 0, len(total.w)-1
+	for first < last && (total.w[first] == nil || total.w[first].Sign() == 0) {
+		first++
+	}
+	for last > first && (total.w[last] == nil || total.w[last].Sign() == 0) {
+		last--
+	}
+
+	d := Distribution{Min: total.offset + first, Probabilities: make([]*big.Rat, last-first+1)}
+	mass := new(big.Int)
+	for i, q := range total.w[first : last+1] {
+		d.Probabilities[i] = new(big.Rat)
+		if q != nil {
+			d.Probabilities[i].SetFrac(q, total.denom)
+			mass.Add(mass, q)
+		}
+	}
+	d.Residual = new(big.Rat).Sub(big.NewRat(1, 1), new(big.Rat).SetFrac(mass, total.denom))
+	return d, nil
+}
+
+// score is what a counted die adds to its group: its value, or 1 for a success and -1 for a failure
+func (g Group) score(value int) int {
+	switch {
+	case g.Success == nil:
+		return value
+	case g.Success.Matches(value):
+		return 1
+	case g.Failure != nil && g.Failure.Matches(value):
+		return -1
+	default:
+		return 0
+	}
+}
+
+// first returns the weights of the faces of a die after its rerolls, along with their denominator
+func (g Group) first() ([]*big.Int, *big.Int) {
+	low, high := g.faces()
+	n := int64(high - low + 1)
+
+	rerolled := int64(0)
+	for v := low; v <= high; v++ {
+		if g.Reroll != nil && g.Reroll.Matches(v) {
+			rerolled++
+		}
+	}
+
+	w := make([]*big.Int, n)
+	for v := low; v <= high; v++ {
+		matches := g.Reroll != nil && g.Reroll.Matches(v)
+		switch {
+		case g.Reroll == nil || !g.RerollOnce && !matches:
+			w[v-low] = big.NewInt(1)
+		case g.RerollOnce && matches:
+			w[v-low] = big.NewInt(rerolled)
+		case g.RerollOnce:
+			w[v-low] = big.NewInt(n + rerolled)
+		default:
+			w[v-low] = new(big.Int)
+		}
+	}
+
+	switch {
+	case g.Reroll == nil:
+		return w, big.NewInt(n)
+	case g.RerollOnce:
+		return w, big.NewInt(n * n)
+	default:
+		return w, big.NewInt(n - rerolled)
+	}
+}
+
+func (c *convolver) group(g Group) (distribution, error) {
+	if !g.Dice() {
+		return point(g.Constant), nil
+	}
+	if g.Selection != nil {
+		if g.Explode != nil {
+			return distribution{}, ErrExplodingSelection
+		}
+		return c.selection(g)
+	}
+
+	die, err := c.die(g)
+	if err != nil {
+		return distribution{}, err
+	}
+	total := point(0)
+	for i := 0; i < g.Count; i++ {
+		if total, err = c.convolve(total, die); err != nil {
+			return distribution{}, err
+		}
+	}
+	return total, nil
+}
+
+// die returns the distribution of the scores of a die with its explosions, which are not rerolled
+func (c *convolver) die(g Group) (distribution, error) {
+	low, high := g.faces()
+	uniform := make([]*big.Int, high-low+1)
+	for i := range uniform {
+		uniform[i] = big.NewInt(1)
+	}
+
+	// NOTE: the explosions are followed from the last one, whose own explosions are left out by the empty chain
+	explosions := MaxExplosions
+	if g.Explode == nil {
+		explosions = 0
+	}
+	chain := distribution{denom: big.NewInt(1)}
+	for depth := explosions; depth >= 0; depth-- {
+		faces, denom := uniform, big.NewInt(int64(len(uniform)))
+		if depth == 0 {
+			faces, denom = g.first()
+		}
+
+		next := distribution{denom: new(big.Int).Mul(denom, chain.denom)}
+		for v := low; v <= high; v++ {
+			if g.Explode != nil && g.Explode.Matches(v) {
+				if err := c.spend(len(chain.w)); err != nil {
+					return distribution{}, err
+				}
+				next.add(chain.shift(g.score(v)), faces[v-low])
+			} else {
+				next.add(point(g.score(v)), new(big.Int).Mul(faces[v-low], chain.denom))
+			}
+		}
+		chain = next
+	}
+	return chain, nil
+}
+
+// selection returns the distribution of the scores of the dice kept, going through the faces from the highest:
+// the dice in positions from keep to keep + kept in that order are the kept ones, and the state is the distribution
+// of the scores of the kept dice for each number of dice with the faces gone through, over the denominator of the
+// faces to the power of that number
+func (c *convolver) selection(g Group) (distribution, error) {
+	n := g.Count
+	dropped := min(g.Selection.Count, n)
+	kept := dropped
+	if g.Selection.Drop {
+		kept = n - dropped
+	}
+	keep := 0
+	if g.Selection.Drop == g.Selection.Highest {
+		keep = n - kept
+	}
+
+	low, high := g.faces()
+
+	// NOTE: the binomials and the powers of the faces are charged before they are computed
+	if err := c.spend(n*(n+1)/2 + (high-low+1)*n); err != nil {
+		return distribution{}, err
+	}
+	first, denom := g.first()
+
+	// NOTE: binomial[m][k] is the number of ways to choose the k dice with a face among the m left, by Pascal's rule
+	binomial := make([][]*big.Int, n+1)
+	for m := range binomial {
+		binomial[m] = make([]*big.Int, m+1)
+		binomial[m][0], binomial[m][m] = big.NewInt(1), big.NewInt(1)
+		for k := 1; k < m; k++ {
+			binomial[m][k] = new(big.Int).Add(binomial[m-1][k-1], binomial[m-1][k])
+		}
+	}
+
+	states := make([]distribution, n+1)
+	states[0] = point(0)
+	for v := high; v >= low; v-- {
+		powers := []*big.Int{big.NewInt(1)}
+		for k := 1; k <= n; k++ {
+			powers = append(powers, new(big.Int).Mul(powers[k-1], first[v-low]))
+		}
+
+		next := make([]distribution, n+1)
+		for i, state := range states {
+			if len(state.w) == 0 {
+				continue
+			}
+			// NOTE: the dice left all have the lowest face
+			from := 0
+			if v == low {
+				from = n - i
+			}
+			for k := from; i+k <= n; k++ {
+				if powers[k].Sign() == 0 && k > 0 {
+					break
+				}
+				if err := c.spend(len(state.w)); err != nil {
+					return distribution{}, err
+				}
+				counted := max(0, min(i+k, keep+kept)-max(i, keep))
+				next[i+k].add(state.shift(counted*g.score(v)), new(big.Int).Mul(powers[k], binomial[n-i][k]))
+			}
+		}
+		states = next
+	}
+
+	total := states[n]
+	total.denom = new(big.Int).Exp(denom, big.NewInt(int64(n)), nil)
+	return total, nil
+}
+
+// Mean returns the expected total, the residual being left out
+func (d Distribution) Mean() *big.Rat {
+	mean := new(big.Rat)
+	for i, q := range d.Probabilities {
+		mean.Add(mean, new(big.Rat).Mul(q, big.NewRat(int64(d.Min+i), 1)))
+	}
+	return mean
+}
+
+// Variance returns the variance of the total, the residual being left out
+func (d Distribution) Variance() *big.Rat {
+	mean := d.Mean()
+	variance := new(big.Rat)
+	for i, q := range d.Probabilities {
+		deviation := new(big.Rat).Sub(big.NewRat(int64(d.Min+i), 1), mean)
+		variance.Add(variance, deviation.Mul(deviation.Mul(deviation, deviation), q))
+	}
+	return variance
+}
+
+// AtLeast returns the probability of a total meeting or exceeding the target
+func (d Distribution) AtLeast(target int) *big.Rat {
+	p := new(big.Rat)
+	for i := max(target-d.Min, 0); i < len(d.Probabilities); i++ {
+		p.Add(p, d.Probabilities[i])
+	}
+	return p
+}
@@ -0,0 +1,81 @@
+package dice
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Distribution(t *testing.T) {
+	tt := map[string]struct {
+		notation         string
+		expectedMin      int
+		expectedMax      int
+		total            int
+		expectedP        string
+		expectedMean     string
+		expectedVariance string
+	}{
+		"sum":                  {notation: "3d6+1", expectedMin: 4, expectedMax: 19, total: 11, expectedP: "1/8", expectedMean: "23/2", expectedVariance: "35/4"},
+		"keep highest":         {notation: "4d6kh3", expectedMin: 3, expectedMax: 18, total: 18, expectedP: "7/432", expectedMean: "15869/1296", expectedVariance: "13612487/1679616"},
+		"keep lowest":          {notation: "2d20kl1", expectedMin: 1, expectedMax: 20, total: 1, expectedP: "39/400", expectedMean: "287/40", expectedVariance: "35511/1600"},
+		"reroll then keep":     {notation: "4d6r1kh3", expectedMin: 6, expectedMax: 18, total: 18, expectedP: "17/625", expectedMean: "8396/625", expectedVariance: "2190934/390625"},
+		"reroll once":          {notation: "d6ro", expectedMin: 1, expectedMax: 6, total: 1, expectedP: "1/36", expectedMean: "47/12", expectedVariance: "35/16"},
+		"successes, failures":  {notation: "5d10>8f1", expectedMin: -5, expectedMax: 5, total: 0, expectedP: "5589/25000", expectedMean: "1", expectedVariance: "9/5"},
+		"successes among kept": {notation: "3d6dl1>5", expectedMin: 0, expectedMax: 2, total: 2, expectedP: "7/27", expectedMean: "26/27", expectedVariance: "404/729"},
+		"fate":                 {notation: "4dF", expectedMin: -4, expectedMax: 4, total: 0, expectedP: "19/81", expectedMean: "0", expectedVariance: "8/3"},
+		"subtracted":           {notation: "d4-d4", expectedMin: -3, expectedMax: 3, total: -3, expectedP: "1/16", expectedMean: "0", expectedVariance: "5/2"},
+		"drop highest":         {notation: "3d4dh2", expectedMin: 1, expectedMax: 4, total: 1, expectedP: "37/64", expectedMean: "25/16", expectedVariance: "143/256"},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			e, err := Parse(tc.notation)
+			assert.NoError(t, err)
+
+			d, err := e.Distribution()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedMin, d.Min)
+			assert.Equal(t, tc.expectedMax, d.Min+len(d.Probabilities)-1)
+			assert.Equal(t, tc.expectedP, d.Probabilities[tc.total-d.Min].RatString())
+			assert.Equal(t, tc.expectedMean, d.Mean().RatString())
+			assert.Equal(t, tc.expectedVariance, d.Variance().RatString())
+			assert.Equal(t, "0", d.Residual.RatString())
+			assert.Equal(t, "1", d.AtLeast(d.Min-1).RatString())
+		})
+	}
+}
+
+func Test_DistributionExploding(t *testing.T) {
+	e, err := Parse("d6!")
+	assert.NoError(t, err)
+
+	d, err := e.Distribution()
+	assert.NoError(t, err)
+
+	// NOTE: the explosions beyond MaxExplosions are left out, 6 being never a total
+	assert.Equal(t, new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Exp(big.NewInt(6), big.NewInt(MaxExplosions+1), nil)), d.Residual)
+	assert.Equal(t, "0", d.Probabilities[6-d.Min].RatString())
+	assert.Equal(t, "1/36", d.Probabilities[7-d.Min].RatString())
+	assert.Equal(t, "4.2000", d.Mean().FloatString(4))
+	assert.Equal(t, "1/6", new(big.Rat).Add(d.AtLeast(7), d.Residual).RatString())
+}
+
+func Test_DistributionErrors(t *testing.T) {
+	for notation, expected := range map[string]error{
+		"4d6!kh3":     ErrExplodingSelection,
+		"1000d1000":   ErrTooManyDice,
+		"51d6+50d6":   ErrTooManyDice,
+		"100d1000":    ErrTooComplex,
+		"100d1000kh1": ErrTooComplex,
+		"100d20kh50":  ErrTooComplex,
+		"20d6!>2+d20": ErrTooComplex,
+	} {
+		e, err := Parse(notation)
+		assert.NoError(t, err)
+
+		_, err = e.Distribution()
+		assert.ErrorIs(t, err, expected, notation)
+	}
+}
@@ -0,0 +1,171 @@
+package math
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// AssetsDir holds the digits files, the value of a constant like "3.1415…" without newline, named after the constant
+const AssetsDir = "./assets"
+
+// ManifestFile lists the SHA-256 checksums of the digits files, in the format of sha256sum
+const ManifestFile = "SHA256SUMS"
+
+// AssetSize is the size of the digits files, "3." followed by a million digits for pi
+const AssetSize = 1000002
+
+// ErrNoManifest is returned when the assets have no checksums to be verified against
+var ErrNoManifest = errors.New("no assets manifest")
+
+// CheckAssets makes sure the digits files ReadPage reads are present and complete
+func CheckAssets(files ...string) error {
+	for _, file := range files {
+		info, err := os.Stat(filepath.Join(AssetsDir, file))
+		if err != nil {
+			return err
+		}
+		if info.Size() != AssetSize {
+			return fmt.Errorf("asset %s is %d bytes instead of %d", file, info.Size(), AssetSize)
+		}
+	}
+	return nil
+}
+
+// Generate computes the constant with digits after the decimal point whatever MaxDigits, to write the digits files
+func Generate(id string, digits int) (string, error) {
+	c, err := lookup(id)
+	if err != nil {
+		return "", err
+	}
+	if digits < 0 {
+		return "", ErrDigitsOutOfRange
+	}
+
+	return formatDigits(c.compute(digits), digits, 10), nil
+}
+
+func checksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// readManifest returns the checksums of the manifest of dir by file
+func readManifest(dir string) (map[string]string, error) {
+	f, err := os.Open(filepath.Join(dir, ManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoManifest
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	checksums := make(map[string]string)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		sum, file, found := strings.Cut(scanner.Text(), "  ")
+		if !found {
+			return nil, fmt.Errorf("invalid manifest line %q", scanner.Text())
+		}
+		checksums[file] = sum
+	}
+
+	return checksums, scanner.Err()
+}
+
+// UpdateManifest writes the checksums of the given files of dir in its manifest, keeping the ones of the other files
+func UpdateManifest(dir string, files ...string) error {
+	checksums, err := readManifest(dir)
+	if errors.Is(err, ErrNoManifest) {
+		checksums = make(map[string]string)
+	} else if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if checksums[file], err = checksum(filepath.Join(dir, file)); err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, 0, len(checksums))
+	for name := range checksums {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var manifest strings.Builder
+	for _, name := range names {
+		manifest.WriteString(checksums[name] + "  " + name + "\n")
+	}
+
+	return os.WriteFile(filepath.Join(dir, ManifestFile), []byte(manifest.String()), 0o644)
+}
+
+// VerifyAssets checks every digits file of dir against the checksums of its manifest
+func VerifyAssets(dir string) error {
+	checksums, err := readManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	for file, expected := range checksums {
+		sum, err := checksum(filepath.Join(dir, file))
+		if err != nil {
+			return err
+		}
+		if sum != expected {
+			return fmt.Errorf("asset %s does not match its checksum", file)
+		}
+	}
+
+	return nil
+}
+
+// ErrPageOutOfRange is returned for the pages after the end of the digits file
+var ErrPageOutOfRange = errors.New("page out of range")
+
+// ReadPage reads the page of pageSize bytes of the digits file of the constant in dir, the last page being shorter
+func ReadPage(dir string, id string, page int, pageSize int) ([]byte, error) {
+	if _, err := lookup(id); err != nil {
+		return nil, err
+	}
+	if page < 0 || pageSize <= 0 {
+		return nil, ErrPageOutOfRange
+	}
+
+	f, err := os.Open(filepath.Join(dir, id))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buffer := make([]byte, pageSize)
+	n, err := f.ReadAt(buffer, int64(page)*int64(pageSize))
+	if n == 0 && errors.Is(err, io.EOF) {
+		return nil, ErrPageOutOfRange
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return buffer[:n], nil
+}
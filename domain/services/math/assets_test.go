@@ -0,0 +1,77 @@
+package math
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Manifest(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.ErrorIs(t, VerifyAssets(dir), ErrNoManifest)
+
+	for _, id := range []string{"pi", "e"} {
+		value, err := Generate(id, 100)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, id), []byte(value), 0o644))
+	}
+
+	assert.NoError(t, UpdateManifest(dir, "pi"))
+	assert.NoError(t, UpdateManifest(dir, "e"))
+	assert.NoError(t, VerifyAssets(dir))
+
+	manifest, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	assert.NoError(t, err)
+	assert.Equal(t, "9962f6e0c9c892feff6674147a3785101d62242a98a24af2942603faa3d33a05  e\n"+
+		"aa6eee625a838a2af84f7d591e8c677bdd9c1b07c44380e2fee8fc738f9234f0  pi\n", string(manifest))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "pi"), []byte("3.14"), 0o644))
+	assert.EqualError(t, VerifyAssets(dir), "asset pi does not match its checksum")
+}
+
+func Test_Generate(t *testing.T) {
+	value, err := Generate("pi", MaxDigits+10)
+	assert.NoError(t, err)
+	assert.Len(t, value, MaxDigits+12)
+
+	_, err = Generate("gamma", 10)
+	assert.ErrorIs(t, err, ErrUnknownConstant)
+}
+
+func Test_VerifyAssets(t *testing.T) {
+	assert.NoError(t, VerifyAssets("../../../assets"))
+}
+
+func Test_ReadPage(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "pi"), []byte("3.14159265"), 0o644))
+
+	tt := map[string]struct {
+		id            string
+		page          int
+		pageSize      int
+		expectedValue string
+		expectedError error
+	}{
+		"first page":    {id: "pi", page: 0, pageSize: 4, expectedValue: "3.14"},
+		"second page":   {id: "pi", page: 1, pageSize: 4, expectedValue: "1592"},
+		"last page":     {id: "pi", page: 2, pageSize: 4, expectedValue: "65"},
+		"after last":    {id: "pi", page: 3, pageSize: 4, expectedError: ErrPageOutOfRange},
+		"negative page": {id: "pi", page: -1, pageSize: 4, expectedError: ErrPageOutOfRange},
+		"empty page":    {id: "pi", page: 0, pageSize: 0, expectedError: ErrPageOutOfRange},
+		"unknown":       {id: "../pi", page: 0, pageSize: 4, expectedError: ErrUnknownConstant},
+		"not written":   {id: "e", page: 0, pageSize: 4, expectedError: os.ErrNotExist},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			value, err := ReadPage(dir, tc.id, tc.page, tc.pageSize)
+
+			assert.ErrorIs(t, err, tc.expectedError)
+			assert.Equal(t, tc.expectedValue, string(value))
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package math
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// guardDigits are computed beyond the requested ones so that the truncation errors do not reach them
+const guardDigits = 10
+
+// MaxDigits bounds the decimals computed on demand
+const MaxDigits = 100000
+
+var (
+	ErrUnknownConstant  = errors.New("unknown constant")
+	ErrDigitsOutOfRange = errors.New("digits out of range")
+	ErrUnsupportedBase  = errors.New("unsupported base")
+)
+
+// Bases the digits can be written in
+var Bases = []int{2, 10, 16}
+
+// constant computes floor(C * 10^digits)
+type constant struct {
+	name    string
+	compute func(digits int) *big.Int
+	// Decimals computed at most, MaxDigits when zero.
+	maxDigits int
+	// Most precise value computed so far, for the constants worth remembering.
+	memo *memo
+}
+
+var constants = map[string]constant{
+	"pi":  {name: "Pi", compute: pi, memo: &memo{}},
+	"tau": {name: "Tau", compute: tau, memo: &memo{}},
+	"e":   {name: "e", compute: e, memo: &memo{}},
+	"phi": {name: "Phi", compute: phi, memo: &memo{}},
+	"ln2": {name: "Ln2", compute: ln2, memo: &memo{}},
+	// NOTE: the series of Catalan gains less than a digit per term
+	"catalan": {name: "Catalan", compute: catalan, maxDigits: 10000, memo: &memo{}},
+	"zeta3":   {name: "Zeta3", compute: zeta3, memo: &memo{}},
+}
+
+// lookup finds the constant, sqrt followed by an integer, like sqrt2, being the square root of that integer
+func lookup(id string) (constant, error) {
+	if c, ok := constants[id]; ok {
+		return c, nil
+	}
+
+	if n, found := strings.CutPrefix(id, "sqrt"); found {
+		radicand, err := strconv.ParseInt(n, 10, 64)
+		if err != nil || radicand < 0 || strconv.FormatInt(radicand, 10) != n {
+			return constant{}, ErrUnknownConstant
+		}
+		return constant{name: "Sqrt" + n, compute: func(digits int) *big.Int {
+			return sqrt(big.NewInt(radicand), digits)
+		}}, nil
+	}
+
+	return constant{}, ErrUnknownConstant
+}
+
+// MaxDigitsOf returns how many digits of the constant can be asked in base
+func MaxDigitsOf(id string, base int) int {
+	c, err := lookup(id)
+	if err != nil {
+		return 0
+	}
+	return int(float64(c.max()) / math.Log10(float64(base)))
+}
+
+func (c constant) max() int {
+	if c.maxDigits == 0 {
+		return MaxDigits
+	}
+	return c.maxDigits
+}
+
+// scaled returns floor(C * base^digits), the value written in base being the digits
+func (c constant) scaled(digits int, base int) *big.Int {
+	if base == 10 {
+		return c.decimals(digits)
+	}
+
+	decimals := int(math.Ceil(float64(digits)*math.Log10(float64(base)))) + guardDigits
+	value := new(big.Int).Exp(big.NewInt(int64(base)), big.NewInt(int64(digits)), nil)
+	value.Mul(value, c.decimals(decimals))
+	return value.Quo(value, pow10(decimals))
+}
+
+func (c constant) decimals(digits int) *big.Int {
+	if c.memo == nil {
+		return c.compute(digits)
+	}
+	return c.memo.get(digits, c.max()+guardDigits, c.compute)
+}
+
+// Constant returns the name of the constant and its value with the given number of digits after the decimal point
+func Constant(id string, digits int) (string, string, error) {
+	return Digits(id, digits, 10)
+}
+
+// Digits returns the name of the constant and its value written in base with the given number of digits after the point
+func Digits(id string, digits int, base int) (string, string, error) {
+	c, err := lookup(id)
+	if err != nil {
+		return "", "", err
+	}
+	if !slices.Contains(Bases, base) {
+		return "", "", ErrUnsupportedBase
+	}
+	if digits < 0 || digits > MaxDigitsOf(id, base) {
+		return "", "", ErrDigitsOutOfRange
+	}
+
+	return c.name, formatDigits(c.scaled(digits, base), digits, base), nil
+}
+
+// DigitsRange returns the name of the constant and length digits after the point written in base, starting at offset
+func DigitsRange(id string, offset int, length int, base int) (string, string, error) {
+	if offset < 0 || length < 0 {
+		return "", "", ErrDigitsOutOfRange
+	}
+
+	name, value, err := Digits(id, offset+length, base)
+	if err != nil {
+		return "", "", err
+	}
+
+	_, fraction, _ := strings.Cut(value, ".")
+	return name, fraction[offset:], nil
+}
+
+// formatDigits writes floor(C * base^digits) as C with digits after the point
+func formatDigits(scaled *big.Int, digits int, base int) string {
+	s := scaled.Text(base)
+	if len(s) <= digits {
+		s = strings.Repeat("0", digits-len(s)+1) + s
+	}
+	if digits == 0 {
+		return s
+	}
+	return s[:len(s)-digits] + "." + s[len(s)-digits:]
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// evaluate computes floor(S * numerator / denominator * 10^digits), S being the sum of terms terms of s
+func evaluate(s series, terms int, numerator int64, denominator int64, digits int) *big.Int {
+	split := s.split(0, terms)
+
+	value := new(big.Int).Mul(split.T, pow10(digits+guardDigits))
+	value.Mul(value, big.NewInt(numerator))
+	value.Quo(value, new(big.Int).Mul(new(big.Int).Mul(split.B, split.Q), big.NewInt(denominator)))
+
+	return value.Quo(value, pow10(guardDigits))
+}
+
+// termsFor returns the number of terms of a series gaining digitsPerTerm digits at each term needed for digits
+func termsFor(digits int, digitsPerTerm float64) int {
+	return int(float64(digits+guardDigits)/digitsPerTerm) + 2
+}
+
+// e = Σ 1/n!
+func e(digits int) *big.Int {
+	// NOTE: the terms decrease faster and faster, so they are counted until n! exceeds the precision
+	terms, logFactorial := 1, 0.0
+	for logFactorial <= float64(digits+guardDigits) {
+		terms++
+		logFactorial += math.Log10(float64(terms))
+	}
+
+	s := series{
+		p: func(n int64) *big.Int { return big.NewInt(1) },
+		q: func(n int64) *big.Int { return big.NewInt(max(n, 1)) },
+	}
+	return evaluate(s, terms+1, 1, 1, digits)
+}
+
+// ln(2) = 2 atanh(1/3) = 2/3 Σ 1/((2n+1) 9^n)
+func ln2(digits int) *big.Int {
+	s := series{
+		b: func(n int64) *big.Int { return big.NewInt(2*n + 1) },
+		p: func(n int64) *big.Int { return big.NewInt(1) },
+		q: func(n int64) *big.Int {
+			if n == 0 {
+				return big.NewInt(1)
+			}
+			return big.NewInt(9)
+		},
+	}
+	return evaluate(s, termsFor(digits, math.Log10(9)), 2, 3, digits)
+}
+
+// ζ(3) = 1/64 Σ (-1)^n (205n² + 250n + 77) (n!)^10 / ((2n+1)!)^5, from Amdeberhan and Zeilberger
+func zeta3(digits int) *big.Int {
+	s := series{
+		a: func(n int64) *big.Int { return big.NewInt(205*n*n + 250*n + 77) },
+		p: func(n int64) *big.Int {
+			if n == 0 {
+				return big.NewInt(1)
+			}
+			return new(big.Int).Neg(new(big.Int).Exp(big.NewInt(n), big.NewInt(5), nil))
+		},
+		q: func(n int64) *big.Int {
+			if n == 0 {
+				return big.NewInt(1)
+			}
+			q := new(big.Int).Exp(big.NewInt(2*n+1), big.NewInt(5), nil)
+			return q.Mul(q, big.NewInt(32))
+		},
+	}
+	return evaluate(s, termsFor(digits, math.Log10(1024)), 1, 64, digits)
+}
+
+// G = 1/64 Σ (-1)^(k+1) 256^k (40k² - 24k + 3) ((2k)!)^3 (k!)^2 / (k^3 (2k-1) ((4k)!)^2) for k ≥ 1, from Lupas
+func catalan(digits int) *big.Int {
+	s := series{
+		a: func(n int64) *big.Int {
+			k := n + 1
+			return big.NewInt(40*k*k - 24*k + 3)
+		},
+		b: func(n int64) *big.Int {
+			k := n + 1
+			b := big.NewInt(2*k - 1)
+			return b.Mul(b, new(big.Int).Exp(big.NewInt(k), big.NewInt(3), nil))
+		},
+		p: func(n int64) *big.Int {
+			k := n + 1
+			p := big.NewInt(-32 * (2*k - 1))
+			return p.Mul(p, new(big.Int).Exp(big.NewInt(k), big.NewInt(3), nil))
+		},
+		q: func(n int64) *big.Int {
+			k := n + 1
+			q := big.NewInt((4*k - 1) * (4*k - 3))
+			return q.Mul(q, q)
+		},
+	}
+	// NOTE: p(0) carries the negative sign of the ratios, the first term being positive
+	return evaluate(s, termsFor(digits, math.Log10(4)), -1, 64, digits)
+}
+
+// sqrt truncates the square root of n with digits after the decimal point
+func sqrt(n *big.Int, digits int) *big.Int {
+	scaled := new(big.Int).Mul(n, pow10(2*digits))
+	return scaled.Sqrt(scaled)
+}
+
+// φ = (1 + √5) / 2
+func phi(digits int) *big.Int {
+	value := new(big.Int).Add(pow10(digits), sqrt(big.NewInt(5), digits))
+	return value.Rsh(value, 1)
+}
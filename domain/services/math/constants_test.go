@@ -0,0 +1,123 @@
+package math
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Constant(t *testing.T) {
+	tt := map[string]struct {
+		id           string
+		expectedName string
+		expected     string
+	}{
+		"e":       {id: "e", expectedName: "e", expected: "2.71828182845904523536028747135266249775724709369995"},
+		"phi":     {id: "phi", expectedName: "Phi", expected: "1.61803398874989484820458683436563811772030917980576"},
+		"ln2":     {id: "ln2", expectedName: "Ln2", expected: "0.69314718055994530941723212145817656807550013436025"},
+		"catalan": {id: "catalan", expectedName: "Catalan", expected: "0.91596559417721901505460351493238411077414937428167"},
+		"zeta3":   {id: "zeta3", expectedName: "Zeta3", expected: "1.20205690315959428539973816151144999076498629234049"},
+		"sqrt2":   {id: "sqrt2", expectedName: "Sqrt2", expected: "1.41421356237309504880168872420969807856967187537694"},
+		"sqrt10":  {id: "sqrt10", expectedName: "Sqrt10", expected: "3.16227766016837933199889354443271853371955513932521"},
+		"sqrt16":  {id: "sqrt16", expectedName: "Sqrt16", expected: "4.00000000000000000000000000000000000000000000000000"},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			constantName, value, err := Constant(tc.id, 50)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedName, constantName)
+			assert.Equal(t, tc.expected, value)
+		})
+	}
+}
+
+func Test_ConstantDigits(t *testing.T) {
+	_, value, err := Constant("e", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", value)
+
+	// NOTE: the more digits, the more terms, the first digits staying the same
+	_, short, _ := Constant("catalan", 100)
+	_, long, _ := Constant("catalan", MaxDigitsOf("catalan", 10))
+	assert.Len(t, long, MaxDigitsOf("catalan", 10)+2)
+	assert.Equal(t, short, long[:len(short)])
+
+	_, _, err = Constant("catalan", MaxDigitsOf("catalan", 10)+1)
+	assert.ErrorIs(t, err, ErrDigitsOutOfRange)
+
+	_, _, err = Constant("sqrt02", 10)
+	assert.ErrorIs(t, err, ErrUnknownConstant)
+
+	_, _, err = Constant("gamma", 10)
+	assert.ErrorIs(t, err, ErrUnknownConstant)
+}
+
+func Test_Digits(t *testing.T) {
+	tt := map[string]struct {
+		id       string
+		digits   int
+		base     int
+		expected string
+	}{
+		"pi in base 16": {id: "pi", digits: 20, base: 16, expected: "3.243f6a8885a308d31319"},
+		"pi in base 2":  {id: "pi", digits: 20, base: 2, expected: "11.00100100001111110110"},
+		"e in base 16":  {id: "e", digits: 12, base: 16, expected: "2.b7e151628aed"},
+		"sqrt2 base 2":  {id: "sqrt2", digits: 16, base: 2, expected: "1.0110101000001001"},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			_, value, err := Digits(tc.id, tc.digits, tc.base)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, value)
+		})
+	}
+
+	_, _, err := Digits("pi", 10, 8)
+	assert.ErrorIs(t, err, ErrUnsupportedBase)
+
+	_, _, err = Digits("pi", MaxDigitsOf("pi", 2)+1, 2)
+	assert.ErrorIs(t, err, ErrDigitsOutOfRange)
+}
+
+func Test_DigitsRange(t *testing.T) {
+	// NOTE: the Feynman point, six 9s from the 762nd decimal
+	_, value, err := DigitsRange("pi", 761, 6, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "999999", value)
+
+	_, value, err = DigitsRange("pi", 0, 4, 16)
+	assert.NoError(t, err)
+	assert.Equal(t, "243f", value)
+
+	_, _, err = DigitsRange("pi", -1, 4, 10)
+	assert.ErrorIs(t, err, ErrDigitsOutOfRange)
+
+	_, _, err = DigitsRange("pi", MaxDigits, 1, 10)
+	assert.ErrorIs(t, err, ErrDigitsOutOfRange)
+}
+
+func Test_Memo(t *testing.T) {
+	computations := 0
+	m := &memo{}
+	compute := func(digits int) *big.Int {
+		computations++
+		return e(digits)
+	}
+
+	assert.Equal(t, e(100), m.get(100, 1000, compute))
+	assert.Equal(t, e(50), m.get(50, 1000, compute))
+	assert.Equal(t, 1, computations)
+
+	assert.Equal(t, e(150), m.get(150, 1000, compute))
+	assert.Equal(t, 200, m.digits)
+	assert.Equal(t, e(180), m.get(180, 1000, compute))
+	assert.Equal(t, 2, computations)
+
+	// NOTE: beyond the limit, the digits asked are still computed
+	assert.Equal(t, e(1001), m.get(1001, 1000, compute))
+}
@@ -0,0 +1,615 @@
+package math
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Formats a number can be converted from and to, besides the bases from 2 to 36
+const (
+	RomanFormat      = "roman"
+	ScientificFormat = "scientific"
+	WordsFormat      = "words"
+	FractionFormat   = "fraction"
+)
+
+// aliases of the usual bases
+var baseAliases = map[string]int{
+	"binary":      2,
+	"octal":       8,
+	"decimal":     10,
+	"hexadecimal": 16,
+}
+
+const (
+	// MaxNumberLength bounds the characters of a number to convert
+	MaxNumberLength = 1000
+
+	// MaxFractionDigits bounds the digits written after the point, the result being rounded beyond
+	MaxFractionDigits = 1000
+
+	// maxScientificExponent bounds the exponent of the numbers in scientific notation
+	maxScientificExponent = 10000
+
+	// scientificDigits are the significant digits of the numbers without finite decimal expansion in scientific notation
+	scientificDigits = 30
+)
+
+var (
+	ErrUnknownFormat    = errors.New("unknown format")
+	ErrInvalidNumber    = errors.New("invalid number")
+	ErrNotRepresentable = errors.New("not representable")
+)
+
+// ValidFormat tells whether the format is a base from 2 to 36, an alias of a base, or one of the named formats
+func ValidFormat(format string) bool {
+	switch format {
+	case RomanFormat, ScientificFormat, WordsFormat, FractionFormat:
+		return true
+	}
+	_, err := base(format)
+	return err == nil
+}
+
+func base(format string) (int, error) {
+	if b, ok := baseAliases[format]; ok {
+		return b, nil
+	}
+	b, err := strconv.Atoi(format)
+	if err != nil || b < 2 || b > 36 || strconv.Itoa(b) != format {
+		return 0, ErrUnknownFormat
+	}
+	return b, nil
+}
+
+// ParseNumber reads the number written in the format, exactly
+func ParseNumber(value string, format string) (*big.Rat, error) {
+	if len(value) > MaxNumberLength {
+		return nil, fmt.Errorf("%w: longer than %d characters", ErrInvalidNumber, MaxNumberLength)
+	}
+
+	switch format {
+	case RomanFormat:
+		return parseRoman(value)
+	case ScientificFormat:
+		return parseScientific(value)
+	case WordsFormat:
+		return parseWords(value)
+	case FractionFormat:
+		return parseFraction(value)
+	}
+
+	b, err := base(format)
+	if err != nil {
+		return nil, err
+	}
+	return parseBase(value, b)
+}
+
+// FormatNumber writes the number in the format, telling whether it is exact or rounded
+func FormatNumber(r *big.Rat, format string) (string, bool, error) {
+	switch format {
+	case RomanFormat:
+		s, err := formatRoman(r)
+		return s, err == nil, err
+	case ScientificFormat:
+		s, exact := formatScientific(r)
+		return s, exact, nil
+	case WordsFormat:
+		s, err := formatWords(r)
+		return s, err == nil, err
+	case FractionFormat:
+		return r.RatString(), true, nil
+	}
+
+	b, err := base(format)
+	if err != nil {
+		return "", false, err
+	}
+	s, exact := formatBase(r, b)
+	return s, exact, nil
+}
+
+// baseNumber is a number in a base, with a fractional part whose repeating digits are between parentheses, like 0.1(6)
+var baseNumber = regexp.MustCompile(`^([+-]?)([0-9a-zA-Z]*)(?:\.([0-9a-zA-Z]*)(?:\(([0-9a-zA-Z]+)\))?)?$`)
+
+func parseBase(value string, b int) (*big.Rat, error) {
+	match := baseNumber.FindStringSubmatch(value)
+	if match == nil || match[2]+match[3]+match[4] == "" {
+		return nil, fmt.Errorf("%w in base %d", ErrInvalidNumber, b)
+	}
+	sign, integer, fraction, repeating := match[1], match[2], match[3], match[4]
+
+	digits := func(s string) (*big.Int, error) {
+		if s == "" {
+			return new(big.Int), nil
+		}
+		n, ok := new(big.Int).SetString(strings.ToLower(s), b)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s has digits out of base %d", ErrInvalidNumber, s, b)
+		}
+		return n, nil
+	}
+
+	// NOTE: x = I + F / b^k + R / (b^k (b^m - 1)), k and m being the number of digits of F and R
+	n, err := digits(integer)
+	if err != nil {
+		return nil, err
+	}
+	r := new(big.Rat).SetInt(n)
+
+	scale := new(big.Int).Exp(big.NewInt(int64(b)), big.NewInt(int64(len(fraction))), nil)
+	if n, err = digits(fraction); err != nil {
+		return nil, err
+	}
+	r.Add(r, new(big.Rat).SetFrac(n, scale))
+
+	if repeating != "" {
+		if n, err = digits(repeating); err != nil {
+			return nil, err
+		}
+		period := new(big.Int).Exp(big.NewInt(int64(b)), big.NewInt(int64(len(repeating))), nil)
+		period.Sub(period, one)
+		r.Add(r, new(big.Rat).SetFrac(n, period.Mul(period, scale)))
+	}
+
+	if sign == "-" {
+		r.Neg(r)
+	}
+	return r, nil
+}
+
+// formatBase writes the number in base b, the repeating digits between parentheses,
+// or rounded toward zero after MaxFractionDigits digits when the period is longer
+func formatBase(r *big.Rat, b int) (string, bool) {
+	integer, remainder := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	remainder.Abs(remainder)
+
+	var s strings.Builder
+	if r.Sign() < 0 {
+		s.WriteString("-")
+	}
+	s.WriteString(new(big.Int).Abs(integer).Text(b))
+	if remainder.Sign() == 0 {
+		return s.String(), true
+	}
+
+	// NOTE: the long division repeats as soon as a remainder does
+	var fraction []byte
+	positions := map[string]int{}
+	bigBase := big.NewInt(int64(b))
+	digit := new(big.Int)
+	for remainder.Sign() != 0 {
+		if len(fraction) == MaxFractionDigits {
+			s.WriteString("." + string(fraction))
+			return s.String(), false
+		}
+		key := remainder.String()
+		if start, ok := positions[key]; ok {
+			s.WriteString("." + string(fraction[:start]) + "(" + string(fraction[start:]) + ")")
+			return s.String(), true
+		}
+		positions[key] = len(fraction)
+
+		remainder.Mul(remainder, bigBase)
+		digit.QuoRem(remainder, r.Denom(), remainder)
+		fraction = append(fraction, strconv.FormatInt(digit.Int64(), b)...)
+	}
+
+	s.WriteString("." + string(fraction))
+	return s.String(), true
+}
+
+func parseFraction(value string) (*big.Rat, error) {
+	numerator, denominator, found := strings.Cut(value, "/")
+	n, err := ParseInteger(numerator)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not an integer or a fraction p/q", ErrInvalidNumber)
+	}
+	if !found {
+		return new(big.Rat).SetInt(n), nil
+	}
+
+	d, err := ParseInteger(denominator)
+	if err != nil || d.Sign() == 0 {
+		return nil, fmt.Errorf("%w: not an integer or a fraction p/q", ErrInvalidNumber)
+	}
+	return new(big.Rat).SetFrac(n, d), nil
+}
+
+var scientificNumber = regexp.MustCompile(`^[+-]?[0-9]+(?:\.[0-9]+)?(?:[eE]([+-]?[0-9]+))?$`)
+
+func parseScientific(value string) (*big.Rat, error) {
+	match := scientificNumber.FindStringSubmatch(value)
+	if match == nil {
+		return nil, fmt.Errorf("%w: not in scientific notation, like 1.5e-3", ErrInvalidNumber)
+	}
+	if exponent, err := strconv.Atoi(match[1]); match[1] != "" && (err != nil || exponent > maxScientificExponent || exponent < -maxScientificExponent) {
+		return nil, fmt.Errorf("%w: exponent beyond ±%d", ErrInvalidNumber, maxScientificExponent)
+	}
+
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, ErrInvalidNumber
+	}
+	return r, nil
+}
+
+// decimalExpansion returns n and scale with |r| = n / 10^scale when r has a finite decimal expansion
+func decimalExpansion(r *big.Rat) (*big.Int, int, bool) {
+	denominator := new(big.Int).Set(r.Denom())
+	twos, fives := 0, 0
+	remainder := new(big.Int)
+	for _, f := range []struct {
+		factor *big.Int
+		count  *int
+	}{{big.NewInt(2), &twos}, {big.NewInt(5), &fives}} {
+		for {
+			quotient, _ := new(big.Int).QuoRem(denominator, f.factor, remainder)
+			if remainder.Sign() != 0 {
+				break
+			}
+			denominator = quotient
+			*f.count++
+		}
+	}
+	if denominator.Cmp(one) != 0 {
+		return nil, 0, false
+	}
+
+	scale := max(twos, fives)
+	n := new(big.Int).Mul(new(big.Int).Abs(r.Num()), pow10(scale))
+	return n.Quo(n, r.Denom()), scale, true
+}
+
+// formatScientific writes the number like 1.5e-3, exactly when it has a finite decimal expansion
+// of at most MaxFractionDigits digits, rounded to scientificDigits digits otherwise
+func formatScientific(r *big.Rat) (string, bool) {
+	if r.Sign() == 0 {
+		return "0", true
+	}
+
+	digits, scale, exact := decimalExpansion(r)
+	if exact {
+		s := digits.String()
+		exact = len(s) <= MaxFractionDigits
+		if exact {
+			return scientific(r.Sign(), s, len(s)-1-scale), true
+		}
+	}
+
+	// NOTE: the exponent is estimated from the bits, then corrected
+	abs := new(big.Rat).Abs(r)
+	exponent := int(float64(abs.Num().BitLen()-abs.Denom().BitLen()) * 0.30102999566398120)
+	for abs.Cmp(tenTo(exponent)) < 0 {
+		exponent--
+	}
+	for abs.Cmp(tenTo(exponent+1)) >= 0 {
+		exponent++
+	}
+
+	// NOTE: the mantissa is rounded half up, a carry making it 10
+	scaled := new(big.Rat).Quo(abs, tenTo(exponent-scientificDigits+1))
+	rounded := new(big.Int).Mul(scaled.Num(), two)
+	rounded.Add(rounded, scaled.Denom())
+	rounded.Quo(rounded, new(big.Int).Mul(scaled.Denom(), two))
+	s := rounded.String()
+	if len(s) > scientificDigits {
+		s, exponent = s[:scientificDigits], exponent+1
+	}
+	return scientific(r.Sign(), s, exponent), false
+}
+
+// tenTo returns 10^n, n being negative or not
+func tenTo(n int) *big.Rat {
+	if n < 0 {
+		return new(big.Rat).SetFrac(one, pow10(-n))
+	}
+	return new(big.Rat).SetInt(pow10(n))
+}
+
+// scientific writes the significant digits as d.ddd × 10^exponent
+func scientific(sign int, digits string, exponent int) string {
+	digits = strings.TrimRight(digits, "0")
+	mantissa := digits[:1]
+	if len(digits) > 1 {
+		mantissa += "." + digits[1:]
+	}
+	if sign < 0 {
+		mantissa = "-" + mantissa
+	}
+	if exponent == 0 {
+		return mantissa
+	}
+	return mantissa + "e" + strconv.Itoa(exponent)
+}
+
+var romanNumeral = regexp.MustCompile(`^M{0,3}(?:CM|CD|D?C{0,3})(?:XC|XL|L?X{0,3})(?:IX|IV|V?I{0,3})$`)
+
+var romanSymbols = []struct {
+	value  int64
+	symbol string
+}{
+	{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"}, {100, "C"}, {90, "XC"},
+	{50, "L"}, {40, "XL"}, {10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+}
+
+func parseRoman(value string) (*big.Rat, error) {
+	value = strings.ToUpper(value)
+	if value == "" || !romanNumeral.MatchString(value) {
+		return nil, fmt.Errorf("%w: not a roman numeral from I to MMMCMXCIX", ErrInvalidNumber)
+	}
+
+	var n int64
+	for _, s := range romanSymbols {
+		for strings.HasPrefix(value, s.symbol) {
+			n += s.value
+			value = value[len(s.symbol):]
+		}
+	}
+	return new(big.Rat).SetInt64(n), nil
+}
+
+func formatRoman(r *big.Rat) (string, error) {
+	if !r.IsInt() || r.Num().Cmp(big.NewInt(1)) < 0 || r.Num().Cmp(big.NewInt(3999)) > 0 {
+		return "", fmt.Errorf("%w: roman numerals are the integers from 1 to 3999", ErrNotRepresentable)
+	}
+
+	n := r.Num().Int64()
+	var s strings.Builder
+	for _, symbol := range romanSymbols {
+		for ; n >= symbol.value; n -= symbol.value {
+			s.WriteString(symbol.symbol)
+		}
+	}
+	return s.String(), nil
+}
+
+var (
+	smallNumberWords = []string{
+		"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten",
+		"eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen",
+	}
+	tensWords = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+
+	// scaleWords are the short scale names of the powers of 1000
+	scaleWords = []string{
+		"", "thousand", "million", "billion", "trillion", "quadrillion", "quintillion", "sextillion", "septillion",
+		"octillion", "nonillion", "decillion", "undecillion", "duodecillion", "tredecillion", "quattuordecillion",
+		"quindecillion", "sexdecillion", "septendecillion", "octodecillion", "novemdecillion", "vigintillion",
+	}
+)
+
+// maxWords is the first integer without name, a thousand vigintillions
+var maxWords = pow10(3 * len(scaleWords))
+
+// formatWords writes the number in English, the decimals being read one by one after point,
+// the fractions without finite decimal expansion being written as a numerator over a denominator
+func formatWords(r *big.Rat) (string, error) {
+	if new(big.Int).Abs(r.Num()).Cmp(maxWords) >= 0 || r.Denom().Cmp(maxWords) >= 0 {
+		return "", fmt.Errorf("%w: the names of the numbers stop at the vigintillions", ErrNotRepresentable)
+	}
+
+	sign := ""
+	if r.Sign() < 0 {
+		sign = "minus "
+	}
+	if r.IsInt() {
+		return sign + integerWords(new(big.Int).Abs(r.Num())), nil
+	}
+
+	digits, scale, ok := decimalExpansion(r)
+	if !ok || scale > MaxFractionDigits {
+		return sign + integerWords(new(big.Int).Abs(r.Num())) + " over " + integerWords(r.Denom()), nil
+	}
+
+	integer, fraction := new(big.Int).QuoRem(digits, pow10(scale), new(big.Int))
+	decimals := fraction.String()
+	decimals = strings.Repeat("0", scale-len(decimals)) + decimals
+
+	words := []string{sign + integerWords(integer), "point"}
+	for _, d := range decimals {
+		words = append(words, smallNumberWords[d-'0'])
+	}
+	return strings.Join(words, " "), nil
+}
+
+// integerWords writes n, positive and below maxWords, like "one hundred twenty-three thousand four hundred fifty-six"
+func integerWords(n *big.Int) string {
+	if n.Sign() == 0 {
+		return smallNumberWords[0]
+	}
+
+	var groups []string
+	rest, group := new(big.Int).Set(n), new(big.Int)
+	for scale := 0; rest.Sign() > 0; scale++ {
+		rest.QuoRem(rest, big.NewInt(1000), group)
+		if g := int(group.Int64()); g > 0 {
+			words := hundredsWords(g)
+			if scaleWords[scale] != "" {
+				words += " " + scaleWords[scale]
+			}
+			groups = append([]string{words}, groups...)
+		}
+	}
+	return strings.Join(groups, " ")
+}
+
+// hundredsWords writes n between 1 and 999
+func hundredsWords(n int) string {
+	var words []string
+	if n >= 100 {
+		words = append(words, smallNumberWords[n/100], "hundred")
+		n %= 100
+	}
+	switch {
+	case n >= 20 && n%10 != 0:
+		words = append(words, tensWords[n/10]+"-"+smallNumberWords[n%10])
+	case n >= 20:
+		words = append(words, tensWords[n/10])
+	case n > 0:
+		words = append(words, smallNumberWords[n])
+	}
+	return strings.Join(words, " ")
+}
+
+// parseWords reads the numbers written by formatWords, "and" being allowed like in "one hundred and five"
+func parseWords(value string) (*big.Rat, error) {
+	fields := strings.Fields(strings.ToLower(strings.ReplaceAll(value, "-", " ")))
+
+	negative := len(fields) > 0 && fields[0] == "minus"
+	if negative {
+		fields = fields[1:]
+	}
+
+	var r *big.Rat
+	numerator, denominator, isFraction := cutWords(fields, "over")
+	integer, decimals, isDecimal := cutWords(fields, "point")
+	switch {
+	case isFraction:
+		n, err := wordsInteger(numerator)
+		if err != nil {
+			return nil, err
+		}
+		d, err := wordsInteger(denominator)
+		if err != nil {
+			return nil, err
+		}
+		if d.Sign() == 0 {
+			return nil, fmt.Errorf("%w: division by zero", ErrInvalidNumber)
+		}
+		r = new(big.Rat).SetFrac(n, d)
+	case isDecimal:
+		n, err := wordsInteger(integer)
+		if err != nil {
+			return nil, err
+		}
+		digits := ""
+		for _, word := range decimals {
+			d := wordDigit(word)
+			if d < 0 {
+				return nil, fmt.Errorf("%w: %s is not a digit", ErrInvalidNumber, word)
+			}
+			digits += strconv.Itoa(d)
+		}
+		fraction, ok := new(big.Int).SetString(digits, 10)
+		if !ok {
+			return nil, fmt.Errorf("%w: no digits after point", ErrInvalidNumber)
+		}
+		r = new(big.Rat).SetInt(n)
+		r.Add(r, new(big.Rat).SetFrac(fraction, pow10(len(digits))))
+	default:
+		n, err := wordsInteger(fields)
+		if err != nil {
+			return nil, err
+		}
+		r = new(big.Rat).SetInt(n)
+	}
+
+	if negative {
+		r.Neg(r)
+	}
+	return r, nil
+}
+
+func cutWords(fields []string, separator string) ([]string, []string, bool) {
+	for i, field := range fields {
+		if field == separator {
+			return fields[:i], fields[i+1:], true
+		}
+	}
+	return fields, nil, false
+}
+
+func wordDigit(word string) int {
+	for d, w := range smallNumberWords[:10] {
+		if w == word {
+			return d
+		}
+	}
+	return -1
+}
+
+// Stages of the group of words read by wordsInteger, like "five hundred sixty seven" before its scale
+const (
+	groupStart = iota
+	// groupUnit is a number below twenty which may be followed by hundred
+	groupUnit
+	groupHundreds
+	groupTens
+	// groupEnd only leaves the scale to come
+	groupEnd
+)
+
+// wordsInteger reads an integer, each group being hundreds, tens and units in this order until a scale multiplies them,
+// the scales decreasing
+func wordsInteger(fields []string) (*big.Int, error) {
+	if len(fields) == 1 && fields[0] == smallNumberWords[0] {
+		return new(big.Int), nil
+	}
+
+	total := new(big.Int)
+	current := int64(0)
+	stage := groupStart
+	lastScale := len(scaleWords)
+	for _, word := range fields {
+		if word == "and" {
+			continue
+		}
+
+		unexpected := fmt.Errorf("%w: unexpected word %s", ErrInvalidNumber, word)
+		if v := indexOf(smallNumberWords, word); v >= 1 {
+			switch {
+			case stage == groupStart:
+				stage = groupUnit
+			case stage == groupHundreds, stage == groupTens && v < 10:
+				stage = groupEnd
+			default:
+				return nil, unexpected
+			}
+			current += int64(v)
+			continue
+		}
+		if v := indexOf(tensWords, word); v >= 2 {
+			if stage != groupStart && stage != groupHundreds {
+				return nil, unexpected
+			}
+			stage = groupTens
+			current += int64(10 * v)
+			continue
+		}
+		if word == "hundred" {
+			if stage != groupUnit || current >= 10 {
+				return nil, unexpected
+			}
+			stage = groupHundreds
+			current *= 100
+			continue
+		}
+		scale := indexOf(scaleWords, word)
+		if scale < 1 || scale >= lastScale || stage == groupStart {
+			return nil, unexpected
+		}
+		lastScale = scale
+		total.Add(total, new(big.Int).Mul(big.NewInt(current), pow10(3*scale)))
+		current = 0
+		stage = groupStart
+	}
+	if stage == groupStart && total.Sign() == 0 {
+		return nil, fmt.Errorf("%w: no number", ErrInvalidNumber)
+	}
+	return total.Add(total, big.NewInt(current)), nil
+}
+
+func indexOf(words []string, word string) int {
+	for i, w := range words {
+		if w == word && w != "" {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,134 @@
+package math
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Convert(t *testing.T) {
+	tt := map[string]struct {
+		value         string
+		from          string
+		to            string
+		expected      string
+		expectedExact bool
+	}{
+		"decimal to binary":        {value: "10", from: "10", to: "2", expected: "1010", expectedExact: true},
+		"hexadecimal to decimal":   {value: "-FF", from: "hexadecimal", to: "decimal", expected: "-255", expectedExact: true},
+		"base 36":                  {value: "zz", from: "36", to: "10", expected: "1295", expectedExact: true},
+		"large integer":            {value: "18446744073709551616", from: "10", to: "16", expected: "10000000000000000", expectedExact: true},
+		"terminating fraction":     {value: "0.75", from: "10", to: "2", expected: "0.11", expectedExact: true},
+		"repeating fraction":       {value: "0.1", from: "10", to: "2", expected: "0.0(0011)", expectedExact: true},
+		"repeating from base":      {value: "0.0(0011)", from: "2", to: "10", expected: "0.1", expectedExact: true},
+		"mixed repeating":          {value: "-1.1(6)", from: "10", to: "fraction", expected: "-7/6", expectedExact: true},
+		"fraction to decimal":      {value: "22/7", from: "fraction", to: "10", expected: "3.(142857)", expectedExact: true},
+		"fraction to fraction":     {value: "6/-4", from: "fraction", to: "fraction", expected: "-3/2", expectedExact: true},
+		"no integer part":          {value: ".5", from: "10", to: "fraction", expected: "1/2", expectedExact: true},
+		"roman to decimal":         {value: "MCMXCIV", from: "roman", to: "10", expected: "1994", expectedExact: true},
+		"decimal to roman":         {value: "3999", from: "10", to: "roman", expected: "MMMCMXCIX", expectedExact: true},
+		"lower case roman":         {value: "xlii", from: "roman", to: "10", expected: "42", expectedExact: true},
+		"scientific to decimal":    {value: "1.5e-3", from: "scientific", to: "10", expected: "0.0015", expectedExact: true},
+		"decimal to scientific":    {value: "-123000", from: "10", to: "scientific", expected: "-1.23e5", expectedExact: true},
+		"small scientific":         {value: "0.00042", from: "10", to: "scientific", expected: "4.2e-4", expectedExact: true},
+		"scientific without power": {value: "7", from: "10", to: "scientific", expected: "7", expectedExact: true},
+		"rounded scientific":       {value: "2/3", from: "fraction", to: "scientific", expected: "6.66666666666666666666666666667e-1"},
+		"rounded small scientific": {value: "-1/7000", from: "fraction", to: "scientific", expected: "-1.42857142857142857142857142857e-4"},
+		"carry in scientific":      {value: "29999999999999999999999999999999/3", from: "fraction", to: "scientific", expected: "1e31"},
+		"words":                    {value: "1234567", from: "10", to: "words", expected: "one million two hundred thirty-four thousand five hundred sixty-seven", expectedExact: true},
+		"zero words":               {value: "0", from: "10", to: "words", expected: "zero", expectedExact: true},
+		"negative decimal words":   {value: "-3.05", from: "10", to: "words", expected: "minus three point zero five", expectedExact: true},
+		"fraction words":           {value: "1/3", from: "fraction", to: "words", expected: "one over three", expectedExact: true},
+		"words to decimal":         {value: "Two Thousand and Twenty-Four", from: "words", to: "10", expected: "2024", expectedExact: true},
+		"decimal words to decimal": {value: "minus zero point two five", from: "words", to: "10", expected: "-0.25", expectedExact: true},
+		"fraction words to binary": {value: "one over three", from: "words", to: "2", expected: "0.(01)", expectedExact: true},
+		"large words":              {value: "one vigintillion", from: "words", to: "scientific", expected: "1e63", expectedExact: true},
+		"hundreds and units":       {value: "one hundred and five", from: "words", to: "10", expected: "105", expectedExact: true},
+		"every stage":              {value: "nine hundred ninety-nine million nineteen thousand seven hundred", from: "words", to: "10", expected: "999019700", expectedExact: true},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			r, err := ParseNumber(tc.value, tc.from)
+			assert.NoError(t, err)
+
+			actual, exact, err := FormatNumber(r, tc.to)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+			assert.Equal(t, tc.expectedExact, exact)
+		})
+	}
+}
+
+func Test_ConvertLongPeriod(t *testing.T) {
+	// NOTE: the period of 1/1019 in base 10 is 1018 digits long
+	r, err := ParseNumber("1/1019", FractionFormat)
+	assert.NoError(t, err)
+
+	actual, exact, err := FormatNumber(r, "10")
+	assert.NoError(t, err)
+	assert.False(t, exact)
+	assert.Len(t, actual, 2+MaxFractionDigits)
+	assert.True(t, strings.HasPrefix(actual, "0.000981354268891069676153091265947006869479882237487"))
+}
+
+func Test_ParseNumberErrors(t *testing.T) {
+	tt := map[string]struct {
+		value    string
+		format   string
+		expected error
+	}{
+		"unknown format":       {value: "1", format: "37", expected: ErrUnknownFormat},
+		"padded base":          {value: "1", format: "02", expected: ErrUnknownFormat},
+		"digit out of base":    {value: "102", format: "2", expected: ErrInvalidNumber},
+		"empty":                {value: "", format: "10", expected: ErrInvalidNumber},
+		"sign only":            {value: "-", format: "10", expected: ErrInvalidNumber},
+		"empty period":         {value: "0.()", format: "10", expected: ErrInvalidNumber},
+		"too long":             {value: strings.Repeat("1", MaxNumberLength+1), format: "10", expected: ErrInvalidNumber},
+		"zero denominator":     {value: "1/0", format: FractionFormat, expected: ErrInvalidNumber},
+		"decimal fraction":     {value: "1.5/2", format: FractionFormat, expected: ErrInvalidNumber},
+		"large exponent":       {value: "1e100000", format: ScientificFormat, expected: ErrInvalidNumber},
+		"hexadecimal float":    {value: "0x1p-2", format: ScientificFormat, expected: ErrInvalidNumber},
+		"invalid roman":        {value: "IIII", format: RomanFormat, expected: ErrInvalidNumber},
+		"empty roman":          {value: "", format: RomanFormat, expected: ErrInvalidNumber},
+		"unknown word":         {value: "one zillion", format: WordsFormat, expected: ErrInvalidNumber},
+		"scales out of order":  {value: "one thousand one million", format: WordsFormat, expected: ErrInvalidNumber},
+		"tens twice":           {value: "twenty twenty", format: WordsFormat, expected: ErrInvalidNumber},
+		"units twice":          {value: "one one one", format: WordsFormat, expected: ErrInvalidNumber},
+		"hundred twice":        {value: "nine hundred hundred hundred hundred hundred hundred hundred hundred hundred hundred", format: WordsFormat, expected: ErrInvalidNumber},
+		"teen after tens":      {value: "twenty twelve", format: WordsFormat, expected: ErrInvalidNumber},
+		"hundreds after tens":  {value: "twenty one hundred", format: WordsFormat, expected: ErrInvalidNumber},
+		"zero in a group":      {value: "one thousand zero", format: WordsFormat, expected: ErrInvalidNumber},
+		"scale alone":          {value: "thousand", format: WordsFormat, expected: ErrInvalidNumber},
+		"and alone":            {value: "and", format: WordsFormat, expected: ErrInvalidNumber},
+		"no digit after point": {value: "one point", format: WordsFormat, expected: ErrInvalidNumber},
+		"not a digit":          {value: "one point twelve", format: WordsFormat, expected: ErrInvalidNumber},
+		"over zero":            {value: "one over zero", format: WordsFormat, expected: ErrInvalidNumber},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseNumber(tc.value, tc.format)
+			assert.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func Test_FormatNumberErrors(t *testing.T) {
+	for _, r := range []*big.Rat{big.NewRat(0, 1), big.NewRat(4000, 1), big.NewRat(1, 2), big.NewRat(-5, 1)} {
+		_, _, err := FormatNumber(r, RomanFormat)
+		assert.ErrorIs(t, err, ErrNotRepresentable, r.String())
+	}
+
+	_, _, err := FormatNumber(new(big.Rat).SetInt(pow10(66)), WordsFormat)
+	assert.ErrorIs(t, err, ErrNotRepresentable)
+
+	_, _, err = FormatNumber(big.NewRat(1, 1), "1")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+
+	assert.True(t, ValidFormat("binary"))
+	assert.True(t, ValidFormat(WordsFormat))
+	assert.False(t, ValidFormat("base64"))
+}
@@ -0,0 +1,156 @@
+package math
+
+import (
+	"math"
+	"math/big"
+)
+
+// guardBits are computed beyond the requested precision so that the rounding errors do not reach it
+const guardBits = 32
+
+// maxReduction bounds the binary exponent of the arguments reduced before a series, beyond which the result is out of range
+const maxReduction = 1 << 14
+
+// float returns the constant C rounded to prec bits
+func (c constant) float(prec uint) *big.Float {
+	digits := int(math.Ceil(float64(prec)*math.Log10(2))) + guardDigits
+
+	value := new(big.Float).SetPrec(prec).SetInt(c.decimals(digits))
+	return value.Quo(value, new(big.Float).SetPrec(prec).SetInt(pow10(digits)))
+}
+
+// converged tells whether the term no longer changes the sum at prec bits
+func converged(term *big.Float, sum *big.Float, prec uint) bool {
+	return term.Sign() == 0 || (sum.Sign() != 0 && term.MantExp(nil) < sum.MantExp(nil)-int(prec))
+}
+
+// expFloat returns e^x, x being halved k times so that the Taylor series converges fast, the sum being squared k times back.
+// It returns ±Inf or 0 when the result is out of the range of big.Float.
+func expFloat(x *big.Float, prec uint) *big.Float {
+	k := max(x.MantExp(nil)+8, 0)
+	if k > maxReduction {
+		if x.Sign() > 0 {
+			return new(big.Float).SetInf(false)
+		}
+		return new(big.Float).SetPrec(prec)
+	}
+
+	work := prec + uint(k) + guardBits
+	r := new(big.Float).SetPrec(work).SetMantExp(x, -k)
+
+	sum := new(big.Float).SetPrec(work).SetInt64(1)
+	term := new(big.Float).SetPrec(work).SetInt64(1)
+	for n := int64(1); ; n++ {
+		term.Mul(term, r)
+		term.Quo(term, new(big.Float).SetInt64(n))
+		if converged(term, sum, work) {
+			break
+		}
+		sum.Add(sum, term)
+	}
+
+	for i := 0; i < k && !sum.IsInf(); i++ {
+		sum.Mul(sum, sum)
+	}
+	return sum.SetPrec(prec)
+}
+
+// lnFloat returns ln(x) for x > 0, with x = m 2^k and ln(m) = 2 atanh((m-1)/(m+1)), m being between √½ and √2
+func lnFloat(x *big.Float, prec uint) *big.Float {
+	work := prec + guardBits
+
+	m := new(big.Float).SetPrec(work)
+	k := x.MantExp(m)
+	if m.Cmp(big.NewFloat(math.Sqrt2/2)) < 0 {
+		m.SetMantExp(m, 1)
+		k--
+	}
+
+	one := new(big.Float).SetPrec(work).SetInt64(1)
+	z := new(big.Float).SetPrec(work).Sub(m, one)
+	z.Quo(z, new(big.Float).SetPrec(work).Add(m, one))
+	z2 := new(big.Float).SetPrec(work).Mul(z, z)
+
+	sum := new(big.Float).SetPrec(work).Set(z)
+	power := new(big.Float).SetPrec(work).Set(z)
+	term := new(big.Float).SetPrec(work)
+	for n := int64(1); z.Sign() != 0; n++ {
+		power.Mul(power, z2)
+		term.Quo(power, new(big.Float).SetInt64(2*n+1))
+		if converged(term, sum, work) {
+			break
+		}
+		sum.Add(sum, term)
+	}
+	sum.SetMantExp(sum, 1)
+
+	if k != 0 {
+		ln2 := constants["ln2"].float(work)
+		sum.Add(sum, ln2.Mul(ln2, new(big.Float).SetInt64(int64(k))))
+	}
+	return sum.SetPrec(prec)
+}
+
+// sinCosFloat returns sin(x) and cos(x), x being reduced between -π and π before the Taylor series.
+// It returns false when x is too large to be reduced.
+func sinCosFloat(x *big.Float, prec uint) (*big.Float, *big.Float, bool) {
+	extra := max(x.MantExp(nil), 0)
+	if extra > maxReduction {
+		return nil, nil, false
+	}
+	work := prec + uint(extra) + guardBits
+
+	// NOTE: r = x - n τ, n being the nearest integer of x / τ
+	tau := constants["tau"].float(work)
+	n := new(big.Float).SetPrec(work).Quo(x, tau)
+	n.Add(n, big.NewFloat(0.5*float64(n.Sign())))
+	turns, _ := n.Int(nil)
+	r := new(big.Float).SetPrec(work).Mul(tau, new(big.Float).SetPrec(work).SetInt(turns))
+	r.Sub(x, r)
+
+	r2 := new(big.Float).SetPrec(work).Mul(r, r)
+	r2.Neg(r2)
+
+	sin := new(big.Float).SetPrec(work).Set(r)
+	term := new(big.Float).SetPrec(work).Set(r)
+	for i := int64(1); r.Sign() != 0; i++ {
+		term.Mul(term, r2)
+		term.Quo(term, new(big.Float).SetInt64((2*i)*(2*i+1)))
+		if converged(term, sin, work) {
+			break
+		}
+		sin.Add(sin, term)
+	}
+
+	cos := new(big.Float).SetPrec(work).SetInt64(1)
+	term.SetInt64(1)
+	for i := int64(1); r.Sign() != 0; i++ {
+		term.Mul(term, r2)
+		term.Quo(term, new(big.Float).SetInt64((2*i-1)*(2*i)))
+		if converged(term, cos, work) {
+			break
+		}
+		cos.Add(cos, term)
+	}
+
+	return sin.SetPrec(prec), cos.SetPrec(prec), true
+}
+
+// powInt returns x^n by squaring, n being positive.
+// It returns Inf when the result is out of range, the squares going out of range before the last bit of n.
+func powInt(x *big.Float, n *big.Int, prec uint) *big.Float {
+	work := prec + uint(n.BitLen()) + guardBits
+
+	result := new(big.Float).SetPrec(work).SetInt64(1)
+	square := new(big.Float).SetPrec(work).Set(x)
+	for i := 0; i < n.BitLen(); i++ {
+		if outOfRange(square) {
+			return new(big.Float).SetInf(false)
+		}
+		if n.Bit(i) == 1 {
+			result.Mul(result, square)
+		}
+		square.Mul(square, square)
+	}
+	return result.SetPrec(prec)
+}
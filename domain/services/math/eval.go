@@ -0,0 +1,537 @@
+package math
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+const (
+	// MaxEvalDigits bounds the significant digits of an evaluated expression
+	MaxEvalDigits = 1000
+
+	// MaxExpressionLength bounds the characters of an expression
+	MaxExpressionLength = 1000
+
+	// MaxFactorial bounds the integers whose factorial is computed
+	MaxFactorial = 10000
+
+	// maxDepth bounds the nesting of an expression
+	maxDepth = 100
+
+	// maxExponent bounds the binary exponent of the values, in absolute value, keeping MaxFactorial! in range,
+	// the values beyond being out of range as big.Float formats the tiny ones in a time quadratic in their exponent
+	maxExponent = 1 << 17
+
+	// maxPowBits bounds the bits of the integer powers computed by squaring, the larger ones being computed as exp(y ln(x))
+	maxPowBits = 64
+)
+
+// Kinds of the nodes of a parse tree
+const (
+	NumberNode   = "number"
+	ConstantNode = "constant"
+	UnaryNode    = "unary"
+	BinaryNode   = "binary"
+	PostfixNode  = "postfix"
+	FunctionNode = "function"
+)
+
+// Node is a node of the parse tree of an expression, its position being the one of its operator, name or number
+type Node struct {
+	Kind     string  `json:"kind" xml:"kind,attr" yaml:"kind"`
+	Value    string  `json:"value" xml:"value,attr" yaml:"value"`
+	Position int     `json:"position" xml:"position,attr" yaml:"position"`
+	Args     []*Node `json:"args,omitempty" xml:"node" yaml:"args,omitempty"`
+}
+
+// ExprError is a syntax or evaluation error of an expression, at a position counted in characters from 1
+type ExprError struct {
+	Position int
+	Message  string
+}
+
+func (e *ExprError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Position)
+}
+
+func exprError(position int, format string, a ...any) *ExprError {
+	return &ExprError{Position: position, Message: fmt.Sprintf(format, a...)}
+}
+
+type token struct {
+	kind     string
+	text     string
+	position int
+}
+
+const (
+	numberToken   = "number"
+	nameToken     = "name"
+	operatorToken = "operator"
+	endToken      = "end"
+)
+
+// tokenize splits the expression in numbers like 1.5e-3, lowercase names and the operators + - * / ^ ! ( ) ,
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.IndexByte("+-*/^!(),", c) >= 0:
+			tokens = append(tokens, token{kind: operatorToken, text: expr[i : i+1], position: i + 1})
+			i++
+		case c >= '0' && c <= '9' || c == '.':
+			j := i
+			for j < len(expr) && (expr[j] >= '0' && expr[j] <= '9' || expr[j] == '.') {
+				j++
+			}
+			// NOTE: the exponent needs digits, e alone being the constant
+			if j < len(expr) && (expr[j] == 'e' || expr[j] == 'E') {
+				k := j + 1
+				if k < len(expr) && (expr[k] == '+' || expr[k] == '-') {
+					k++
+				}
+				if k < len(expr) && expr[k] >= '0' && expr[k] <= '9' {
+					for j = k; j < len(expr) && expr[j] >= '0' && expr[j] <= '9'; j++ {
+					}
+				}
+			}
+			tokens = append(tokens, token{kind: numberToken, text: expr[i:j], position: i + 1})
+			i = j
+		case c >= 'a' && c <= 'z':
+			j := i
+			for j < len(expr) && (expr[j] >= 'a' && expr[j] <= 'z' || expr[j] >= '0' && expr[j] <= '9') {
+				j++
+			}
+			tokens = append(tokens, token{kind: nameToken, text: expr[i:j], position: i + 1})
+			i = j
+		default:
+			return nil, exprError(i+1, "unexpected character %q", rune(c))
+		}
+	}
+	return append(tokens, token{kind: endToken, position: len(expr) + 1}), nil
+}
+
+// parser reads the tokens by recursive descent, from the lowest precedence:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/") unary }
+//	unary   = ("-" | "+") unary | power
+//	power   = postfix [ "^" unary ]
+//	postfix = primary { "!" }
+//	primary = number | name [ "(" expr { "," expr } ")" ] | "(" expr ")"
+type parser struct {
+	tokens []token
+	next   int
+	depth  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) take() token {
+	t := p.tokens[p.next]
+	if t.kind != endToken {
+		p.next++
+	}
+	return t
+}
+
+func (p *parser) is(text string) bool {
+	t := p.peek()
+	return t.kind == operatorToken && t.text == text
+}
+
+func unexpected(t token) *ExprError {
+	if t.kind == endToken {
+		return exprError(t.position, "unexpected end of expression")
+	}
+	return exprError(t.position, "unexpected %q", t.text)
+}
+
+func (p *parser) expr() (*Node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, exprError(p.peek().position, "expression nested too deeply")
+	}
+
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("+") || p.is("-") {
+		operator := p.take()
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		left = &Node{Kind: BinaryNode, Value: operator.text, Position: operator.position, Args: []*Node{left, right}}
+	}
+	return left, nil
+}
+
+func (p *parser) term() (*Node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("*") || p.is("/") {
+		operator := p.take()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = &Node{Kind: BinaryNode, Value: operator.text, Position: operator.position, Args: []*Node{left, right}}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (*Node, error) {
+	if p.is("-") || p.is("+") {
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > maxDepth {
+			return nil, exprError(p.peek().position, "expression nested too deeply")
+		}
+
+		operator := p.take()
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Kind: UnaryNode, Value: operator.text, Position: operator.position, Args: []*Node{operand}}, nil
+	}
+	return p.power()
+}
+
+func (p *parser) power() (*Node, error) {
+	base, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+	if !p.is("^") {
+		return base, nil
+	}
+
+	// NOTE: the exponent is parsed as a unary, which makes ^ right associative and allows 2^-1
+	operator := p.take()
+	exponent, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return &Node{Kind: BinaryNode, Value: operator.text, Position: operator.position, Args: []*Node{base, exponent}}, nil
+}
+
+func (p *parser) postfix() (*Node, error) {
+	operand, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("!") {
+		operator := p.take()
+		operand = &Node{Kind: PostfixNode, Value: operator.text, Position: operator.position, Args: []*Node{operand}}
+	}
+	return operand, nil
+}
+
+func (p *parser) primary() (*Node, error) {
+	t := p.take()
+	switch {
+	case t.kind == numberToken:
+		return &Node{Kind: NumberNode, Value: t.text, Position: t.position}, nil
+	case t.kind == nameToken && p.is("("):
+		p.take()
+		node := &Node{Kind: FunctionNode, Value: t.text, Position: t.position}
+		for {
+			arg, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			node.Args = append(node.Args, arg)
+			if !p.is(",") {
+				break
+			}
+			p.take()
+		}
+		if !p.is(")") {
+			return nil, unexpected(p.peek())
+		}
+		p.take()
+		return node, nil
+	case t.kind == nameToken:
+		return &Node{Kind: ConstantNode, Value: t.text, Position: t.position}, nil
+	case t.kind == operatorToken && t.text == "(":
+		node, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.is(")") {
+			return nil, unexpected(p.peek())
+		}
+		p.take()
+		return node, nil
+	}
+	return nil, unexpected(t)
+}
+
+// Parse returns the parse tree of the expression
+func Parse(expr string) (*Node, error) {
+	if len(expr) > MaxExpressionLength {
+		return nil, exprError(MaxExpressionLength+1, "expression longer than %d characters", MaxExpressionLength)
+	}
+
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	node, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != endToken {
+		return nil, unexpected(t)
+	}
+	return node, nil
+}
+
+// Eval evaluates the expression with digits significant digits, returning its parse tree along
+func Eval(expr string, digits int) (string, *Node, error) {
+	if digits < 1 || digits > MaxEvalDigits {
+		return "", nil, ErrDigitsOutOfRange
+	}
+
+	node, err := Parse(expr)
+	if err != nil {
+		return "", nil, err
+	}
+
+	ev := evaluator{prec: uint(math.Ceil(float64(digits)*math.Log2(10))) + guardBits}
+	value, err := ev.eval(node)
+	if err != nil {
+		return "", node, err
+	}
+
+	if value.Sign() == 0 {
+		return "0", node, nil
+	}
+	return value.Text('g', digits), node, nil
+}
+
+// function evaluates the arguments of a function node, whose number is checked beforehand
+type function struct {
+	arity    int
+	evaluate func(ev evaluator, node *Node, args []*big.Float) (*big.Float, error)
+}
+
+var functions = map[string]function{
+	"sqrt": {1, func(ev evaluator, node *Node, args []*big.Float) (*big.Float, error) {
+		if args[0].Sign() < 0 {
+			return nil, exprError(node.Position, "square root of a negative number")
+		}
+		return new(big.Float).SetPrec(ev.prec).Sqrt(args[0]), nil
+	}},
+	"ln": {1, func(ev evaluator, node *Node, args []*big.Float) (*big.Float, error) {
+		if args[0].Sign() <= 0 {
+			return nil, exprError(node.Position, "logarithm of a number which is not positive")
+		}
+		return lnFloat(args[0], ev.prec), nil
+	}},
+	"log": {1, func(ev evaluator, node *Node, args []*big.Float) (*big.Float, error) {
+		if args[0].Sign() <= 0 {
+			return nil, exprError(node.Position, "logarithm of a number which is not positive")
+		}
+		value := lnFloat(args[0], ev.prec+guardBits)
+		return value.Quo(value, lnFloat(new(big.Float).SetInt64(10), ev.prec+guardBits)).SetPrec(ev.prec), nil
+	}},
+	"exp": {1, func(ev evaluator, node *Node, args []*big.Float) (*big.Float, error) {
+		return expFloat(args[0], ev.prec), nil
+	}},
+	"sin": {1, func(ev evaluator, node *Node, args []*big.Float) (*big.Float, error) {
+		sin, _, ok := sinCosFloat(args[0], ev.prec)
+		if !ok {
+			return nil, exprError(node.Position, "argument too large")
+		}
+		return sin, nil
+	}},
+	"cos": {1, func(ev evaluator, node *Node, args []*big.Float) (*big.Float, error) {
+		_, cos, ok := sinCosFloat(args[0], ev.prec)
+		if !ok {
+			return nil, exprError(node.Position, "argument too large")
+		}
+		return cos, nil
+	}},
+	"tan": {1, func(ev evaluator, node *Node, args []*big.Float) (*big.Float, error) {
+		sin, cos, ok := sinCosFloat(args[0], ev.prec+guardBits)
+		if !ok {
+			return nil, exprError(node.Position, "argument too large")
+		}
+		return sin.Quo(sin, cos).SetPrec(ev.prec), nil
+	}},
+	"abs": {1, func(ev evaluator, node *Node, args []*big.Float) (*big.Float, error) {
+		return new(big.Float).SetPrec(ev.prec).Abs(args[0]), nil
+	}},
+	"pow": {2, func(ev evaluator, node *Node, args []*big.Float) (*big.Float, error) {
+		return ev.pow(node, args[0], args[1])
+	}},
+	"factorial": {1, func(ev evaluator, node *Node, args []*big.Float) (*big.Float, error) {
+		return ev.factorial(node, args[0])
+	}},
+}
+
+// evaluator computes the nodes with prec bits
+type evaluator struct {
+	prec uint
+}
+
+func (ev evaluator) eval(node *Node) (*big.Float, error) {
+	var args []*big.Float
+	for _, arg := range node.Args {
+		value, err := ev.eval(arg)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+
+	value, err := ev.apply(node, args)
+	if err != nil {
+		return nil, err
+	}
+	if outOfRange(value) {
+		return nil, exprError(node.Position, "result out of range")
+	}
+	return value, nil
+}
+
+// outOfRange tells whether the value is infinite, or its binary exponent beyond maxExponent
+func outOfRange(x *big.Float) bool {
+	exponent := x.MantExp(nil)
+	return x.IsInf() || exponent > maxExponent || exponent < -maxExponent
+}
+
+func (ev evaluator) apply(node *Node, args []*big.Float) (*big.Float, error) {
+	switch node.Kind {
+	case NumberNode:
+		value, _, err := big.ParseFloat(node.Value, 10, ev.prec, big.ToNearestEven)
+		if err != nil {
+			return nil, exprError(node.Position, "invalid number %q", node.Value)
+		}
+		return value, nil
+	case ConstantNode:
+		if _, ok := functions[node.Value]; ok {
+			return nil, exprError(node.Position, "function %s needs arguments", node.Value)
+		}
+		c, err := lookup(node.Value)
+		if err != nil {
+			return nil, exprError(node.Position, "unknown constant %s", node.Value)
+		}
+		return c.float(ev.prec), nil
+	case FunctionNode:
+		f, ok := functions[node.Value]
+		if !ok {
+			return nil, exprError(node.Position, "unknown function %s", node.Value)
+		}
+		if len(args) != f.arity {
+			return nil, exprError(node.Position, "%s takes %d arguments", node.Value, f.arity)
+		}
+		return f.evaluate(ev, node, args)
+	case UnaryNode:
+		value := new(big.Float).SetPrec(ev.prec).Set(args[0])
+		if node.Value == "-" {
+			value.Neg(value)
+		}
+		return value, nil
+	case PostfixNode:
+		return ev.factorial(node, args[0])
+	}
+
+	value := new(big.Float).SetPrec(ev.prec)
+	switch node.Value {
+	case "+":
+		return value.Add(args[0], args[1]), nil
+	case "-":
+		return value.Sub(args[0], args[1]), nil
+	case "*":
+		return value.Mul(args[0], args[1]), nil
+	case "/":
+		if args[1].Sign() == 0 {
+			return nil, exprError(node.Position, "division by zero")
+		}
+		return value.Quo(args[0], args[1]), nil
+	}
+	return ev.pow(node, args[0], args[1])
+}
+
+// pow computes x^y by squaring when y is an integer of at most maxPowBits bits, as exp(y ln(x)) otherwise
+func (ev evaluator) pow(node *Node, x *big.Float, y *big.Float) (*big.Float, error) {
+	if y.IsInt() {
+		n, _ := y.Int(nil)
+		if n.Sign() == 0 {
+			return new(big.Float).SetPrec(ev.prec).SetInt64(1), nil
+		}
+		if n.BitLen() > maxPowBits && x.Sign() < 0 {
+			value, err := ev.pow(node, new(big.Float).Neg(x), y)
+			if err == nil && n.Bit(0) == 1 {
+				value.Neg(value)
+			}
+			return value, err
+		}
+		if n.BitLen() <= maxPowBits && (n.Sign() > 0 || x.Sign() != 0) {
+			value := powInt(x, new(big.Int).Abs(n), ev.prec+guardBits)
+			if n.Sign() < 0 && !value.IsInf() {
+				value.Quo(new(big.Float).SetInt64(1), value)
+			}
+			return value.SetPrec(ev.prec), nil
+		}
+	}
+
+	switch {
+	case x.Sign() < 0:
+		return nil, exprError(node.Position, "negative number to a fractional power")
+	case x.Sign() == 0 && y.Sign() < 0:
+		return nil, exprError(node.Position, "division by zero")
+	case x.Sign() == 0:
+		return new(big.Float).SetPrec(ev.prec), nil
+	case x.Cmp(new(big.Float).SetInt64(1)) == 0:
+		return new(big.Float).SetPrec(ev.prec).SetInt64(1), nil
+	}
+
+	// NOTE: x being neither 0 nor 1, |log2(x)| is at least 2^-MinPrec(x), so that |y log2(x)| is beyond maxExponent
+	// when the exponent of y is beyond MinPrec(x) + log2(maxExponent) + 1
+	if y.MantExp(nil) > int(x.MinPrec())+18 {
+		return nil, exprError(node.Position, "result out of range")
+	}
+
+	// NOTE: the error on y ln(x) is the relative error on the result, so ln(x) needs the bits of the integer part of y ln(x)
+	exponent := new(big.Float).SetInt64(int64(x.MantExp(nil)))
+	extra := uint(max(y.MantExp(nil), 0) + max(exponent.MantExp(nil), 0) + 1)
+	value := lnFloat(x, ev.prec+extra+guardBits)
+	value.Mul(value, y)
+	value = expFloat(value, ev.prec+guardBits)
+	if value.Sign() == 0 {
+		// NOTE: x^y being positive, 0 is an underflow
+		return nil, exprError(node.Position, "result out of range")
+	}
+	return value.SetPrec(ev.prec), nil
+}
+
+// factorial computes n! for the integers n between 0 and MaxFactorial
+func (ev evaluator) factorial(node *Node, x *big.Float) (*big.Float, error) {
+	if !x.IsInt() || x.Sign() < 0 {
+		return nil, exprError(node.Position, "factorial of a number which is not a natural number")
+	}
+	n, _ := x.Int64()
+	if x.Cmp(new(big.Float).SetInt64(MaxFactorial)) > 0 {
+		return nil, exprError(node.Position, "factorial of a number over %d", MaxFactorial)
+	}
+
+	return new(big.Float).SetPrec(ev.prec).SetInt(new(big.Int).MulRange(1, n)), nil
+}
@@ -0,0 +1,128 @@
+package math
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Eval(t *testing.T) {
+	tt := map[string]struct {
+		expr     string
+		digits   int
+		expected string
+	}{
+		"integer":           {expr: "1 + 2 * 3", digits: 10, expected: "7"},
+		"parentheses":       {expr: "(1 + 2) * 3", digits: 10, expected: "9"},
+		"left associative":  {expr: "10 - 4 - 3", digits: 10, expected: "3"},
+		"division":          {expr: "1 / 3", digits: 20, expected: "0.33333333333333333333"},
+		"decimals":          {expr: "0.1 + 0.2", digits: 30, expected: "0.3"},
+		"exponent notation": {expr: "1.5e3 + 2E-1", digits: 10, expected: "1500.2"},
+		"power":             {expr: "2^10", digits: 10, expected: "1024"},
+		"right associative": {expr: "2^3^2", digits: 10, expected: "512"},
+		"negative power":    {expr: "2^-2", digits: 10, expected: "0.25"},
+		"unary precedence":  {expr: "-2^2", digits: 10, expected: "-4"},
+		"large power":       {expr: "2^200", digits: 20, expected: "1.6069380442589902755e+60"},
+		"fractional power":  {expr: "2^0.5", digits: 30, expected: "1.41421356237309504880168872421"},
+		"power of one":      {expr: "1^(2^100)", digits: 10, expected: "1"},
+		"odd power":         {expr: "(-1)^(2^100+1)", digits: 40, expected: "-1"},
+		"huge power":        {expr: "(1 + 2^-80)^(2^80)", digits: 20, expected: "2.7182818284590452354"},
+		"factorial range":   {expr: "1 / 10000! * 10000!", digits: 10, expected: "1"},
+		"pow":               {expr: "pow(27, 1/3)", digits: 20, expected: "3"},
+		"factorial":         {expr: "20!", digits: 30, expected: "2432902008176640000"},
+		"factorial power":   {expr: "2^3!", digits: 10, expected: "64"},
+		"factorial call":    {expr: "factorial(5)", digits: 10, expected: "120"},
+		"pi":                {expr: "pi", digits: 50, expected: "3.1415926535897932384626433832795028841971693993751"},
+		"tau":               {expr: "tau / 2 - pi", digits: 50, expected: "0"},
+		"e":                 {expr: "e", digits: 30, expected: "2.71828182845904523536028747135"},
+		"other constants":   {expr: "phi^2 - phi - 1", digits: 50, expected: "0"},
+		"sqrt":              {expr: "sqrt(2)", digits: 50, expected: "1.4142135623730950488016887242096980785696718753769"},
+		"exp":               {expr: "exp(1)", digits: 50, expected: "2.7182818284590452353602874713526624977572470937"},
+		"exp negative":      {expr: "exp(-10)", digits: 20, expected: "4.5399929762484851536e-05"},
+		"exp large":         {expr: "exp(100)", digits: 20, expected: "2.6881171418161354484e+43"},
+		"ln":                {expr: "ln(10)", digits: 50, expected: "2.3025850929940456840179914546843642076011014886288"},
+		"ln one":            {expr: "ln(1)", digits: 50, expected: "0"},
+		"ln exp":            {expr: "ln(exp(5))", digits: 40, expected: "5"},
+		"log":               {expr: "log(1000)", digits: 40, expected: "3"},
+		"sin":               {expr: "sin(1)", digits: 50, expected: "0.84147098480789650665250232163029899962256306079837"},
+		"cos":               {expr: "cos(pi / 3)", digits: 40, expected: "0.5"},
+		"tan":               {expr: "tan(pi / 4)", digits: 40, expected: "1"},
+		"sin large":         {expr: "sin(1e20)", digits: 20, expected: "-0.64525128526578084421"},
+		"abs":               {expr: "abs(-3.5)", digits: 10, expected: "3.5"},
+		"spaces":            {expr: " ( 1+2 ) *\t3 ", digits: 10, expected: "9"},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			value, _, err := Eval(tc.expr, tc.digits)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, value)
+		})
+	}
+}
+
+func Test_EvalErrors(t *testing.T) {
+	tt := map[string]struct {
+		expr     string
+		expected string
+	}{
+		"character":           {expr: "1 + #", expected: "unexpected character '#' at position 5"},
+		"missing operand":     {expr: "1 +", expected: "unexpected end of expression at position 4"},
+		"missing parenthesis": {expr: "(1 + 2", expected: "unexpected end of expression at position 7"},
+		"extra parenthesis":   {expr: "1 + 2)", expected: "unexpected \")\" at position 6"},
+		"two numbers":         {expr: "1 2", expected: "unexpected \"2\" at position 3"},
+		"invalid number":      {expr: "1.2.3", expected: "invalid number \"1.2.3\" at position 1"},
+		"unknown constant":    {expr: "2 * x", expected: "unknown constant x at position 5"},
+		"unknown function":    {expr: "foo(1)", expected: "unknown function foo at position 1"},
+		"function alone":      {expr: "sqrt + 1", expected: "function sqrt needs arguments at position 1"},
+		"arguments":           {expr: "pow(2)", expected: "pow takes 2 arguments at position 1"},
+		"division by zero":    {expr: "1 / (2 - 2)", expected: "division by zero at position 3"},
+		"negative sqrt":       {expr: "sqrt(-1)", expected: "square root of a negative number at position 1"},
+		"negative ln":         {expr: "1 + ln(0)", expected: "logarithm of a number which is not positive at position 5"},
+		"negative base":       {expr: "(-8)^(1/3)", expected: "negative number to a fractional power at position 5"},
+		"factorial fraction":  {expr: "2.5!", expected: "factorial of a number which is not a natural number at position 4"},
+		"factorial too large": {expr: "100000!", expected: "factorial of a number over 10000 at position 7"},
+		"overflow":            {expr: "exp(1e20)", expected: "result out of range at position 1"},
+		"large number":        {expr: "1 + 1e600000000", expected: "result out of range at position 5"},
+		"tiny number":         {expr: "1e-600000000^0.5", expected: "result out of range at position 1"},
+		"tiny power":          {expr: "2^-1e9", expected: "result out of range at position 2"},
+		"large power of one":  {expr: "1^1e600000000", expected: "result out of range at position 3"},
+		"large power":         {expr: "1.5^1e30", expected: "result out of range at position 4"},
+		"tiny power of half":  {expr: "(-0.5)^(2^70+1)", expected: "result out of range at position 7"},
+		"underflow":           {expr: "0.9999999^(2^83)", expected: "result out of range at position 10"},
+		"large product":       {expr: "1e30000 * 1e30000", expected: "result out of range at position 9"},
+		"too deep":            {expr: "((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((1))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))", expected: "expression nested too deeply at position 101"},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := Eval(tc.expr, 20)
+
+			assert.EqualError(t, err, tc.expected)
+		})
+	}
+}
+
+func Test_Parse(t *testing.T) {
+	node, err := Parse("-2 * sqrt(x)!")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &Node{Kind: BinaryNode, Value: "*", Position: 4, Args: []*Node{
+		{Kind: UnaryNode, Value: "-", Position: 1, Args: []*Node{{Kind: NumberNode, Value: "2", Position: 2}}},
+		{Kind: PostfixNode, Value: "!", Position: 13, Args: []*Node{
+			{Kind: FunctionNode, Value: "sqrt", Position: 6, Args: []*Node{{Kind: ConstantNode, Value: "x", Position: 11}}},
+		}},
+	}}, node)
+}
+
+func Test_EvalDigits(t *testing.T) {
+	_, _, err := Eval("1", MaxEvalDigits+1)
+	assert.ErrorIs(t, err, ErrDigitsOutOfRange)
+
+	// NOTE: the value is rounded to the digits, the last one being 9 instead of the 8 of the truncated decimals
+	value, _, err := Eval("pi", MaxEvalDigits)
+	assert.NoError(t, err)
+	_, expected, _ := Constant("pi", MaxEvalDigits)
+	assert.Equal(t, expected[:MaxEvalDigits]+"9", value)
+}
@@ -1,78 +1,69 @@
 package math
 
 import (
-	"bufio"
-	"fmt"
-	"math"
-	"math/big"
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
-	"strings"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_CalculatePi(t *testing.T) {
+	// NOTE: SHA-256 checksums of "3." or "6." followed by the digits, as found in the assets
 	tt := map[string]struct {
-		value    *big.Float
-		expected string
+		id       string
+		digits   int
+		checksum string
 	}{
-		"pi": {
-			value:    Chudnovsky(10000),
-			expected: fmt.Sprint(math.Pi),
+		"pi 1000": {
+			id:       "pi",
+			digits:   1000,
+			checksum: "823a2e34f63c5d5f30a27733976df5a1ab57feaab505f40d95d3dd3fefa425cc",
 		},
-		"tau": {
-			value:    ChudnovskyTau(10000),
-			expected: fmt.Sprint(math.Pi * 2.0),
+		"pi 10000": {
+			id:       "pi",
+			digits:   10000,
+			checksum: "452304d0e15d9e9fd9b63024212bb571de54b9b9f0aa050481f90530ef0b5c5d",
+		},
+		"tau 10000": {
+			id:       "tau",
+			digits:   10000,
+			checksum: "46f453e6c8dae095ee12e30c0851c6e392df282a565ba963f055fc3afe50e1db",
 		},
 	}
 
 	for name, tc := range tt {
 		t.Run(name, func(t *testing.T) {
-			strVal := fmt.Sprintf("%.1000000f", tc.value)
-
-			f, err := os.Create("/tmp/" + name)
-			assert.NoError(t, err)
-
-			defer f.Close()
-
-			w := bufio.NewWriter(f)
-			_, err = w.WriteString(strVal)
+			_, value, err := Constant(tc.id, tc.digits)
 			assert.NoError(t, err)
 
-			assert.True(t, strings.HasPrefix(strVal, tc.expected))
+			sum := sha256.Sum256([]byte(value))
+			assert.Equal(t, tc.checksum, hex.EncodeToString(sum[:]))
 		})
 	}
 }
 
-func Benchmark_CalculatePi(b *testing.B) {
-	tt := map[string]struct {
-		value int
-	}{
-		"10": {
-			value: 10,
-		},
-		"100": {
-			value: 100,
-		},
-		"1000": {
-			value: 1000,
-		},
-		"10000": {
-			value: 10000,
-		},
-		"100000": {
-			value: 100000,
-		},
-		"1000000": {
-			value: 1000000,
-		},
+func Test_CalculatePiAssets(t *testing.T) {
+	if testing.Short() {
+		t.Skip("computing 100K digits")
 	}
 
-	for name, tc := range tt {
-		b.Run(name, func(b *testing.B) {
+	for _, name := range []string{"pi", "tau"} {
+		expected, err := os.ReadFile("../../../assets/" + name)
+		assert.NoError(t, err)
+
+		value := formatDigits(constants[name].compute(100000), 100000, 10)
+		assert.Equal(t, string(expected[:100002]), value)
+	}
+}
+
+func Benchmark_CalculatePi(b *testing.B) {
+	for _, digits := range []int{10, 100, 1000, 10000, 100000, 1000000} {
+		b.Run(strconv.Itoa(digits), func(b *testing.B) {
 			for i := 0; i < b.N; i++ {
-				Chudnovsky(tc.value)
+				pi(digits)
 			}
 		})
 	}
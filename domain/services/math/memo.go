@@ -0,0 +1,29 @@
+package math
+
+import (
+	"math/big"
+	"sync"
+)
+
+// memo keeps the most precise value of a constant computed so far, floor(C * 10^digits),
+// the less precise values being derived from it by an exact division
+type memo struct {
+	mu     sync.Mutex
+	digits int
+	value  *big.Int
+}
+
+// get returns floor(C * 10^digits), computing at least twice the digits of the previous computation
+// up to limit so that asking for more and more digits does not compute them every time
+func (m *memo) get(digits int, limit int, compute func(digits int) *big.Int) *big.Int {
+	// NOTE: the concurrent requests wait for the computation instead of repeating it
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.value == nil || m.digits < digits {
+		m.digits = max(digits, min(2*m.digits, limit))
+		m.value = compute(m.digits)
+	}
+
+	return new(big.Int).Quo(m.value, pow10(m.digits-digits))
+}
@@ -0,0 +1,301 @@
+package math
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxIntegerDigits bounds the digits of the integers of the number theory functions
+const MaxIntegerDigits = 1000
+
+// millerRabinRounds are the Miller-Rabin rounds with random bases done besides the Baillie-PSW test
+const millerRabinRounds = 20
+
+// trialDivisionBound bounds the primes the factorization first divides by
+const trialDivisionBound = 10000
+
+var (
+	ErrInvalidInteger  = errors.New("invalid integer")
+	ErrNotPositive     = errors.New("not strictly positive")
+	ErrNotInvertible   = errors.New("not invertible")
+	ErrInvalidModulus  = errors.New("modulus must be strictly positive")
+	ErrNoPreviousPrime = errors.New("no previous prime")
+	ErrOutOfTime       = errors.New("time budget ran out")
+)
+
+var (
+	one = big.NewInt(1)
+	two = big.NewInt(2)
+)
+
+// ParseInteger reads a decimal integer of at most MaxIntegerDigits digits, with an optional sign
+func ParseInteger(s string) (*big.Int, error) {
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	if digits == "" || len(digits) > MaxIntegerDigits || strings.Trim(digits, "0123456789") != "" {
+		return nil, ErrInvalidInteger
+	}
+
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, ErrInvalidInteger
+	}
+	return n, nil
+}
+
+// IsPrime tells whether n is prime with Miller-Rabin and Baillie-PSW tests, which never fail below 2^64
+// and have no known counterexample above
+func IsPrime(n *big.Int) bool {
+	return n.ProbablyPrime(millerRabinRounds)
+}
+
+// NextPrime returns the smallest prime strictly greater than n, or ErrOutOfTime when the deadline is passed before
+// it is found, the gaps between the large primes being hundreds of candidates
+func NextPrime(n *big.Int, deadline time.Time) (*big.Int, error) {
+	if n.Cmp(two) < 0 {
+		return big.NewInt(2), nil
+	}
+
+	// NOTE: the candidates are the odd numbers after n
+	candidate := new(big.Int).Add(n, one)
+	if candidate.Bit(0) == 0 {
+		candidate.Add(candidate, one)
+	}
+	for !IsPrime(candidate) {
+		if time.Now().After(deadline) {
+			return nil, ErrOutOfTime
+		}
+		candidate.Add(candidate, two)
+	}
+	return candidate, nil
+}
+
+// PreviousPrime returns the greatest prime strictly less than n, or ErrOutOfTime when the deadline is passed before
+// it is found
+func PreviousPrime(n *big.Int, deadline time.Time) (*big.Int, error) {
+	if n.Cmp(big.NewInt(3)) < 0 {
+		return nil, ErrNoPreviousPrime
+	}
+	if n.Cmp(big.NewInt(3)) == 0 {
+		return big.NewInt(2), nil
+	}
+
+	candidate := new(big.Int).Sub(n, one)
+	if candidate.Bit(0) == 0 {
+		candidate.Sub(candidate, one)
+	}
+	for !IsPrime(candidate) {
+		if time.Now().After(deadline) {
+			return nil, ErrOutOfTime
+		}
+		candidate.Sub(candidate, two)
+	}
+	return candidate, nil
+}
+
+// Factor is a factor of a factorization with its exponent, which is not prime when the time budget ran out before it was split
+type Factor struct {
+	Value    *big.Int
+	Exponent int
+	Prime    bool
+}
+
+var smallPrimes = func() []int64 {
+	composite := make([]bool, trialDivisionBound)
+	var primes []int64
+	for i := 2; i < trialDivisionBound; i++ {
+		if composite[i] {
+			continue
+		}
+		primes = append(primes, int64(i))
+		for j := i * i; j < trialDivisionBound; j += i {
+			composite[j] = true
+		}
+	}
+	return primes
+}()
+
+// Factorize decomposes n > 0 in prime factors by trial division then Pollard's rho, sorted by value.
+// It returns false with the factors left composite when the budget runs out.
+func Factorize(n *big.Int, budget time.Duration) ([]Factor, bool, error) {
+	if n.Sign() <= 0 {
+		return nil, false, ErrNotPositive
+	}
+	deadline := time.Now().Add(budget)
+
+	var factors []Factor
+	rest := new(big.Int).Set(n)
+	quotient, remainder := new(big.Int), new(big.Int)
+	for _, p := range smallPrimes {
+		prime := big.NewInt(p)
+		if new(big.Int).Mul(prime, prime).Cmp(rest) > 0 {
+			break
+		}
+		for {
+			quotient.QuoRem(rest, prime, remainder)
+			if remainder.Sign() != 0 {
+				break
+			}
+			factors = append(factors, Factor{Value: prime, Exponent: 1, Prime: true})
+			rest.Set(quotient)
+		}
+	}
+
+	complete := true
+	composites := []*big.Int{rest}
+	for len(composites) > 0 {
+		c := composites[len(composites)-1]
+		composites = composites[:len(composites)-1]
+
+		switch {
+		case c.Cmp(one) == 0:
+		case IsPrime(c):
+			factors = append(factors, Factor{Value: c, Exponent: 1, Prime: true})
+		default:
+			d := rho(c, deadline)
+			if d == nil {
+				factors = append(factors, Factor{Value: c, Exponent: 1})
+				complete = false
+				continue
+			}
+			composites = append(composites, d, new(big.Int).Quo(c, d))
+		}
+	}
+
+	return mergeFactors(factors), complete, nil
+}
+
+// mergeFactors sorts the factors and adds up the exponents of the equal ones
+func mergeFactors(factors []Factor) []Factor {
+	slices.SortFunc(factors, func(a, b Factor) int {
+		return a.Value.Cmp(b.Value)
+	})
+
+	merged := []Factor{}
+	for _, factor := range factors {
+		if last := len(merged) - 1; last >= 0 && merged[last].Value.Cmp(factor.Value) == 0 {
+			merged[last].Exponent += factor.Exponent
+			continue
+		}
+		merged = append(merged, factor)
+	}
+	return merged
+}
+
+// rho finds a non trivial divisor of the composite n with Pollard's rho, or returns nil when the deadline is passed
+func rho(n *big.Int, deadline time.Time) *big.Int {
+	if n.Bit(0) == 0 {
+		return big.NewInt(2)
+	}
+	for c := int64(1); time.Now().Before(deadline); c++ {
+		if d := brent(n, big.NewInt(c), deadline); d != nil && d.Cmp(n) != 0 {
+			return d
+		}
+	}
+	return nil
+}
+
+// brent runs the cycle detection of Brent on x ↦ x² + c mod n, computing the gcd once every batch of steps.
+// It returns n when the cycle closes without a divisor, and nil when the deadline is passed.
+func brent(n *big.Int, c *big.Int, deadline time.Time) *big.Int {
+	const batch = 128
+
+	next := func(z *big.Int) {
+		z.Mul(z, z)
+		z.Add(z, c)
+		z.Mod(z, n)
+	}
+
+	x, y, ys := new(big.Int), big.NewInt(2), new(big.Int)
+	q, g, diff := big.NewInt(1), big.NewInt(1), new(big.Int)
+	for r := 1; g.Cmp(one) == 0; r *= 2 {
+		x.Set(y)
+		for i := 0; i < r; i++ {
+			if i%batch == 0 && time.Now().After(deadline) {
+				return nil
+			}
+			next(y)
+		}
+		for k := 0; k < r && g.Cmp(one) == 0; k += batch {
+			if time.Now().After(deadline) {
+				return nil
+			}
+			ys.Set(y)
+			for i := 0; i < min(batch, r-k); i++ {
+				next(y)
+				q.Mul(q, diff.Abs(diff.Sub(x, y)))
+				q.Mod(q, n)
+			}
+			g.GCD(nil, nil, q, n)
+		}
+	}
+
+	// NOTE: the batch went past the divisor, which is found again step by step
+	if g.Cmp(n) == 0 {
+		for {
+			next(ys)
+			g.GCD(nil, nil, diff.Abs(diff.Sub(x, ys)), n)
+			if g.Cmp(one) != 0 {
+				break
+			}
+		}
+	}
+	return g
+}
+
+// GCD returns the greatest common divisor of the numbers, positive
+func GCD(numbers ...*big.Int) *big.Int {
+	gcd := new(big.Int)
+	for _, n := range numbers {
+		gcd.GCD(nil, nil, gcd, new(big.Int).Abs(n))
+	}
+	return gcd
+}
+
+// LCM returns the least common multiple of the numbers, positive, zero when one of them is
+func LCM(numbers ...*big.Int) *big.Int {
+	lcm := big.NewInt(1)
+	for _, n := range numbers {
+		if n.Sign() == 0 {
+			return new(big.Int)
+		}
+		gcd := new(big.Int).GCD(nil, nil, lcm, new(big.Int).Abs(n))
+		lcm.Mul(lcm, new(big.Int).Quo(new(big.Int).Abs(n), gcd))
+	}
+	return lcm
+}
+
+// ModInverse returns x between 0 and m - 1 such that a x ≡ 1 mod m
+func ModInverse(a *big.Int, m *big.Int) (*big.Int, error) {
+	if m.Sign() <= 0 {
+		return nil, ErrInvalidModulus
+	}
+	if m.Cmp(one) == 0 {
+		return new(big.Int), nil
+	}
+
+	inverse := new(big.Int).ModInverse(new(big.Int).Mod(a, m), m)
+	if inverse == nil {
+		return nil, ErrNotInvertible
+	}
+	return inverse, nil
+}
+
+// ModPow returns b^e mod m between 0 and m - 1, a negative exponent being the one of the inverse of b
+func ModPow(b *big.Int, e *big.Int, m *big.Int) (*big.Int, error) {
+	if m.Sign() <= 0 {
+		return nil, ErrInvalidModulus
+	}
+
+	base := new(big.Int).Mod(b, m)
+	if e.Sign() < 0 {
+		inverse, err := ModInverse(base, m)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).Exp(inverse, new(big.Int).Neg(e), m), nil
+	}
+	return new(big.Int).Exp(base, e, m), nil
+}
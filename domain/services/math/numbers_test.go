@@ -0,0 +1,190 @@
+package math
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func integer(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+func Test_ParseInteger(t *testing.T) {
+	n, err := ParseInteger("-123456789012345678901234567890")
+	assert.NoError(t, err)
+	assert.Equal(t, "-123456789012345678901234567890", n.String())
+
+	for _, s := range []string{"", "-", "12a", "1e5", "0x10", " 1", string(make([]byte, MaxIntegerDigits+1))} {
+		_, err := ParseInteger(s)
+		assert.ErrorIs(t, err, ErrInvalidInteger, s)
+	}
+}
+
+func Test_IsPrime(t *testing.T) {
+	tt := map[string]struct {
+		n        string
+		expected bool
+	}{
+		"zero":                      {n: "0", expected: false},
+		"one":                       {n: "1", expected: false},
+		"two":                       {n: "2", expected: true},
+		"Carmichael":                {n: "561", expected: false},
+		"strong pseudoprime base 2": {n: "2047", expected: false},
+		"Mersenne 61":               {n: "2305843009213693951", expected: true},
+		"Mersenne 89":               {n: "618970019642690137449562111", expected: true},
+		"Fermat 5":                  {n: "4294967297", expected: false},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, IsPrime(integer(tc.n)))
+		})
+	}
+}
+
+func Test_NextPreviousPrime(t *testing.T) {
+	tt := map[string]struct {
+		n                string
+		expectedNext     string
+		expectedPrevious string
+	}{
+		"negative":    {n: "-5", expectedNext: "2"},
+		"two":         {n: "2", expectedNext: "3"},
+		"three":       {n: "3", expectedNext: "5", expectedPrevious: "2"},
+		"prime":       {n: "13", expectedNext: "17", expectedPrevious: "11"},
+		"gap":         {n: "1000", expectedNext: "1009", expectedPrevious: "997"},
+		"large":       {n: "1000000000000", expectedNext: "1000000000039", expectedPrevious: "999999999989"},
+		"Mersenne 61": {n: "2305843009213693951", expectedNext: "2305843009213693967", expectedPrevious: "2305843009213693921"},
+	}
+
+	deadline := time.Now().Add(time.Minute)
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			next, err := NextPrime(integer(tc.n), deadline)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedNext, next.String())
+
+			previous, err := PreviousPrime(integer(tc.n), deadline)
+			if tc.expectedPrevious == "" {
+				assert.ErrorIs(t, err, ErrNoPreviousPrime)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedPrevious, previous.String())
+		})
+	}
+
+	t.Run("out of time", func(t *testing.T) {
+		// NOTE: 10^999 being far from any prime, the past deadline is checked before one is found
+		n := new(big.Int).Exp(big.NewInt(10), big.NewInt(999), nil)
+
+		_, err := NextPrime(n, time.Now())
+		assert.ErrorIs(t, err, ErrOutOfTime)
+
+		_, err = PreviousPrime(n, time.Now())
+		assert.ErrorIs(t, err, ErrOutOfTime)
+	})
+}
+
+func Test_Factorize(t *testing.T) {
+	tt := map[string]struct {
+		n        string
+		expected []Factor
+	}{
+		"one":   {n: "1", expected: []Factor{}},
+		"prime": {n: "97", expected: []Factor{{Value: big.NewInt(97), Exponent: 1, Prime: true}}},
+		"powers": {n: "360", expected: []Factor{
+			{Value: big.NewInt(2), Exponent: 3, Prime: true},
+			{Value: big.NewInt(3), Exponent: 2, Prime: true},
+			{Value: big.NewInt(5), Exponent: 1, Prime: true},
+		}},
+		"Project Euler 3": {n: "600851475143", expected: []Factor{
+			{Value: big.NewInt(71), Exponent: 1, Prime: true},
+			{Value: big.NewInt(839), Exponent: 1, Prime: true},
+			{Value: big.NewInt(1471), Exponent: 1, Prime: true},
+			{Value: big.NewInt(6857), Exponent: 1, Prime: true},
+		}},
+		"Fermat 6": {n: "18446744073709551617", expected: []Factor{
+			{Value: big.NewInt(274177), Exponent: 1, Prime: true},
+			{Value: big.NewInt(67280421310721), Exponent: 1, Prime: true},
+		}},
+		"square of a large prime": {n: "1000000014000000049", expected: []Factor{
+			{Value: big.NewInt(1000000007), Exponent: 2, Prime: true},
+		}},
+		"semiprime": {n: "1000000016000000063", expected: []Factor{
+			{Value: big.NewInt(1000000007), Exponent: 1, Prime: true},
+			{Value: big.NewInt(1000000009), Exponent: 1, Prime: true},
+		}},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			factors, complete, err := Factorize(integer(tc.n), 5*time.Second)
+
+			assert.NoError(t, err)
+			assert.True(t, complete)
+			assert.Equal(t, tc.expected, factors)
+		})
+	}
+}
+
+func Test_FactorizeBudget(t *testing.T) {
+	// NOTE: the product of two 30 digits primes is far beyond what Pollard's rho splits in a millisecond
+	p, err := NextPrime(integer("100000000000000000000000000000"), time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	q, err := NextPrime(integer("200000000000000000000000000000"), time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	n := new(big.Int).Mul(p, q)
+	n.Mul(n, big.NewInt(12))
+
+	factors, complete, err := Factorize(n, time.Millisecond)
+
+	assert.NoError(t, err)
+	assert.False(t, complete)
+	assert.Equal(t, []Factor{
+		{Value: big.NewInt(2), Exponent: 2, Prime: true},
+		{Value: big.NewInt(3), Exponent: 1, Prime: true},
+		{Value: new(big.Int).Quo(n, big.NewInt(12)), Exponent: 1, Prime: false},
+	}, factors)
+
+	_, _, err = Factorize(big.NewInt(0), time.Second)
+	assert.ErrorIs(t, err, ErrNotPositive)
+}
+
+func Test_GCDLCM(t *testing.T) {
+	assert.Equal(t, "6", GCD(big.NewInt(12), big.NewInt(-18), big.NewInt(30)).String())
+	assert.Equal(t, "5", GCD(big.NewInt(0), big.NewInt(5)).String())
+	assert.Equal(t, "180", LCM(big.NewInt(12), big.NewInt(-18), big.NewInt(30)).String())
+	assert.Equal(t, "0", LCM(big.NewInt(12), big.NewInt(0)).String())
+}
+
+func Test_ModInversePow(t *testing.T) {
+	inverse, err := ModInverse(big.NewInt(3), big.NewInt(11))
+	assert.NoError(t, err)
+	assert.Equal(t, "4", inverse.String())
+
+	inverse, err = ModInverse(big.NewInt(-3), big.NewInt(11))
+	assert.NoError(t, err)
+	assert.Equal(t, "7", inverse.String())
+
+	_, err = ModInverse(big.NewInt(2), big.NewInt(4))
+	assert.ErrorIs(t, err, ErrNotInvertible)
+
+	_, err = ModInverse(big.NewInt(2), big.NewInt(0))
+	assert.ErrorIs(t, err, ErrInvalidModulus)
+
+	power, err := ModPow(big.NewInt(2), big.NewInt(10), big.NewInt(1000))
+	assert.NoError(t, err)
+	assert.Equal(t, "24", power.String())
+
+	power, err = ModPow(big.NewInt(3), big.NewInt(-2), big.NewInt(11))
+	assert.NoError(t, err)
+	assert.Equal(t, "5", power.String())
+
+	_, err = ModPow(big.NewInt(2), big.NewInt(-1), big.NewInt(4))
+	assert.ErrorIs(t, err, ErrNotInvertible)
+}
@@ -0,0 +1,123 @@
+package math
+
+import (
+	"errors"
+	"index/suffixarray"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// MaxSearchLength bounds the digit sequences searched
+const MaxSearchLength = 100
+
+var (
+	ErrNotIndexed    = errors.New("constant not indexed")
+	ErrInvalidSearch = errors.New("invalid search")
+)
+
+// maxCachedOffsets bounds the offsets of the searches kept sorted, the oldest searches being forgotten first
+const maxCachedOffsets = 1 << 20
+
+// indexes find the digit sequences in the digits files without scanning them, by constant
+var (
+	indexesMu sync.RWMutex
+	indexes   = make(map[string]*suffixarray.Index)
+)
+
+// searches keep the sorted offsets of the last searches, the pages of a search being asked one after the other
+var (
+	searchesMu     sync.Mutex
+	searches       = make(map[string][]int)
+	searchesOrder  []string
+	searchesCached int
+)
+
+// IndexAssets builds the suffix arrays of the digits after the point of the given digits files of dir
+func IndexAssets(dir string, files ...string) error {
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return err
+		}
+
+		// NOTE: the offsets are the ones of the digits after the point, like for DigitsRange
+		if point := slices.Index(data, '.'); point >= 0 {
+			data = data[point+1:]
+		}
+		index := suffixarray.New(data)
+
+		indexesMu.Lock()
+		indexes[file] = index
+		indexesMu.Unlock()
+	}
+
+	searchesMu.Lock()
+	searches, searchesOrder, searchesCached = make(map[string][]int), nil, 0
+	searchesMu.Unlock()
+	return nil
+}
+
+// sortedOffsets returns the offsets of the digits in the index in increasing order, which must not be modified
+func sortedOffsets(id string, index *suffixarray.Index, digits string) []int {
+	key := id + ":" + digits
+
+	searchesMu.Lock()
+	offsets, ok := searches[key]
+	searchesMu.Unlock()
+	if ok {
+		return offsets
+	}
+
+	offsets = index.Lookup([]byte(digits), -1)
+	slices.Sort(offsets)
+	if len(offsets) > maxCachedOffsets {
+		return offsets
+	}
+
+	searchesMu.Lock()
+	defer searchesMu.Unlock()
+	if _, ok := searches[key]; !ok {
+		for searchesCached+len(offsets) > maxCachedOffsets {
+			searchesCached -= len(searches[searchesOrder[0]])
+			delete(searches, searchesOrder[0])
+			searchesOrder = searchesOrder[1:]
+		}
+		searches[key] = offsets
+		searchesOrder = append(searchesOrder, key)
+		searchesCached += len(offsets)
+	}
+	return offsets
+}
+
+// Search returns how many times the digits appear after the point of the constant, and the offsets of a page of
+// them in increasing order, the pages being numbered from 0
+func Search(id string, digits string, page int, pageSize int) (int, []int, error) {
+	if len(digits) == 0 || len(digits) > MaxSearchLength || page < 0 || pageSize <= 0 {
+		return 0, nil, ErrInvalidSearch
+	}
+	for _, digit := range digits {
+		if digit < '0' || digit > '9' {
+			return 0, nil, ErrInvalidSearch
+		}
+	}
+
+	indexesMu.RLock()
+	index, ok := indexes[id]
+	indexesMu.RUnlock()
+	if !ok {
+		return 0, nil, ErrNotIndexed
+	}
+
+	offsets := sortedOffsets(id, index, digits)
+
+	// NOTE: checking the page before multiplying, page * pageSize overflowing for the large ones
+	if page > (len(offsets)-1)/pageSize {
+		return len(offsets), []int{}, nil
+	}
+	start := page * pageSize
+	end := min(start+pageSize, len(offsets))
+
+	return len(offsets), append([]int{}, offsets[start:end]...), nil
+}
@@ -0,0 +1,61 @@
+package math
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Search(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "test"), []byte("3.14159265358979323846264338327950288419716939937510"), 0o644))
+	assert.NoError(t, IndexAssets(dir, "test"))
+
+	tt := map[string]struct {
+		digits          string
+		page            int
+		pageSize        int
+		expectedTotal   int
+		expectedOffsets []int
+	}{
+		"first digits": {digits: "1415", page: 0, pageSize: 10, expectedTotal: 1, expectedOffsets: []int{0}},
+		"all":          {digits: "9", page: 0, pageSize: 10, expectedTotal: 8, expectedOffsets: []int{4, 11, 13, 29, 37, 41, 43, 44}},
+		"second page":  {digits: "9", page: 1, pageSize: 3, expectedTotal: 8, expectedOffsets: []int{29, 37, 41}},
+		"after last":   {digits: "9", page: 3, pageSize: 3, expectedTotal: 8, expectedOffsets: []int{}},
+		"overflowing":  {digits: "9", page: 6917529027641081856, pageSize: 4, expectedTotal: 8, expectedOffsets: []int{}},
+		"largest size": {digits: "9", page: 1, pageSize: math.MaxInt, expectedTotal: 8, expectedOffsets: []int{}},
+		"cached":       {digits: "9", page: 2, pageSize: 3, expectedTotal: 8, expectedOffsets: []int{43, 44}},
+		"pair":         {digits: "99", page: 0, pageSize: 10, expectedTotal: 1, expectedOffsets: []int{43}},
+		"not found":    {digits: "000", page: 0, pageSize: 10, expectedTotal: 0, expectedOffsets: []int{}},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			total, offsets, err := Search("test", tc.digits, tc.page, tc.pageSize)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedTotal, total)
+			assert.ElementsMatch(t, tc.expectedOffsets, offsets)
+			assert.IsIncreasing(t, offsets)
+		})
+	}
+
+	_, _, err := Search("test", "12a", 0, 10)
+	assert.ErrorIs(t, err, ErrInvalidSearch)
+
+	_, _, err = Search("other", "12", 0, 10)
+	assert.ErrorIs(t, err, ErrNotIndexed)
+}
+
+func Test_SearchAssets(t *testing.T) {
+	assert.NoError(t, IndexAssets("../../../assets", "pi"))
+
+	// NOTE: the Feynman point
+	total, offsets, err := Search("pi", "999999", 0, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{761}, offsets)
+	assert.Greater(t, total, 1)
+}
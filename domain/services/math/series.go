@@ -0,0 +1,83 @@
+package math
+
+import (
+	"math/big"
+	"runtime"
+)
+
+// parallelTerms is the size of the ranges below which the halves are not worth a goroutine
+const parallelTerms = 256
+
+// splitters bounds the goroutines splitting the series, shared by the concurrent computations
+var splitters = make(chan struct{}, runtime.GOMAXPROCS(0))
+
+// series is a hypergeometric-like series Σ a(n)/b(n) · p(0)…p(n) / q(0)…q(n), a and b defaulting to 1,
+// summed with binary splitting so that every operation is on exact integers
+type series struct {
+	a, b, p, q func(n int64) *big.Int
+}
+
+// splitResult holds P = p(n1)…p(n2-1), Q and B likewise, and T such that the sum over [n1, n2) is T / (B·Q)
+type splitResult struct {
+	P, Q, B, T *big.Int
+}
+
+func (s series) term(f func(n int64) *big.Int, n int64) *big.Int {
+	if f == nil {
+		return big.NewInt(1)
+	}
+	return f(n)
+}
+
+func (s series) split(n1 int, n2 int) splitResult {
+	if n2-n1 == 1 {
+		n := int64(n1)
+		p := s.p(n)
+		return splitResult{
+			P: p,
+			Q: s.q(n),
+			B: s.term(s.b, n),
+			T: new(big.Int).Mul(s.term(s.a, n), p),
+		}
+	}
+
+	m := (n1 + n2) / 2
+
+	if n2-n1 >= parallelTerms {
+		select {
+		case splitters <- struct{}{}:
+			var left splitResult
+			done := make(chan struct{})
+			go func() {
+				defer func() { <-splitters }()
+				left = s.split(n1, m)
+				close(done)
+			}()
+			right := s.split(m, n2)
+			<-done
+			return left.merge(right)
+		default:
+			// NOTE: every splitter is busy, this goroutine does both halves
+		}
+	}
+
+	left := s.split(n1, m)
+	right := s.split(m, n2)
+
+	return left.merge(right)
+}
+
+func (left splitResult) merge(right splitResult) splitResult {
+	// T = B_r·Q_r·T_l + B_l·P_l·T_r
+	t := new(big.Int).Mul(right.B, right.Q)
+	t.Mul(t, left.T)
+	u := new(big.Int).Mul(left.B, left.P)
+	u.Mul(u, right.T)
+
+	return splitResult{
+		P: new(big.Int).Mul(left.P, right.P),
+		Q: new(big.Int).Mul(left.Q, right.Q),
+		B: new(big.Int).Mul(left.B, right.B),
+		T: t.Add(t, u),
+	}
+}
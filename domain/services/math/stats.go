@@ -0,0 +1,331 @@
+package math
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"math/big"
+	"slices"
+	"time"
+)
+
+const (
+	// MaxBuckets bounds the buckets of a histogram
+	MaxBuckets = 1000
+
+	// statsPrec is the precision in bits of the statistics which are not rational, like the standard deviation
+	statsPrec = 512
+)
+
+var (
+	ErrNoValues          = errors.New("no values")
+	ErrInvalidPercentile = errors.New("percentiles must be between 0 and 100")
+	ErrInvalidBuckets    = errors.New("invalid number of buckets")
+	ErrConstantX         = errors.New("regression needs at least two distinct x")
+)
+
+// DefaultPercentiles are the percentiles given when none is asked for
+var DefaultPercentiles = []*big.Rat{big.NewRat(5, 1), big.NewRat(25, 1), big.NewRat(75, 1), big.NewRat(95, 1), big.NewRat(99, 1)}
+
+// Statistics describe values exactly, the sample variance and deviation being nil for a single value
+type Statistics struct {
+	Count          int
+	Sum            *big.Rat
+	Mean           *big.Rat
+	Median         *big.Rat
+	Modes          []*big.Rat
+	Min            *big.Rat
+	Max            *big.Rat
+	Variance       *big.Rat
+	StdDev         *big.Float
+	SampleVariance *big.Rat
+	SampleStdDev   *big.Float
+	Percentiles    []Percentile
+	Histogram      []Bucket
+}
+
+type Percentile struct {
+	Rank  *big.Rat
+	Value *big.Rat
+}
+
+// Bucket counts the values from Start to End, End being excluded except for the last bucket
+type Bucket struct {
+	Start *big.Rat
+	End   *big.Rat
+	Count int
+}
+
+// Regression is the least squares line y = Slope x + Intercept, the correlation being nil when all y are equal
+type Regression struct {
+	Slope       *big.Rat
+	Intercept   *big.Rat
+	Correlation *big.Float
+}
+
+// ratSum adds up rationals by denominator, the integer additions being much cheaper than the big.Rat ones which
+// reduce each result by a gcd, and the decimal values having a few denominators
+type ratSum struct {
+	numerators   map[string]*big.Int
+	denominators map[string]*big.Int
+}
+
+func newRatSum() ratSum {
+	return ratSum{numerators: make(map[string]*big.Int), denominators: make(map[string]*big.Int)}
+}
+
+// add adds num / denom, denom being positive
+func (s ratSum) add(num *big.Int, denom *big.Int) {
+	key := string(denom.Bytes())
+	if sum, ok := s.numerators[key]; ok {
+		sum.Add(sum, num)
+		return
+	}
+	s.numerators[key] = new(big.Int).Set(num)
+	s.denominators[key] = denom
+}
+
+// rat returns the sum, or ErrOutOfTime when the deadline is passed before the sums by denominator are added up
+func (s ratSum) rat(deadline time.Time) (*big.Rat, error) {
+	sum := new(big.Rat)
+	for key, num := range s.numerators {
+		if time.Now().After(deadline) {
+			return nil, ErrOutOfTime
+		}
+		sum.Add(sum, new(big.Rat).SetFrac(num, s.denominators[key]))
+	}
+	return sum, nil
+}
+
+// compareRats is big.Rat.Cmp, comparing the signs, the magnitudes and the numerators of the same denominators first,
+// Cmp multiplying the rationals crosswise
+func compareRats(a *big.Rat, b *big.Rat) int {
+	if a.Sign() != b.Sign() {
+		return cmp.Compare(a.Sign(), b.Sign())
+	}
+
+	// NOTE: |x| = n / d is between 2^(e-1) and 2^(e+1), e being the bits of n minus the bits of d
+	ea, eb := a.Num().BitLen()-a.Denom().BitLen(), b.Num().BitLen()-b.Denom().BitLen()
+	switch {
+	case ea >= eb+2:
+		return a.Sign()
+	case eb >= ea+2:
+		return -a.Sign()
+	case a.Denom().Cmp(b.Denom()) == 0:
+		return a.Num().Cmp(b.Num())
+	}
+	return a.Cmp(b)
+}
+
+// Describe computes the statistics of the values with rational arithmetic, which never overflows nor rounds.
+// The percentiles are interpolated between the closest ranks, and the histogram has buckets of equal width,
+// their number following Sturges' rule when buckets is 0.
+// It returns ErrOutOfTime when the deadline is passed, the rational arithmetic being slow for the values with large
+// numerators or denominators.
+func Describe(values []*big.Rat, percentiles []*big.Rat, buckets int, deadline time.Time) (Statistics, error) {
+	n := len(values)
+	if n == 0 {
+		return Statistics{}, ErrNoValues
+	}
+	for _, p := range percentiles {
+		if p.Sign() < 0 || p.Cmp(big.NewRat(100, 1)) > 0 {
+			return Statistics{}, ErrInvalidPercentile
+		}
+	}
+	if buckets < 0 || buckets > MaxBuckets {
+		return Statistics{}, ErrInvalidBuckets
+	}
+
+	// NOTE: the comparisons multiplying the rationals crosswise, the sort ends quickly once the deadline is passed
+	outOfTime := false
+	sorted := slices.Clone(values)
+	slices.SortFunc(sorted, func(a, b *big.Rat) int {
+		if outOfTime = outOfTime || time.Now().After(deadline); outOfTime {
+			return 0
+		}
+		return compareRats(a, b)
+	})
+	if outOfTime {
+		return Statistics{}, ErrOutOfTime
+	}
+
+	// NOTE: the equal values being next to each other, each one is squared once
+	sums, squareSums := newRatSum(), newRatSum()
+	for i := 0; i < n; {
+		if time.Now().After(deadline) {
+			return Statistics{}, ErrOutOfTime
+		}
+		j := i + 1
+		for j < n && compareRats(sorted[j], sorted[i]) == 0 {
+			j++
+		}
+		v, repeats := sorted[i], big.NewInt(int64(j-i))
+
+		sums.add(new(big.Int).Mul(v.Num(), repeats), v.Denom())
+		square := new(big.Int).Mul(v.Num(), v.Num())
+		squareSums.add(square.Mul(square, repeats), new(big.Int).Mul(v.Denom(), v.Denom()))
+		i = j
+	}
+	sum, err := sums.rat(deadline)
+	if err != nil {
+		return Statistics{}, err
+	}
+	squares, err := squareSums.rat(deadline)
+	if err != nil {
+		return Statistics{}, err
+	}
+	count := new(big.Rat).SetInt64(int64(n))
+
+	// NOTE: Σ(x - mean)² = Σx² - (Σx)² / n holds exactly with rationals
+	deviations := new(big.Rat).Mul(sum, sum)
+	deviations.Sub(squares, deviations.Quo(deviations, count))
+
+	stats := Statistics{
+		Count:    n,
+		Sum:      sum,
+		Mean:     new(big.Rat).Quo(sum, count),
+		Median:   percentile(sorted, big.NewRat(50, 1)),
+		Modes:    modes(sorted),
+		Min:      sorted[0],
+		Max:      sorted[n-1],
+		Variance: new(big.Rat).Quo(deviations, count),
+	}
+	stats.StdDev = sqrtRat(stats.Variance)
+	if n > 1 {
+		stats.SampleVariance = new(big.Rat).Quo(deviations, new(big.Rat).SetInt64(int64(n-1)))
+		stats.SampleStdDev = sqrtRat(stats.SampleVariance)
+	}
+
+	stats.Percentiles = make([]Percentile, len(percentiles))
+	for i, p := range percentiles {
+		stats.Percentiles[i] = Percentile{Rank: p, Value: percentile(sorted, p)}
+	}
+
+	if buckets == 0 {
+		buckets = min(int(math.Ceil(math.Log2(float64(n))))+1, MaxBuckets)
+	}
+	stats.Histogram = histogram(sorted, buckets)
+
+	return stats, nil
+}
+
+// percentile interpolates linearly between the closest ranks of the sorted values
+func percentile(sorted []*big.Rat, p *big.Rat) *big.Rat {
+	h := new(big.Rat).Mul(p, big.NewRat(int64(len(sorted)-1), 100))
+	lower := new(big.Int).Quo(h.Num(), h.Denom())
+	i := int(lower.Int64())
+	if i == len(sorted)-1 {
+		return sorted[i]
+	}
+
+	fraction := new(big.Rat).Sub(h, new(big.Rat).SetInt(lower))
+	value := new(big.Rat).Sub(sorted[i+1], sorted[i])
+	return value.Add(sorted[i], value.Mul(value, fraction))
+}
+
+// modes returns the most frequent of the sorted values, none when they are all distinct
+func modes(sorted []*big.Rat) []*big.Rat {
+	modes := []*big.Rat{}
+	best := 1
+	for i := 0; i < len(sorted); {
+		j := i + 1
+		for j < len(sorted) && compareRats(sorted[j], sorted[i]) == 0 {
+			j++
+		}
+		switch {
+		case j-i > best:
+			best = j - i
+			modes = []*big.Rat{sorted[i]}
+		case j-i == best && best > 1:
+			modes = append(modes, sorted[i])
+		}
+		i = j
+	}
+	return modes
+}
+
+// histogram counts the sorted values in buckets of equal width from the minimum to the maximum
+func histogram(sorted []*big.Rat, buckets int) []Bucket {
+	low, high := sorted[0], sorted[len(sorted)-1]
+	if compareRats(low, high) == 0 {
+		return []Bucket{{Start: low, End: high, Count: len(sorted)}}
+	}
+
+	width := new(big.Rat).Sub(high, low)
+	width.Quo(width, new(big.Rat).SetInt64(int64(buckets)))
+
+	histogram := make([]Bucket, buckets)
+	for i := range histogram {
+		histogram[i].Start = new(big.Rat).Add(low, new(big.Rat).Mul(width, new(big.Rat).SetInt64(int64(i))))
+		histogram[i].End = new(big.Rat).Add(histogram[i].Start, width)
+	}
+	histogram[buckets-1].End = high
+
+	// NOTE: the values being sorted, the buckets are filled one after the other
+	i := 0
+	for _, v := range sorted {
+		for i < buckets-1 && compareRats(v, histogram[i].End) >= 0 {
+			i++
+		}
+		histogram[i].Count++
+	}
+	return histogram
+}
+
+// Regress fits the least squares line through the points (xs[i], ys[i]), or returns ErrOutOfTime when the deadline
+// is passed
+func Regress(xs []*big.Rat, ys []*big.Rat, deadline time.Time) (Regression, error) {
+	if len(xs) == 0 {
+		return Regression{}, ErrNoValues
+	}
+	n := new(big.Rat).SetInt64(int64(len(xs)))
+
+	product := func(u *big.Rat, v *big.Rat) (*big.Int, *big.Int) {
+		return new(big.Int).Mul(u.Num(), v.Num()), new(big.Int).Mul(u.Denom(), v.Denom())
+	}
+	terms := [5]ratSum{newRatSum(), newRatSum(), newRatSum(), newRatSum(), newRatSum()}
+	for i := range xs {
+		if time.Now().After(deadline) {
+			return Regression{}, ErrOutOfTime
+		}
+		terms[0].add(xs[i].Num(), xs[i].Denom())
+		terms[1].add(ys[i].Num(), ys[i].Denom())
+		terms[2].add(product(xs[i], xs[i]))
+		terms[3].add(product(xs[i], ys[i]))
+		terms[4].add(product(ys[i], ys[i]))
+	}
+	var sums [5]*big.Rat
+	for i, term := range terms {
+		sum, err := term.rat(deadline)
+		if err != nil {
+			return Regression{}, err
+		}
+		sums[i] = sum
+	}
+	sumX, sumY, sumXX, sumXY, sumYY := sums[0], sums[1], sums[2], sums[3], sums[4]
+
+	// NOTE: the centered sums Sxx, Sxy and Syy are n times the variances and the covariance
+	centered := func(sumUV *big.Rat, sumU *big.Rat, sumV *big.Rat) *big.Rat {
+		s := new(big.Rat).Mul(sumU, sumV)
+		return s.Sub(sumUV, s.Quo(s, n))
+	}
+	sxx, sxy, syy := centered(sumXX, sumX, sumX), centered(sumXY, sumX, sumY), centered(sumYY, sumY, sumY)
+	if sxx.Sign() == 0 {
+		return Regression{}, ErrConstantX
+	}
+
+	slope := new(big.Rat).Quo(sxy, sxx)
+	intercept := new(big.Rat).Mul(slope, sumX)
+	intercept.Quo(intercept.Sub(sumY, intercept), n)
+
+	regression := Regression{Slope: slope, Intercept: intercept}
+	if syy.Sign() != 0 {
+		deviation := sqrtRat(new(big.Rat).Mul(sxx, syy))
+		regression.Correlation = deviation.Quo(new(big.Float).SetPrec(statsPrec).SetRat(sxy), deviation)
+	}
+	return regression, nil
+}
+
+func sqrtRat(r *big.Rat) *big.Float {
+	return new(big.Float).SetPrec(statsPrec).Sqrt(new(big.Float).SetPrec(statsPrec).SetRat(r))
+}
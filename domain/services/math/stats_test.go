@@ -0,0 +1,140 @@
+package math
+
+import (
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// later is a deadline the tests never reach
+var later = time.Now().Add(time.Hour)
+
+func rationals(values ...string) []*big.Rat {
+	rats := make([]*big.Rat, len(values))
+	for i, v := range values {
+		rats[i], _ = new(big.Rat).SetString(v)
+	}
+	return rats
+}
+
+func Test_Describe(t *testing.T) {
+	stats, err := Describe(rationals("9", "4", "2", "5", "4", "7", "4", "5"), DefaultPercentiles, 0, later)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 8, stats.Count)
+	assert.Equal(t, "40", stats.Sum.RatString())
+	assert.Equal(t, "5", stats.Mean.RatString())
+	assert.Equal(t, "9/2", stats.Median.RatString())
+	assert.Equal(t, rationals("4"), stats.Modes)
+	assert.Equal(t, "2", stats.Min.RatString())
+	assert.Equal(t, "9", stats.Max.RatString())
+	assert.Equal(t, "4", stats.Variance.RatString())
+	assert.Equal(t, "2", stats.StdDev.Text('g', 20))
+	assert.Equal(t, "32/7", stats.SampleVariance.RatString())
+	assert.Equal(t, "2.1380899352993950775", stats.SampleStdDev.Text('g', 20))
+
+	var percentiles []string
+	for _, p := range stats.Percentiles {
+		percentiles = append(percentiles, p.Rank.RatString()+":"+p.Value.FloatString(2))
+	}
+	assert.Equal(t, []string{"5:2.70", "25:4.00", "75:5.50", "95:8.30", "99:8.86"}, percentiles)
+
+	// NOTE: Sturges' rule gives 4 buckets of width 7/4 for 8 values
+	var buckets []string
+	for _, b := range stats.Histogram {
+		buckets = append(buckets, b.Start.FloatString(2)+"-"+b.End.FloatString(2)+":"+strconv.Itoa(b.Count))
+	}
+	assert.Equal(t, []string{"2.00-3.75:1", "3.75-5.50:5", "5.50-7.25:1", "7.25-9.00:1"}, buckets)
+}
+
+func Test_DescribeExact(t *testing.T) {
+	// NOTE: the sum neither rounds 0.1 + 0.2, nor overflows, nor loses the small value
+	stats, err := Describe(rationals("0.1", "0.2", "1e400", "-1e400", "1e-400"), nil, 1, later)
+	assert.NoError(t, err)
+
+	expected := new(big.Rat).Add(big.NewRat(3, 10), new(big.Rat).SetFrac(one, pow10(400)))
+	assert.Zero(t, expected.Cmp(stats.Sum))
+	assert.Equal(t, []*big.Rat{}, stats.Modes)
+	assert.Equal(t, []Percentile{}, stats.Percentiles)
+	assert.Len(t, stats.Histogram, 1)
+	assert.Equal(t, 5, stats.Histogram[0].Count)
+}
+
+func Test_DescribeSingleValue(t *testing.T) {
+	stats, err := Describe(rationals("3", "3"), []*big.Rat{big.NewRat(0, 1), big.NewRat(100, 1)}, 0, later)
+	assert.NoError(t, err)
+	assert.Equal(t, rationals("3"), stats.Modes)
+	assert.Equal(t, "0", stats.Variance.RatString())
+	assert.Equal(t, []Bucket{{Start: stats.Min, End: stats.Max, Count: 2}}, stats.Histogram)
+
+	stats, err = Describe(rationals("3"), nil, 0, later)
+	assert.NoError(t, err)
+	assert.Nil(t, stats.SampleVariance)
+	assert.Nil(t, stats.SampleStdDev)
+}
+
+func Test_DescribeErrors(t *testing.T) {
+	_, err := Describe(nil, nil, 0, later)
+	assert.ErrorIs(t, err, ErrNoValues)
+
+	_, err = Describe(rationals("1"), []*big.Rat{big.NewRat(101, 1)}, 0, later)
+	assert.ErrorIs(t, err, ErrInvalidPercentile)
+
+	_, err = Describe(rationals("1"), nil, MaxBuckets+1, later)
+	assert.ErrorIs(t, err, ErrInvalidBuckets)
+
+	_, err = Describe(rationals("1", "3", "2"), nil, 0, time.Now())
+	assert.ErrorIs(t, err, ErrOutOfTime)
+}
+
+func Test_DescribeLargeValues(t *testing.T) {
+	// NOTE: the sums of the values alternating between two denominators reduce no fraction until the end
+	alternating := rationals("9e9999", "1.2e-9999")
+	values := make([]*big.Rat, 100000)
+	for i := range values {
+		values[i] = alternating[i%2]
+	}
+
+	start := time.Now()
+	stats, err := Describe(values, nil, 1, later)
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	expected := new(big.Rat).Mul(new(big.Rat).Add(values[0], values[1]), big.NewRat(50000, 1))
+	assert.Zero(t, expected.Cmp(stats.Sum))
+}
+
+func Test_Regress(t *testing.T) {
+	regression, err := Regress(rationals("1", "2", "3", "4", "5"), rationals("2", "4", "5", "4", "5"), later)
+	assert.NoError(t, err)
+	assert.Equal(t, "3/5", regression.Slope.RatString())
+	assert.Equal(t, "11/5", regression.Intercept.RatString())
+	assert.Equal(t, "0.77459666924148337704", regression.Correlation.Text('g', 20))
+
+	regression, err = Regress(rationals("1", "2"), rationals("7", "7"), later)
+	assert.NoError(t, err)
+	assert.Equal(t, "0", regression.Slope.RatString())
+	assert.Nil(t, regression.Correlation)
+
+	_, err = Regress(rationals("1", "1"), rationals("1", "2"), later)
+	assert.ErrorIs(t, err, ErrConstantX)
+
+	_, err = Regress(nil, nil, later)
+	assert.ErrorIs(t, err, ErrNoValues)
+
+	_, err = Regress(rationals("1", "2"), rationals("1", "2"), time.Now())
+	assert.ErrorIs(t, err, ErrOutOfTime)
+}
+
+func Test_compareRats(t *testing.T) {
+	values := rationals("-1e300", "-3", "-5/2", "-1e-300", "0", "1e-300", "1/3", "0.34", "1", "3/2", "2", "1e300")
+	for i, a := range values {
+		for j, b := range values {
+			assert.Equal(t, a.Cmp(b), compareRats(a, b), a.String()+" "+b.String())
+			assert.Equal(t, i < j, compareRats(a, b) < 0)
+		}
+	}
+}
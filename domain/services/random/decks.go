@@ -0,0 +1,144 @@
+package random
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultDeckTTL is how long cards can be drawn from a deck
+	DefaultDeckTTL = 24 * time.Hour
+
+	// DefaultMaxDecks bounds the decks not expired yet
+	DefaultMaxDecks = 100000
+
+	// DefaultMaxClientDecks bounds the decks not expired yet created by a client
+	DefaultMaxClientDecks = 100
+
+	// MaxDeckCards bounds the cards of a deck
+	MaxDeckCards = 1000
+)
+
+var (
+	ErrUnknownDeck        = errors.New("unknown or expired deck")
+	ErrTooManyDecks       = errors.New("too many decks")
+	ErrTooManyClientDecks = errors.New("too many decks created by the client")
+	ErrInvalidDeck        = errors.New("invalid deck")
+	ErrNotEnoughCards     = errors.New("not enough cards left")
+)
+
+// StandardDeck returns the 52 cards of a standard deck, written like AS for the ace of spades, 10H or QD, and the two
+// jokers JK when asked
+func StandardDeck(jokers bool) []string {
+	var cards []string
+	for _, suit := range []string{"S", "H", "D", "C"} {
+		for _, rank := range []string{"A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"} {
+			cards = append(cards, rank+suit)
+		}
+	}
+	if jokers {
+		cards = append(cards, "JK", "JK")
+	}
+	return cards
+}
+
+type deck struct {
+	cards   []string
+	client  string
+	expires time.Time
+}
+
+// Decks are shuffled decks, known by their ID, whose cards are drawn without replacement until they expire
+type Decks struct {
+	mu      sync.Mutex
+	decks   map[string]*deck
+	clients map[string]int
+
+	ttl          time.Duration
+	max          int
+	maxPerClient int
+
+	source Source
+	now    func() time.Time
+}
+
+func NewDecks(ttl time.Duration, max int, maxPerClient int) *Decks {
+	return &Decks{
+		decks:        make(map[string]*deck),
+		clients:      make(map[string]int),
+		ttl:          ttl,
+		max:          max,
+		maxPerClient: maxPerClient,
+		source:       Crypto,
+		now:          time.Now,
+	}
+}
+
+// Create shuffles the cards into a new deck for the client, returning its ID and when it expires
+func (d *Decks) Create(client string, cards []string) (string, time.Time, error) {
+	if len(cards) == 0 || len(cards) > MaxDeckCards {
+		return "", time.Time{}, ErrInvalidDeck
+	}
+
+	shuffled := append([]string{}, cards...)
+	Shuffle(d.source, shuffled)
+	id := uuid.NewString()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.decks) >= d.max {
+		return "", time.Time{}, ErrTooManyDecks
+	}
+	if d.clients[client] >= d.maxPerClient {
+		return "", time.Time{}, ErrTooManyClientDecks
+	}
+	expires := d.now().Add(d.ttl)
+	d.decks[id] = &deck{cards: shuffled, client: client, expires: expires}
+	d.clients[client]++
+	return id, expires, nil
+}
+
+// Draw takes count cards from the top of the deck, returning them along with the number of cards left
+func (d *Decks) Draw(id string, count int) ([]string, int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dk, ok := d.decks[id]
+	if !ok || !d.now().Before(dk.expires) {
+		return nil, 0, ErrUnknownDeck
+	}
+	if count > len(dk.cards) {
+		return nil, len(dk.cards), ErrNotEnoughCards
+	}
+	drawn := dk.cards[:count:count]
+	dk.cards = dk.cards[count:]
+	return drawn, len(dk.cards), nil
+}
+
+// Run periodically forgets the expired decks, until ctx is done
+func (d *Decks) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Minute):
+			d.mu.Lock()
+			now := d.now()
+			for id, dk := range d.decks {
+				if !now.Before(dk.expires) {
+					delete(d.decks, id)
+					d.clients[dk.client]--
+					if d.clients[dk.client] == 0 {
+						delete(d.clients, dk.client)
+					}
+				}
+			}
+			d.mu.Unlock()
+		}
+	}
+}
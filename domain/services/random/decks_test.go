@@ -0,0 +1,61 @@
+package random
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StandardDeck(t *testing.T) {
+	cards := StandardDeck(false)
+	assert.Len(t, cards, 52)
+	assert.Equal(t, []string{"AS", "2S"}, cards[:2])
+	assert.Equal(t, "KC", cards[51])
+	assert.Len(t, StandardDeck(true), 54)
+}
+
+func Test_Decks(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	decks := NewDecks(time.Hour, 3, 2)
+	decks.source = &sequence{0, 0, 1}
+	decks.now = func() time.Time { return now }
+
+	_, _, err := decks.Create("alice", nil)
+	assert.ErrorIs(t, err, ErrInvalidDeck)
+
+	id, expires, err := decks.Create("alice", []string{"a", "b", "c", "d"})
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), expires)
+
+	cards, left, err := decks.Draw(id, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c", "b", "d"}, cards)
+	assert.Equal(t, 1, left)
+
+	_, left, err = decks.Draw(id, 2)
+	assert.ErrorIs(t, err, ErrNotEnoughCards)
+	assert.Equal(t, 1, left)
+
+	cards, left, err = decks.Draw(id, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, cards)
+	assert.Equal(t, 0, left)
+
+	_, _, err = decks.Draw("other", 1)
+	assert.ErrorIs(t, err, ErrUnknownDeck)
+
+	decks.source = Crypto
+	_, _, err = decks.Create("alice", StandardDeck(true))
+	assert.NoError(t, err)
+	_, _, err = decks.Create("alice", StandardDeck(false))
+	assert.ErrorIs(t, err, ErrTooManyClientDecks)
+	_, _, err = decks.Create("bob", StandardDeck(false))
+	assert.NoError(t, err)
+	_, _, err = decks.Create("carol", StandardDeck(false))
+	assert.ErrorIs(t, err, ErrTooManyDecks)
+
+	now = now.Add(time.Hour)
+	_, _, err = decks.Draw(id, 0)
+	assert.ErrorIs(t, err, ErrUnknownDeck)
+}
@@ -0,0 +1,244 @@
+package random
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSeedTTL is how long a server seed can be used
+	DefaultSeedTTL = 24 * time.Hour
+
+	// DefaultSeedRetention is how long a server seed can still be revealed once it can no longer be used
+	DefaultSeedRetention = 7 * 24 * time.Hour
+
+	// DefaultMaxSeeds bounds the server seeds kept, in use or kept to be revealed
+	DefaultMaxSeeds = 100000
+
+	// DefaultMaxClientSeeds bounds the server seeds in use created by a client
+	DefaultMaxClientSeeds = 100
+
+	// seedBytes are the random bytes of a server seed
+	seedBytes = 32
+)
+
+var (
+	ErrUnknownSeed        = errors.New("unknown or expired seed")
+	ErrTooManySeeds       = errors.New("too many seeds")
+	ErrTooManyClientSeeds = errors.New("too many seeds in use by the client")
+	ErrSeedInUse          = errors.New("seed in use by another client")
+)
+
+type cryptoSource struct{}
+
+// Intn draws a number between 0 and n - 1 with crypto/rand
+func (cryptoSource) Intn(n int) int {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		// NOTE: crypto/rand only fails when the system has no randomness left to give
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return int(v.Int64())
+}
+
+// Crypto draws numbers with crypto/rand, unpredictable
+var Crypto = cryptoSource{}
+
+// Seeded draws numbers determined by a server seed, a client seed and a nonce: the bytes of
+// HMAC-SHA256(server seed, "client seed:nonce:round") for the rounds 0, 1, 2... are read as big endian uint32,
+// the ones beyond the greatest multiple of n being skipped so that every number is as likely
+type Seeded struct {
+	serverSeed string
+	clientSeed string
+	nonce      int
+
+	round  int
+	buffer []byte
+}
+
+func NewSeeded(serverSeed string, clientSeed string, nonce int) *Seeded {
+	return &Seeded{serverSeed: serverSeed, clientSeed: clientSeed, nonce: nonce}
+}
+
+func (s *Seeded) uint32() uint32 {
+	if len(s.buffer) < 4 {
+		mac := hmac.New(sha256.New, []byte(s.serverSeed))
+		fmt.Fprintf(mac, "%s:%d:%d", s.clientSeed, s.nonce, s.round)
+		s.buffer = mac.Sum(nil)
+		s.round++
+	}
+
+	v := binary.BigEndian.Uint32(s.buffer)
+	s.buffer = s.buffer[4:]
+	return v
+}
+
+// Intn draws a number between 0 and n - 1, from a uint32 for n up to 2^32 and from two of them, the first one being
+// the high bits, beyond
+func (s *Seeded) Intn(n int) int {
+	if n <= 1<<32 {
+		limit := (1 << 32) - (1<<32)%uint64(n)
+		for {
+			if v := uint64(s.uint32()); v < limit {
+				return int(v % uint64(n))
+			}
+		}
+	}
+
+	// NOTE: -n mod n is 2^64 mod n, the count of the greatest values skipped
+	skipped := -uint64(n) % uint64(n)
+	for {
+		if v := uint64(s.uint32())<<32 | uint64(s.uint32()); v <= math.MaxUint64-skipped {
+			return int(v % uint64(n))
+		}
+	}
+}
+
+// Commit returns the SHA-256 commitment of the seed, in hexadecimal
+func Commit(seed string) string {
+	hash := sha256.Sum256([]byte(seed))
+	return hex.EncodeToString(hash[:])
+}
+
+type seed struct {
+	value   string
+	client  string
+	nonce   int
+	expires time.Time
+
+	// Whether the seed was revealed or expired, no longer being used.
+	revealed bool
+}
+
+// Seeds are the server seeds of the verifiable draws, known by their commitment. A seed is only used and revealed by
+// the client which created it until it is revealed or expires, and can then be revealed by anyone until it is forgotten.
+type Seeds struct {
+	mu      sync.Mutex
+	seeds   map[string]*seed
+	clients map[string]int
+
+	ttl          time.Duration
+	retention    time.Duration
+	max          int
+	maxPerClient int
+
+	now func() time.Time
+}
+
+func NewSeeds(ttl time.Duration, retention time.Duration, max int, maxPerClient int) *Seeds {
+	return &Seeds{
+		seeds:        make(map[string]*seed),
+		clients:      make(map[string]int),
+		ttl:          ttl,
+		retention:    retention,
+		max:          max,
+		maxPerClient: maxPerClient,
+		now:          time.Now,
+	}
+}
+
+// Create draws a new server seed for the client, returning its commitment and when it expires
+func (s *Seeds) Create(client string) (string, time.Time, error) {
+	b := make([]byte, seedBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, err
+	}
+	value := hex.EncodeToString(b)
+	commitment := Commit(value)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.seeds) >= s.max {
+		return "", time.Time{}, ErrTooManySeeds
+	}
+	if s.clients[client] >= s.maxPerClient {
+		return "", time.Time{}, ErrTooManyClientSeeds
+	}
+	expires := s.now().Add(s.ttl)
+	s.seeds[commitment] = &seed{value: value, client: client, expires: expires}
+	s.clients[client]++
+	return commitment, expires, nil
+}
+
+// Next returns a source for the next draw of the client with its seed and the client seed, along with its nonce
+// counted from 0
+func (s *Seeds) Next(commitment string, client string, clientSeed string) (*Seeded, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sd, ok := s.seeds[commitment]
+	if !ok || sd.revealed || !s.now().Before(sd.expires) {
+		return nil, 0, ErrUnknownSeed
+	}
+	if sd.client != client {
+		return nil, 0, ErrSeedInUse
+	}
+	nonce := sd.nonce
+	sd.nonce++
+	return NewSeeded(sd.value, clientSeed, nonce), nonce, nil
+}
+
+// Reveal returns the server seed and the number of draws done with it. A seed in use can only be revealed by the
+// client which created it, and can then no longer be used.
+func (s *Seeds) Reveal(commitment string, client string) (string, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	sd, ok := s.seeds[commitment]
+	if !ok || !now.Before(sd.expires.Add(s.retention)) {
+		return "", 0, ErrUnknownSeed
+	}
+	if !sd.revealed && now.Before(sd.expires) {
+		if sd.client != client {
+			return "", 0, ErrSeedInUse
+		}
+		s.retire(sd)
+	}
+	return sd.value, sd.nonce, nil
+}
+
+// retire stops the use of the seed, no longer counting it for its client
+func (s *Seeds) retire(sd *seed) {
+	if sd.revealed {
+		return
+	}
+	sd.revealed = true
+	s.clients[sd.client]--
+	if s.clients[sd.client] == 0 {
+		delete(s.clients, sd.client)
+	}
+}
+
+// Run periodically retires the expired seeds and forgets the ones past their retention, until ctx is done
+func (s *Seeds) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Minute):
+			s.mu.Lock()
+			now := s.now()
+			for commitment, sd := range s.seeds {
+				if !now.Before(sd.expires) {
+					s.retire(sd)
+				}
+				if !now.Before(sd.expires.Add(s.retention)) {
+					delete(s.seeds, commitment)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
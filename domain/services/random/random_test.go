@@ -0,0 +1,111 @@
+package random
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func draw(s interface{ Intn(n int) int }, n int, count int) []int {
+	values := make([]int, count)
+	for i := range values {
+		values[i] = s.Intn(n)
+	}
+	return values
+}
+
+func Test_Crypto(t *testing.T) {
+	seen := make(map[int]bool)
+	for _, v := range draw(Crypto, 6, 1000) {
+		assert.GreaterOrEqual(t, v, 0)
+		assert.Less(t, v, 6)
+		seen[v] = true
+	}
+	assert.Len(t, seen, 6)
+}
+
+func Test_Seeded(t *testing.T) {
+	tt := map[string]struct {
+		nonce    int
+		n        int
+		expected []int
+	}{
+		"d6":            {nonce: 0, n: 6, expected: []int{2, 4, 5, 5, 1, 0, 5, 0, 4, 1}},
+		"next nonce":    {nonce: 1, n: 6, expected: []int{2, 3, 3, 3, 5, 0, 5, 2, 4, 4}},
+		"whole words":   {nonce: 0, n: 1 << 32, expected: []int{1667191592, 3293609674, 3492291845}},
+		"safe integers": {nonce: 0, n: MaxSafeInteger + 1, expected: []int{8817158835437258, 2292507180851959}},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			s := NewSeeded("server seed", "client seed", tc.nonce)
+			assert.Equal(t, tc.expected, draw(s, tc.n, len(tc.expected)))
+		})
+	}
+}
+
+func Test_Commit(t *testing.T) {
+	assert.Equal(t, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", Commit("abc"))
+}
+
+func Test_Seeds(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	seeds := NewSeeds(time.Hour, 24*time.Hour, 4, 2)
+	seeds.now = func() time.Time { return now }
+
+	commitment, expires, err := seeds.Create("alice")
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), expires)
+
+	_, _, err = seeds.Next(commitment, "bob", "client")
+	assert.ErrorIs(t, err, ErrSeedInUse)
+
+	first, nonce, err := seeds.Next(commitment, "alice", "client")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, nonce)
+	second, nonce, err := seeds.Next(commitment, "alice", "client")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, nonce)
+
+	_, _, err = seeds.Reveal(commitment, "bob")
+	assert.ErrorIs(t, err, ErrSeedInUse)
+
+	value, draws, err := seeds.Reveal(commitment, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, draws)
+	assert.Equal(t, commitment, Commit(value))
+	assert.Equal(t, draw(NewSeeded(value, "client", 0), 100, 5), draw(first, 100, 5))
+	assert.Equal(t, draw(NewSeeded(value, "client", 1), 100, 5), draw(second, 100, 5))
+
+	_, _, err = seeds.Next(commitment, "alice", "client")
+	assert.ErrorIs(t, err, ErrUnknownSeed)
+	revealed, draws, err := seeds.Reveal(commitment, "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, value, revealed)
+	assert.Equal(t, 2, draws)
+
+	expired, _, err := seeds.Create("alice")
+	assert.NoError(t, err)
+	_, _, err = seeds.Create("alice")
+	assert.NoError(t, err)
+	_, _, err = seeds.Create("alice")
+	assert.ErrorIs(t, err, ErrTooManyClientSeeds)
+	_, _, err = seeds.Create("bob")
+	assert.NoError(t, err)
+	_, _, err = seeds.Create("carol")
+	assert.ErrorIs(t, err, ErrTooManySeeds)
+
+	now = now.Add(time.Hour)
+	_, _, err = seeds.Next(expired, "alice", "client")
+	assert.ErrorIs(t, err, ErrUnknownSeed)
+	_, draws, err = seeds.Reveal(expired, "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, draws)
+
+	now = now.Add(24 * time.Hour)
+	_, _, err = seeds.Reveal(expired, "alice")
+	assert.ErrorIs(t, err, ErrUnknownSeed)
+	_, _, err = seeds.Reveal("other", "alice")
+	assert.ErrorIs(t, err, ErrUnknownSeed)
+}
@@ -0,0 +1,63 @@
+package random
+
+import (
+	"errors"
+	"math"
+)
+
+// MaxSafeInteger bounds the integers drawn, beyond which JSON clients lose precision
+const MaxSafeInteger = 1<<53 - 1
+
+var ErrInvalidWeights = errors.New("weights must be positive or zero, some being positive")
+
+// Source draws a number between 0 and n - 1, n being up to 2^54 for the utilities below
+type Source interface {
+	Intn(n int) int
+}
+
+// Float64 draws a number between 0 included and 1 excluded, as likely as the others among the multiples of 2^-53
+func Float64(s Source) float64 {
+	return float64(s.Intn(1<<53)) / (1 << 53)
+}
+
+// Int draws an integer between low and high included, which are at most MaxSafeInteger in absolute value
+func Int(s Source, low int, high int) int {
+	return low + s.Intn(high-low+1)
+}
+
+// Shuffle puts the items in a random order, every order being as likely
+func Shuffle[T any](s Source, items []T) {
+	// NOTE: Fisher-Yates, swapping each item with one before it or itself
+	for i := len(items) - 1; i > 0; i-- {
+		j := s.Intn(i + 1)
+		items[i], items[j] = items[j], items[i]
+	}
+}
+
+// Pick draws the index of a weight, with a probability proportional to the weight
+func Pick(s Source, weights []float64) (int, error) {
+	total := 0.0
+	last := -1
+	for i, w := range weights {
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return 0, ErrInvalidWeights
+		}
+		if w > 0 {
+			total += w
+			last = i
+		}
+	}
+	if last < 0 || math.IsInf(total, 0) {
+		return 0, ErrInvalidWeights
+	}
+
+	r := Float64(s) * total
+	for i, w := range weights {
+		if r < w {
+			return i, nil
+		}
+		r -= w
+	}
+	// NOTE: the rounding errors of the subtractions may leave r beyond the last weight
+	return last, nil
+}
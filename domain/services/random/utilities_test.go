@@ -0,0 +1,65 @@
+package random
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sequence draws the numbers in order
+type sequence []int
+
+func (s *sequence) Intn(n int) int {
+	v := (*s)[0] % n
+	*s = (*s)[1:]
+	return v
+}
+
+func Test_Float64(t *testing.T) {
+	assert.Equal(t, 0.0, Float64(&sequence{0}))
+	assert.Equal(t, 0.5, Float64(&sequence{1 << 52}))
+	assert.Equal(t, 1-1.0/(1<<53), Float64(&sequence{1<<53 - 1}))
+}
+
+func Test_Int(t *testing.T) {
+	assert.Equal(t, -3, Int(&sequence{0}, -3, 3))
+	assert.Equal(t, 3, Int(&sequence{6}, -3, 3))
+	assert.Equal(t, MaxSafeInteger, Int(&sequence{2 * MaxSafeInteger}, -MaxSafeInteger, MaxSafeInteger))
+
+	for i := 0; i < 100; i++ {
+		v := Int(Crypto, -MaxSafeInteger, MaxSafeInteger)
+		assert.LessOrEqual(t, v, MaxSafeInteger)
+		assert.GreaterOrEqual(t, v, -MaxSafeInteger)
+	}
+}
+
+func Test_Shuffle(t *testing.T) {
+	items := []string{"a", "b", "c", "d"}
+	Shuffle(&sequence{0, 0, 1}, items)
+	assert.Equal(t, []string{"c", "b", "d", "a"}, items)
+}
+
+func Test_Pick(t *testing.T) {
+	tt := map[string]struct {
+		weights       []float64
+		draw          int
+		expected      int
+		expectedError error
+	}{
+		"first":           {weights: []float64{1, 3}, draw: 0, expected: 0},
+		"second":          {weights: []float64{1, 3}, draw: 1 << 51, expected: 1},
+		"skipping zero":   {weights: []float64{0, 2, 0}, draw: 0, expected: 1},
+		"last":            {weights: []float64{1, 1, 0}, draw: 1<<53 - 1, expected: 1},
+		"no weight":       {weights: []float64{0, 0}, expectedError: ErrInvalidWeights},
+		"negative":        {weights: []float64{1, -1}, expectedError: ErrInvalidWeights},
+		"nothing to pick": {weights: []float64{}, expectedError: ErrInvalidWeights},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			i, err := Pick(&sequence{tc.draw}, tc.weights)
+			assert.ErrorIs(t, err, tc.expectedError)
+			assert.Equal(t, tc.expected, i)
+		})
+	}
+}
@@ -2,10 +2,12 @@ package spectrum
 
 import (
 	"bytes"
+	"context"
 	"time"
 
 	"github.com/gorilla/websocket"
 	log "github.com/sirupsen/logrus"
+	"utile.space/api/infrastructure/metrics"
 )
 
 type Client struct {
@@ -22,6 +24,9 @@ type Client struct {
 	userID string
 
 	hub *Hub
+
+	// Logger of the request which opened the connection.
+	logger *log.Entry
 }
 
 const (
@@ -36,6 +41,9 @@ const (
 
 	// Maximum message size allowed from peer.
 	maxMessageSize = 512
+
+	// Label of the endpoint in the websocket metrics.
+	metricsEndpoint = "spectrum"
 )
 
 var (
@@ -45,18 +53,33 @@ var (
 
 var lastClientId = 0
 
-func NewClient(hub *Hub, conn *websocket.Conn) *Client {
+func NewClient(hub *Hub, conn *websocket.Conn, logger *log.Entry) *Client {
 	lastClientId = lastClientId + 1
 	return &Client{
-		conn: conn,
-		send: make(chan []byte, 256),
-		id:   lastClientId,
-		hub:  hub,
+		conn:   conn,
+		send:   make(chan []byte, 256),
+		id:     lastClientId,
+		hub:    hub,
+		logger: logger,
 	}
 }
 
+// Logger returns the logger of the connection, with the client and user identifiers
+func (c *Client) Logger() *log.Entry {
+	return c.logger.WithFields(log.Fields{
+		"client": c.id,
+		"user":   c.userID,
+	})
+}
+
+// Send queues a message for the WritePump without blocking the hub: when the buffer is full, the message is dropped
 func (c *Client) Send(content []byte) {
-	c.send <- content
+	select {
+	case c.send <- content:
+	default:
+		metrics.WebsocketDrop(metricsEndpoint)
+		c.Logger().Warn("Send buffer full, message dropped")
+	}
 }
 
 func (c *Client) UserID() string {
@@ -72,7 +95,7 @@ func (c *Client) SetUserID(userID string) {
 // The application runs ReadPump in a per-connection goroutine. The application
 // ensures that there is at most one reader on a connection by executing all
 // reads from this goroutine.
-func (c *Client) ReadPump() {
+func (c *Client) ReadPump(ctx context.Context) {
 	defer func() {
 		c.hub.unregister <- c
 		c.conn.Close()
@@ -81,7 +104,7 @@ func (c *Client) ReadPump() {
 	c.conn.SetReadLimit(maxMessageSize)
 	err := c.conn.SetReadDeadline(time.Now().Add(pongWait))
 	if err != nil {
-		log.Warnf("ReadPump error: %v", err)
+		c.Logger().Warnf("ReadPump error: %v", err)
 	}
 	c.conn.SetPongHandler(func(string) error { err := c.conn.SetReadDeadline(time.Now().Add(pongWait)); return err })
 
@@ -89,15 +112,16 @@ func (c *Client) ReadPump() {
 		_, message, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Warnf("ReadPump error: %v", err)
+				c.Logger().Warnf("ReadPump error: %v", err)
 			}
 			break
 		}
 		message = bytes.TrimSpace(bytes.Replace(message, newline, space, -1))
+		metrics.WebsocketMessage(metricsEndpoint, metrics.In)
 
-		err = c.EvaluateRPC(string(message))
+		err = c.EvaluateRPC(ctx, string(message))
 		if err != nil {
-			log.Debugf("ReadPump read error: %v", err)
+			c.Logger().Debugf("ReadPump read error: %v", err)
 		}
 	}
 }
@@ -118,12 +142,12 @@ func (c *Client) WritePump() {
 		case message, ok := <-c.send:
 			err := c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err != nil {
-				log.Warnf("WritePump error: %v", err)
+				c.Logger().Warnf("WritePump error: %v", err)
 			}
 			if !ok {
 				// The hub closed the channel.
 				err = c.conn.WriteMessage(websocket.CloseMessage, []byte{})
-				log.Warnf("WritePump channel closed error: %v", err)
+				c.Logger().Warnf("WritePump channel closed error: %v", err)
 				return
 			}
 
@@ -134,22 +158,24 @@ func (c *Client) WritePump() {
 
 			n, err := w.Write(message)
 			if n != len(message) {
-				log.Warn("Different length written and expected in the websocket")
+				c.Logger().Warn("Different length written and expected in the websocket")
 			}
 			if err != nil {
-				log.Warnf("WritePump error: %v", err)
+				c.Logger().Warnf("WritePump error: %v", err)
 			}
+			metrics.WebsocketMessage(metricsEndpoint, metrics.Out)
 
 			// Add queued chat messages to the current websocket message.
 			n = len(c.send)
 			for i := 0; i < n; i++ {
 				if _, err = w.Write(newline); err != nil {
-					log.Warnf("WritePump error: %v", err)
+					c.Logger().Warnf("WritePump error: %v", err)
 				}
 
 				if _, err = w.Write(<-c.send); err != nil {
-					log.Warnf("WritePump error: %v", err)
+					c.Logger().Warnf("WritePump error: %v", err)
 				}
+				metrics.WebsocketMessage(metricsEndpoint, metrics.Out)
 			}
 
 			if err := w.Close(); err != nil {
@@ -157,7 +183,7 @@ func (c *Client) WritePump() {
 			}
 		case <-ticker.C:
 			if err := c.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
-				log.Warnf("WritePump error: %v", err)
+				c.Logger().Warnf("WritePump error: %v", err)
 			}
 
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
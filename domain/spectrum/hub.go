@@ -2,25 +2,50 @@ package spectrum
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"utile.space/api/domain/services/dice"
 	"utile.space/api/domain/valueobjects"
+	"utile.space/api/infrastructure/history"
+	"utile.space/api/infrastructure/ratelimit"
 	"utile.space/api/utils"
 )
 
+const (
+	// maxRoomIDAttempts bounds the room IDs drawn for a new room
+	maxRoomIDAttempts = 100
+
+	// roomIDReuse is how long the ID of a room having rolled is not given to a new room
+	roomIDReuse = 24 * time.Hour
+)
+
+// rollTier bounds the rolls of each connection
+var rollTier = ratelimit.Tier{Rate: 1, Burst: 10}
+
 // Hub maintains the set of active clients with their business entity logic plus the entities associating clients together: Players with Battleships Matches, Participants with Spectrum Rooms, etc.
 type Hub struct {
 	// Registered clients.
 	clients map[*Client]bool
 
+	// Guards the users, the rooms and their participants, changed by the clients and the cleaning routine.
+	mu sync.Mutex
+
 	users map[string]*User
 
 	mappingUserIDToClient map[string]*Client
 
 	rooms map[string]*Room
 
+	// Rolls of the rooms, kept beyond the rooms themselves.
+	history history.Store
+
+	// Rolls allowed to each connection.
+	rolls *ratelimit.Limiter
+
 	messages chan *valueobjects.Message
 
 	// Register requests from the clients.
@@ -28,6 +53,21 @@ type Hub struct {
 
 	// Unregister requests from clients.
 	unregister chan *Client
+
+	// Liveness probes, closed by the runner.
+	pings chan chan struct{}
+
+	// Requests of the counts, answered by the runner.
+	stats chan chan Stats
+
+	logger *log.Entry
+}
+
+// Stats are the counts of the users and rooms of the hub
+type Stats struct {
+	OnlineUsers int
+	ActiveRooms int
+	TotalRooms  int
 }
 
 var (
@@ -35,21 +75,33 @@ var (
 	ErrRoomClosed            = errors.New("room already closed")
 	ErrWrongRoomOrPassword   = errors.New("wrong room or password")
 	ErrUnknownAtRoomCreation = errors.New("unknown problem at room creation")
+	ErrNoRoomID              = errors.New("no room ID left")
 	ErrUserCannotJoin        = errors.New("user cannot join room")
+	ErrNotInRoom             = errors.New("user not in a room")
 )
 
-func NewHub() *Hub {
+func NewHub(logger *log.Entry) *Hub {
 	return &Hub{
 		messages:              make(chan *valueobjects.Message),
 		Register:              make(chan *Client),
 		unregister:            make(chan *Client),
+		pings:                 make(chan chan struct{}),
+		stats:                 make(chan chan Stats),
 		clients:               make(map[*Client]bool),
 		users:                 make(map[string]*User),
 		mappingUserIDToClient: make(map[string]*Client),
 		rooms:                 make(map[string]*Room),
+		history:               history.NewMemoryStore(),
+		rolls:                 ratelimit.NewLimiter(rollTier, rollTier, nil),
+		logger:                logger,
 	}
 }
 
+// SetHistory makes the hub record the rolls in the store, before it runs
+func (h *Hub) SetHistory(store history.Store) {
+	h.history = store
+}
+
 func (h *Hub) CountOnlineUsers() int {
 	return len(h.users)
 }
@@ -69,6 +121,9 @@ func (h *Hub) CountActiveRooms() int {
 }
 
 func (h *Hub) LinkUserWithClient(userID string, client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	if _, ok := h.users[userID]; !ok {
 		user := NewUser(userID)
 		h.users[userID] = user
@@ -78,11 +133,17 @@ func (h *Hub) LinkUserWithClient(userID string, client *Client) {
 }
 
 func (h *Hub) NewRoom(creatorUserID string, creatorColor string) (string, error) {
-	roomID := utils.GenerateRandomString(4)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	roomID, err := h.newRoomID()
+	if err != nil {
+		return "", err
+	}
 
 	room := NewRoom(h.users[creatorUserID], roomID, "")
 
-	err := room.AddUser(creatorColor, h.users[creatorUserID])
+	err = room.AddUser(creatorColor, h.users[creatorUserID])
 	if err != nil {
 		return "", ErrUnknownAtRoomCreation
 	}
@@ -92,6 +153,33 @@ func (h *Hub) NewRoom(creatorUserID string, creatorColor string) (string, error)
 	return roomID, nil
 }
 
+// newRoomID draws a room ID which is neither the one of a room nor the one of a room having rolled within roomIDReuse,
+// the older rolls being forgotten so that a new room does not show the rolls of another one. It gives up after
+// maxRoomIDAttempts draws, most of the IDs being taken.
+func (h *Hub) newRoomID() (string, error) {
+	ctx := context.Background()
+	for i := 0; i < maxRoomIDAttempts; i++ {
+		roomID := utils.GenerateRandomString(4)
+		if _, ok := h.rooms[roomID]; ok {
+			continue
+		}
+		rolls, err := h.history.List(ctx, roomID, 1)
+		if err == nil && len(rolls) > 0 {
+			if time.Since(rolls[0].Time) < roomIDReuse {
+				continue
+			}
+			err = h.history.Forget(ctx, roomID)
+		}
+		if err != nil {
+			h.logger.WithFields(log.Fields{
+				"roomID": roomID,
+			}).Warnf("Roll history not forgotten: %v", err)
+		}
+		return roomID, nil
+	}
+	return "", ErrNoRoomID
+}
+
 func (h *Hub) NewPrivateRoom(creatorUserID string, creatorColor string) (string, string, error) {
 	roomID, err := h.NewRoom(creatorUserID, creatorColor)
 	if err != nil {
@@ -99,7 +187,9 @@ func (h *Hub) NewPrivateRoom(creatorUserID string, creatorColor string) (string,
 	}
 	password := utils.GenerateRandomString(12)
 
+	h.mu.Lock()
 	err = h.rooms[roomID].SetPassword(password)
+	h.mu.Unlock()
 	if err != nil {
 		return "", "", errors.New("unknown problem at room locking")
 	}
@@ -108,24 +198,29 @@ func (h *Hub) NewPrivateRoom(creatorUserID string, creatorColor string) (string,
 }
 
 func (h *Hub) JoinRoom(roomID string, userID string, color string) error {
+	h.mu.Lock()
 	var room *Room
 	if r, ok := h.rooms[roomID]; !ok {
+		h.mu.Unlock()
 		return ErrRoomNotFound
 	} else {
 		room = r
 	}
 
 	if room.IsClosed() {
+		h.mu.Unlock()
 		return ErrRoomClosed
 	}
 
 	user := h.users[userID]
 
 	if err := room.AddUser(color, user); err != nil {
+		h.mu.Unlock()
 		return errors.Join(err, ErrUserCannotJoin)
 	}
 
 	user.SetRoom(roomID)
+	h.mu.Unlock()
 
 	userNickname := user.Nickname
 	if userNickname == "" {
@@ -138,9 +233,11 @@ func (h *Hub) JoinRoom(roomID string, userID string, color string) error {
 }
 
 func (h *Hub) JoinPrivateRoom(roomID string, userID string, password string, color string) error {
-	room := h.rooms[roomID]
+	h.mu.Lock()
+	room, ok := h.rooms[roomID]
+	h.mu.Unlock()
 
-	if password != room.password {
+	if !ok || password != room.password {
 		return ErrWrongRoomOrPassword
 	}
 
@@ -155,34 +252,148 @@ func (h *Hub) MessageUser(senderID string, recipentID string, content string) {
 	h.messages <- valueobjects.NewMessage(senderID, recipentID, []byte(content))
 }
 
+// MessageRoom sends the content to the participants of the room, if it still exists
 func (h *Hub) MessageRoom(roomID string, content string) {
-	for _, user := range h.rooms[roomID].participants {
-		h.messages <- valueobjects.NewServiceMessage(user.UserID, []byte(content))
+	// NOTE: the messages are sent once the lock is released, the runner taking it
+	h.mu.Lock()
+	var recipients []string
+	if room, ok := h.rooms[roomID]; ok {
+		for _, user := range room.participants {
+			recipients = append(recipients, user.UserID)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, recipient := range recipients {
+		h.messages <- valueobjects.NewServiceMessage(recipient, []byte(content))
+	}
+}
+
+// RollDice rolls the dice of the notation for the user, recording the roll in the history of their room and sending it
+// to every participant with the nickname of the user and the time
+func (h *Hub) RollDice(ctx context.Context, userID string, notation string) error {
+	e, err := dice.Parse(notation)
+	if err != nil {
+		return err
+	}
+
+	roll := newRoll(e, e.Roll(dice.DefaultSource))
+
+	h.mu.Lock()
+	user, ok := h.users[userID]
+	if !ok || !user.IsInRoom() {
+		h.mu.Unlock()
+		return ErrNotInRoom
+	}
+	nickname := user.Nickname
+	if nickname == "" {
+		nickname = userID
+	}
+	roll.Room = user.Room()
+	roll.Nickname = nickname
+	roll.Color = user.Color
+	roll.Time = time.Now().UTC()
+	h.mu.Unlock()
+
+	// NOTE: a roll not recorded is still sent, the history being a convenience
+	if err := h.history.Record(ctx, roll); err != nil {
+		h.logger.WithFields(log.Fields{
+			"roomID": roll.Room,
+		}).Warnf("Roll not recorded: %v", err)
+	}
+
+	content, err := json.Marshal(roll)
+	if err != nil {
+		return errors.Join(ErrUnexpected, err)
+	}
+	h.MessageRoom(roll.Room, rolled+string(content))
+
+	return nil
+}
+
+// RollHistory returns the last rolls of the room, from the oldest
+func (h *Hub) RollHistory(ctx context.Context, roomID string) ([]history.Roll, error) {
+	return h.history.List(ctx, roomID, history.MaxRolls)
+}
+
+func newRoll(e dice.Expression, result dice.Result) history.Roll {
+	roll := history.Roll{Expression: e.Notation, Total: result.Total, Groups: []history.Group{}}
+	for _, group := range result.Groups {
+		g := history.Group{Notation: group.Group.Notation, Total: group.Total, Dice: []int{}}
+		for _, die := range group.Dice {
+			if die.Dropped || die.Rerolled {
+				g.Discarded = append(g.Discarded, die.Value)
+			} else {
+				g.Dice = append(g.Dice, die.Value)
+			}
+		}
+		roll.Groups = append(roll.Groups, g)
+	}
+	return roll
+}
+
+// Ping checks that the runner is still processing the channels, failing when it does not answer before ctx is done
+func (h *Hub) Ping(ctx context.Context) error {
+	pong := make(chan struct{})
+
+	select {
+	case h.pings <- pong:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-pong:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the counts of the hub, read by the runner so that they do not race with its changes
+func (h *Hub) Stats(ctx context.Context) (Stats, error) {
+	// NOTE: buffered so that the runner never waits for a caller which gave up
+	reply := make(chan Stats, 1)
+
+	select {
+	case h.stats <- reply:
+	case <-ctx.Done():
+		return Stats{}, ctx.Err()
+	}
+
+	select {
+	case stats := <-reply:
+		return stats, nil
+	case <-ctx.Done():
+		return Stats{}, ctx.Err()
 	}
 }
 
 func (h *Hub) Run(ctx context.Context) {
 	go h.Routine(ctx)
+	go h.rolls.Run(ctx)
 
-	log.Debug("Hub runner starting...")
+	h.logger.Debug("Hub runner starting...")
 	for {
 		select {
 		case client := <-h.Register:
 			h.clients[client] = true
-			log.WithFields(log.Fields{
+			h.logger.WithFields(log.Fields{
 				"connectionsOpened": len(h.clients),
 			}).Debug("New client connected")
 		case client := <-h.unregister:
 			if _, ok := h.clients[client]; ok {
-				log.WithFields(log.Fields{
+				h.logger.WithFields(log.Fields{
 					"player": (*client).UserID(),
 				}).Debug("Unregistering client")
 
+				h.mu.Lock()
 				if client.UserID() != "" && h.users[client.UserID()].IsInRoom() {
 					h.users[client.UserID()].beginningGracePeriod = time.Now().Unix()
 				}
-				delete(h.clients, client)
 				delete(h.mappingUserIDToClient, client.UserID())
+				h.mu.Unlock()
+				delete(h.clients, client)
 				client.SetUserID("")
 			}
 		case message := <-h.messages:
@@ -191,65 +402,96 @@ func (h *Hub) Run(ctx context.Context) {
 					(*client).Send(message.Content())
 				}
 			} else {
-				if client, ok := h.mappingUserIDToClient[message.Recipient()]; ok {
+				h.mu.Lock()
+				client, ok := h.mappingUserIDToClient[message.Recipient()]
+				h.mu.Unlock()
+				if ok {
 					(*client).Send(message.Content())
 				}
 			}
+		case pong := <-h.pings:
+			close(pong)
+		case reply := <-h.stats:
+			h.mu.Lock()
+			reply <- Stats{
+				OnlineUsers: h.CountOnlineUsers(),
+				ActiveRooms: h.CountActiveRooms(),
+				TotalRooms:  h.CountTotalRooms(),
+			}
+			h.mu.Unlock()
 		case <-ctx.Done():
-			log.Info("Hub runner terminated...")
+			h.logger.Info("Hub runner terminated...")
 			return
 		}
 	}
 }
 
 func (h *Hub) Routine(ctx context.Context) {
-	log.Debug("Hub cleaning starting...")
+	h.logger.Debug("Hub cleaning starting...")
 	for {
 		select {
 		case <-ctx.Done():
-			log.Info("Hub runner terminated...")
+			h.logger.Info("Hub runner terminated...")
 			return
 		case <-time.After(30 * time.Second):
 			// Cleaning routine
-			log.Debug("Cleaning routine")
-			for roomID, room := range h.rooms {
-				log.WithFields(log.Fields{
-					"roomID": roomID,
-				}).Debug("Checking room")
-				if room.IsClosed() {
-					continue
-				}
-				if len(room.participants) == 0 {
-					room.Close()
-					continue
-				}
+			h.logger.Debug("Cleaning routine")
+			h.clean()
+		}
+	}
+}
 
-				participantsDeleted := make([]string, 0, len(room.participants))
-				participantsToNotify := make([]string, 0, len(room.participants))
-				for i, participant := range room.participants {
-					log.WithFields(log.Fields{
-						"color": i,
-					}).Debug("Checking user")
-					if participant.beginningGracePeriod+20 < time.Now().Unix() {
-						log.WithFields(log.Fields{
-							"color": i,
-							"grace": participant.beginningGracePeriod,
-							"now":   time.Now().Unix(),
-						}).Debug("Removing user")
-
-						participant.SetRoom("")
-						delete(room.participants, i)
-						participantsDeleted = append(participantsDeleted, i)
-					} else {
-						participantsToNotify = append(participantsToNotify, participant.UserID)
-					}
-				}
-				for _, participantToNotify := range participantsToNotify {
-					for _, participantDeleted := range participantsDeleted {
-						h.MessageUser(participantToNotify, participantToNotify, "userleft "+participantDeleted)
-					}
-				}
+// clean closes the rooms left empty and removes the participants whose grace period is over, notifying the others
+// once the lock is released
+func (h *Hub) clean() {
+	type departure struct {
+		recipient string
+		color     string
+	}
+	var departures []departure
+
+	h.mu.Lock()
+	for roomID, room := range h.rooms {
+		h.logger.WithFields(log.Fields{
+			"roomID": roomID,
+		}).Debug("Checking room")
+		if room.IsClosed() {
+			continue
+		}
+		if len(room.participants) == 0 {
+			room.Close()
+			continue
+		}
+
+		participantsDeleted := make([]string, 0, len(room.participants))
+		participantsToNotify := make([]string, 0, len(room.participants))
+		for i, participant := range room.participants {
+			h.logger.WithFields(log.Fields{
+				"color": i,
+			}).Debug("Checking user")
+			if participant.beginningGracePeriod+20 < time.Now().Unix() {
+				h.logger.WithFields(log.Fields{
+					"color": i,
+					"grace": participant.beginningGracePeriod,
+					"now":   time.Now().Unix(),
+				}).Debug("Removing user")
+
+				participant.SetRoom("")
+				delete(room.participants, i)
+				participantsDeleted = append(participantsDeleted, i)
+			} else {
+				participantsToNotify = append(participantsToNotify, participant.UserID)
+			}
+		}
+		for _, participantToNotify := range participantsToNotify {
+			for _, participantDeleted := range participantsDeleted {
+				departures = append(departures, departure{recipient: participantToNotify, color: participantDeleted})
 			}
 		}
 	}
+	h.mu.Unlock()
+
+	for _, d := range departures {
+		h.MessageUser(d.recipient, d.recipient, "userleft "+d.color)
+	}
 }
@@ -1,26 +1,32 @@
 package spectrum
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"math/rand"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 
-	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 	"utile.space/api/domain/valueobjects"
+	"utile.space/api/infrastructure/tracing"
 )
 
 const (
 	spectrum    = "spectrum "
 	newposition = "newposition "
+	rolled      = "rolled "
+	rollhistory = "history "
 )
 
 var (
 	newPositions = []string{"569,514", "509,521", "426,521", "514,566", "424,569", "382,523"}
-	r            = regexp.MustCompile(`^(emoji|signin|nickname|startspectrum|joinspectrum|leavespectrum|resetpositions|update|claim|makeadmin)(\s+([0-9a-f-]*))?(\s+([0-9]+,[0-9]+))?(\s+([\x{1F600}-\x{1F6FF}|[\x{2600}-\x{26FF}]|[\x{1FAE3}]|[\x{1F92F}]|[\x{1F91A}]|[\x{1F99D}]|[\x{1FAE1}]|[\x{1F6DF}]))?(\s+(.+))?$`)
+	r            = regexp.MustCompile(`^(emoji|signin|nickname|startspectrum|joinspectrum|leavespectrum|resetpositions|update|claim|makeadmin|roll|history)(\s+([0-9a-f-]*))?(\s+([0-9]+,[0-9]+))?(\s+([\x{1F600}-\x{1F6FF}|[\x{2600}-\x{26FF}]|[\x{1FAE3}]|[\x{1F92F}]|[\x{1F91A}]|[\x{1F99D}]|[\x{1FAE1}]|[\x{1F6DF}]))?(\s+(.+))?$`)
 )
 
 var (
@@ -28,16 +34,25 @@ var (
 	ErrCannotReachOpponent  = errors.New("cannot reach opponent")
 	ErrCannotParseCoords    = errors.New("cannot parse coords")
 	ErrUnexpected           = errors.New("unexpected error")
+	ErrTooManyRolls         = errors.New("too many rolls")
 )
 
 //nolint:gocyclo
-func (c *Client) EvaluateRPC(command string) error {
+func (c *Client) EvaluateRPC(ctx context.Context, command string) (err error) {
+	_, span := tracing.Start(ctx, "spectrum.rpc", attribute.Int("client.id", c.id))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
 	subMatch := r.FindStringSubmatch(command)
 	if subMatch == nil {
 		return errors.Join(ErrCommandNotRecognized, errors.New(command))
 	}
 
-	log.Debug("RPC " + subMatch[0])
+	span.SetName("spectrum.rpc " + subMatch[1])
+	span.SetAttributes(attribute.String("rpc.method", subMatch[1]), attribute.String("user.id", c.UserID()))
+
+	c.Logger().Debug("RPC " + subMatch[0])
 
 	switch {
 	case subMatch[1] == "emoji":
@@ -61,6 +76,7 @@ func (c *Client) EvaluateRPC(command string) error {
 			}
 			c.hub.MessageUser(c.UserID(), c.UserID(), newposition+c.hub.users[c.userID].LastPosition())
 			c.hub.MessageUser(c.UserID(), c.UserID(), "claim "+c.hub.rooms[roomID].Topic())
+			c.sendRollHistory(ctx, roomID)
 		}
 	case subMatch[1] == "nickname":
 		c.send <- valueobjects.RPC_ACK.Export()
@@ -84,7 +100,7 @@ func (c *Client) EvaluateRPC(command string) error {
 		err := c.hub.JoinRoom(roomID, c.UserID(), spt[2])
 		if err != nil {
 			// Nothing
-			log.Error(err.Error())
+			c.Logger().Error(err.Error())
 			c.send <- valueobjects.RPC_NACK.ExportWith(err.Error())
 		} else {
 			c.hub.users[c.UserID()].SetRoom(roomID)
@@ -99,6 +115,7 @@ func (c *Client) EvaluateRPC(command string) error {
 				c.send <- []byte("update " + participant.Color + " " + participant.LastPosition() + " " + participant.Nickname + adminUser)
 			}
 			c.hub.MessageUser(c.UserID(), c.UserID(), "claim "+c.hub.rooms[roomID].Topic())
+			c.sendRollHistory(ctx, roomID)
 		}
 	case subMatch[1] == "leavespectrum":
 		roomID := c.hub.users[c.userID].currentRoomID
@@ -145,9 +162,42 @@ func (c *Client) EvaluateRPC(command string) error {
 			c.hub.rooms[roomID].SetTopic(subMatch[9])
 			c.hub.MessageRoom(roomID, command)
 		}
+	case subMatch[1] == "roll":
+		if allowed, _, _ := c.hub.rolls.Take(strconv.Itoa(c.id), rollTier, 1); !allowed {
+			c.send <- valueobjects.RPC_NACK.ExportWith(ErrTooManyRolls.Error())
+			break
+		}
+		// NOTE: the notation is the rest of the command, the groups above not matching all of it
+		err := c.hub.RollDice(ctx, c.UserID(), strings.TrimSpace(strings.TrimPrefix(command, "roll")))
+		if err != nil {
+			c.send <- valueobjects.RPC_NACK.ExportWith(err.Error())
+		}
+	case subMatch[1] == "history":
+		if user, ok := c.hub.users[c.UserID()]; ok && user.IsInRoom() {
+			c.sendRollHistory(ctx, user.Room())
+		} else {
+			c.send <- valueobjects.RPC_NACK.ExportWith(ErrNotInRoom.Error())
+		}
 	default:
 		return ErrCommandNotRecognized
 	}
 
 	return nil
 }
+
+// sendRollHistory sends the last rolls of the room to the client, as a JSON array from the oldest
+func (c *Client) sendRollHistory(ctx context.Context, roomID string) {
+	rolls, err := c.hub.RollHistory(ctx, roomID)
+	if err != nil {
+		c.Logger().Warnf("Roll history error: %v", err)
+		c.send <- valueobjects.RPC_NACK.ExportWith(ErrUnexpected.Error())
+		return
+	}
+
+	content, err := json.Marshal(rolls)
+	if err != nil {
+		c.send <- valueobjects.RPC_NACK.ExportWith(ErrUnexpected.Error())
+		return
+	}
+	c.send <- []byte(rollhistory + string(content))
+}
@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"utile.space/api/infrastructure/logging"
+	"utile.space/api/utils"
+)
+
+const (
+	// APIKeyHeader carries the API key, which can also be given with the api_key query parameter
+	APIKeyHeader = "X-API-Key"
+	apiKeyQuery  = "api_key"
+
+	// ScopeAll grants every scope
+	ScopeAll = "*"
+)
+
+var (
+	ErrUnknownKey = errors.New("unknown API key")
+)
+
+// Key is an API key as registered in a store, only the SHA-256 hash of the secret being kept
+type Key struct {
+	ID     string   `json:"id" yaml:"id"`
+	Name   string   `json:"name" yaml:"name"`
+	Hash   string   `json:"-" yaml:"hash"`
+	Scopes []string `json:"scopes" yaml:"scopes"`
+	// Requests allowed per day, unlimited when 0.
+	Quota int64 `json:"quota" yaml:"quota"`
+}
+
+func (k *Key) HasScope(scope string) bool {
+	return scope == "" || slices.Contains(k.Scopes, ScopeAll) || slices.Contains(k.Scopes, scope)
+}
+
+// Usage is the number of requests made with a key on a given day
+type Usage struct {
+	KeyID    string
+	Name     string
+	Day      string
+	Requests int64
+	Quota    int64
+}
+
+// Store holds the API keys and counts their usage
+type Store interface {
+	// Find returns the key matching the given secret hash, or ErrUnknownKey
+	Find(ctx context.Context, hash string) (*Key, error)
+	// Increment counts one more request for the key on the given day and returns the count for that day
+	Increment(ctx context.Context, keyID string, day string) (int64, error)
+	// Usage lists the counters of all the keys
+	Usage(ctx context.Context) ([]Usage, error)
+	Close() error
+}
+
+// Hash returns the hash of a secret, as stored
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// OpenStore opens the store described as file:<path to YAML file> or sqlite:<path to database>,
+// no store meaning that any given API key is unknown
+func OpenStore(spec string) (Store, error) {
+	kind, path, _ := strings.Cut(spec, ":")
+
+	switch kind {
+	case "":
+		return NewFileStore(nil), nil
+	case "file":
+		return OpenFileStore(path)
+	case "sqlite":
+		return OpenSQLiteStore(path)
+	default:
+		return nil, fmt.Errorf("unknown API keys store %q", kind)
+	}
+}
+
+// Policy is the access rule of a route: the scope a key needs, and whether anonymous requests are allowed
+type Policy struct {
+	Scope     string
+	Anonymous bool
+}
+
+type contextKey struct{}
+
+// FromContext returns the key authenticating the request, nil for anonymous requests
+func FromContext(ctx context.Context) *Key {
+	key, _ := ctx.Value(contextKey{}).(*Key)
+	return key
+}
+
+// KeyID returns the ID of the key authenticating the request
+func KeyID(r *http.Request) (string, bool) {
+	if key := FromContext(r.Context()); key != nil {
+		return key.ID, true
+	}
+	return "", false
+}
+
+// Authenticator resolves the optional API key of the requests, enforcing the scopes, and the daily quotas with Quota
+type Authenticator struct {
+	store Store
+
+	// Policy of each route template, routes without one being open to anyone.
+	policies map[string]Policy
+
+	now func() time.Time
+}
+
+func NewAuthenticator(store Store, policies map[string]Policy) *Authenticator {
+	return &Authenticator{
+		store:    store,
+		policies: policies,
+		now:      time.Now,
+	}
+}
+
+func (a *Authenticator) policy(r *http.Request) Policy {
+	if policy, ok := a.policies[utils.RouteTemplate(r)]; ok {
+		return policy
+	}
+	return Policy{Anonymous: true}
+}
+
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept := r.Header["Accept"]
+		policy := a.policy(r)
+
+		secret := r.Header.Get(APIKeyHeader)
+		if secret == "" {
+			secret = r.URL.Query().Get(apiKeyQuery)
+		}
+
+		if secret == "" {
+			if !policy.Anonymous {
+				utils.OutputError(w, accept, http.StatusUnauthorized, "API key required")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, err := a.store.Find(r.Context(), Hash(secret))
+		if errors.Is(err, ErrUnknownKey) {
+			utils.OutputError(w, accept, http.StatusUnauthorized, "Unknown API key")
+			return
+		}
+		if err != nil {
+			logging.FromContext(r.Context()).Errorf("API key lookup error: %v", err)
+			utils.OutputError(w, accept, http.StatusInternalServerError, "Internal Server Error")
+			return
+		}
+
+		if !key.HasScope(policy.Scope) {
+			utils.OutputError(w, accept, http.StatusForbidden, "API key lacks the "+policy.Scope+" scope")
+			return
+		}
+
+		logger := logging.FromContext(r.Context()).WithField("api_key", key.ID)
+		ctx := logging.NewContext(context.WithValue(r.Context(), contextKey{}, key), logger)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Quota counts the requests of the key resolved by Middleware against its daily quota, to be used after the middlewares
+// which may still reject the request, like the rate limiter, so that only the requests served are counted
+func (a *Authenticator) Quota(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := FromContext(r.Context())
+		if key == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		count, err := a.store.Increment(r.Context(), key.ID, a.now().UTC().Format(time.DateOnly))
+		if err != nil {
+			logging.FromContext(r.Context()).Errorf("API key usage error: %v", err)
+		} else if key.Quota > 0 && count > key.Quota {
+			utils.OutputError(w, r.Header["Accept"], http.StatusTooManyRequests, "Daily quota exceeded")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Usage lists the counters of the store
+func (a *Authenticator) Usage(ctx context.Context) ([]Usage, error) {
+	return a.store.Usage(ctx)
+}
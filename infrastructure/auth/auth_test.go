@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Middleware(t *testing.T) {
+	store := NewFileStore([]*Key{
+		{ID: "player", Hash: Hash("player-secret"), Scopes: []string{"games"}, Quota: 2},
+		{ID: "admin", Hash: Hash("admin-secret"), Scopes: []string{ScopeAll}},
+	})
+	authenticator := NewAuthenticator(store, map[string]Policy{
+		"/api/battleships/stats": {Scope: "games", Anonymous: true},
+		"/api/math/ws":           {Scope: "math:ws", Anonymous: true},
+		"/api/admin/usage":       {Scope: "admin"},
+	})
+
+	router := mux.NewRouter()
+	router.Use(authenticator.Middleware)
+	router.Use(authenticator.Quota)
+	for _, path := range []string{"/api/battleships/stats", "/api/math/ws", "/api/admin/usage"} {
+		router.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {})
+	}
+
+	serve := func(path string, secret string) int {
+		recorder := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if secret != "" {
+			r.Header.Set(APIKeyHeader, secret)
+		}
+		router.ServeHTTP(recorder, r)
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("/api/math/ws", ""))
+	assert.Equal(t, http.StatusUnauthorized, serve("/api/admin/usage", ""))
+	assert.Equal(t, http.StatusUnauthorized, serve("/api/math/ws", "wrong-secret"))
+	assert.Equal(t, http.StatusForbidden, serve("/api/math/ws", "player-secret"))
+	assert.Equal(t, http.StatusForbidden, serve("/api/admin/usage", "player-secret"))
+	assert.Equal(t, http.StatusOK, serve("/api/battleships/stats", "player-secret"))
+	assert.Equal(t, http.StatusOK, serve("/api/battleships/stats", "player-secret"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("/api/battleships/stats", "player-secret"))
+	assert.Equal(t, http.StatusOK, serve("/api/admin/usage?api_key=admin-secret", ""))
+
+	usage, err := authenticator.Usage(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, usage, 2)
+}
+
+func Test_QuotaAfterRejection(t *testing.T) {
+	store := NewFileStore([]*Key{{ID: "player", Hash: Hash("player-secret"), Quota: 1}})
+	authenticator := NewAuthenticator(store, nil)
+
+	// NOTE: the rate limiter rejecting the first request, between the key resolution and the quota
+	rejected := true
+	router := mux.NewRouter()
+	router.Use(authenticator.Middleware)
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if rejected {
+				rejected = false
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	router.Use(authenticator.Quota)
+	router.HandleFunc("/api/status", func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func() int {
+		recorder := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+		r.Header.Set(APIKeyHeader, "player-secret")
+		router.ServeHTTP(recorder, r)
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusTooManyRequests, serve())
+	assert.Equal(t, http.StatusOK, serve())
+	assert.Equal(t, http.StatusTooManyRequests, serve())
+}
+
+func Test_SQLiteStore(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := OpenSQLiteStore(filepath.Join(t.TempDir(), "keys.db"))
+	assert.NoError(t, err)
+	defer store.Close()
+
+	assert.NoError(t, store.Add(ctx, &Key{ID: "sonny", Name: "Sonny", Hash: Hash("secret"), Scopes: []string{"games", "math:ws"}, Quota: 10}))
+
+	_, err = store.Find(ctx, Hash("other"))
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	key, err := store.Find(ctx, Hash("secret"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"games", "math:ws"}, key.Scopes)
+
+	for i := int64(1); i <= 3; i++ {
+		count, err := store.Increment(ctx, key.ID, "2026-10-19")
+		assert.NoError(t, err)
+		assert.Equal(t, i, count)
+	}
+
+	usage, err := store.Usage(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []Usage{{KeyID: "sonny", Name: "Sonny", Day: "2026-10-19", Requests: 3, Quota: 10}}, usage)
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"sort"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// FileStore reads the keys from a YAML file, the usage counters being kept in memory only
+type FileStore struct {
+	mu    sync.Mutex
+	keys  map[string]*Key
+	usage map[string]map[string]int64
+}
+
+type keysFile struct {
+	Keys []*Key `yaml:"keys"`
+}
+
+func NewFileStore(keys []*Key) *FileStore {
+	s := &FileStore{
+		keys:  make(map[string]*Key),
+		usage: make(map[string]map[string]int64),
+	}
+	for _, key := range keys {
+		s.keys[key.Hash] = key
+	}
+	return s
+}
+
+// OpenFileStore loads a file listing the keys as:
+//
+//	keys:
+//	  - id: sonny
+//	    name: Sonny
+//	    hash: <SHA-256 of the key, hex encoded>
+//	    scopes: [games, math:ws]
+//	    quota: 10000
+func OpenFileStore(path string) (*FileStore, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file keysFile
+	if err := yaml.UnmarshalStrict(content, &file); err != nil {
+		return nil, err
+	}
+
+	return NewFileStore(file.Keys), nil
+}
+
+func (s *FileStore) Find(_ context.Context, hash string) (*Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[hash]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+func (s *FileStore) Increment(_ context.Context, keyID string, day string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.usage[keyID]; !ok {
+		s.usage[keyID] = make(map[string]int64)
+	}
+	s.usage[keyID][day]++
+
+	return s.usage[keyID][day], nil
+}
+
+func (s *FileStore) Usage(_ context.Context) ([]Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	usage := make([]Usage, 0)
+	for _, key := range s.keys {
+		for day, requests := range s.usage[key.ID] {
+			usage = append(usage, Usage{
+				KeyID:    key.ID,
+				Name:     key.Name,
+				Day:      day,
+				Requests: requests,
+				Quota:    key.Quota,
+			})
+		}
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Day != usage[j].Day {
+			return usage[i].Day > usage[j].Day
+		}
+		return usage[i].KeyID < usage[j].KeyID
+	})
+
+	return usage, nil
+}
+
+func (s *FileStore) Close() error {
+	return nil
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	// NOTE: pure Go driver, the image being built without cgo
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS api_keys (
+	id     TEXT PRIMARY KEY,
+	name   TEXT NOT NULL DEFAULT '',
+	hash   TEXT NOT NULL UNIQUE,
+	scopes TEXT NOT NULL DEFAULT '',
+	quota  INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS api_usage (
+	key_id   TEXT NOT NULL REFERENCES api_keys(id),
+	day      TEXT NOT NULL,
+	requests INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (key_id, day)
+);`
+
+// SQLiteStore keeps the keys and their usage in a SQLite database, scopes being stored comma separated
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func OpenSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: SQLite only supports one writer at a time
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// Add registers a key
+func (s *SQLiteStore) Add(ctx context.Context, key *Key) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO api_keys (id, name, hash, scopes, quota) VALUES (?, ?, ?, ?, ?)",
+		key.ID, key.Name, key.Hash, strings.Join(key.Scopes, ","), key.Quota)
+	return err
+}
+
+func (s *SQLiteStore) Find(ctx context.Context, hash string) (*Key, error) {
+	var key Key
+	var scopes string
+
+	err := s.db.QueryRowContext(ctx, "SELECT id, name, hash, scopes, quota FROM api_keys WHERE hash = ?", hash).
+		Scan(&key.ID, &key.Name, &key.Hash, &scopes, &key.Quota)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUnknownKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if scopes != "" {
+		key.Scopes = strings.Split(scopes, ",")
+	}
+
+	return &key, nil
+}
+
+func (s *SQLiteStore) Increment(ctx context.Context, keyID string, day string) (int64, error) {
+	var requests int64
+
+	err := s.db.QueryRowContext(ctx, `INSERT INTO api_usage (key_id, day, requests) VALUES (?, ?, 1)
+		ON CONFLICT (key_id, day) DO UPDATE SET requests = requests + 1
+		RETURNING requests`, keyID, day).Scan(&requests)
+
+	return requests, err
+}
+
+func (s *SQLiteStore) Usage(ctx context.Context) ([]Usage, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT k.id, k.name, u.day, u.requests, k.quota
+		FROM api_usage u JOIN api_keys k ON k.id = u.key_id
+		ORDER BY u.day DESC, k.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := make([]Usage, 0)
+	for rows.Next() {
+		var u Usage
+		if err := rows.Scan(&u.KeyID, &u.Name, &u.Day, &u.Requests, &u.Quota); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+
+	return usage, rows.Err()
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
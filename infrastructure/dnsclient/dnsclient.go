@@ -0,0 +1,149 @@
+package dnsclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.opentelemetry.io/otel/attribute"
+	"utile.space/api/infrastructure/metrics"
+	"utile.space/api/infrastructure/tracing"
+)
+
+// Upstream is the DNS server every lookup of the API is sent to
+const Upstream = "1.1.1.1:53"
+
+// Resolver returns a net.Resolver querying Upstream
+func Resolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			d := net.Dialer{
+				Timeout: time.Millisecond * time.Duration(10000),
+			}
+			conn, err := d.DialContext(ctx, network, Upstream)
+			if err != nil {
+				return nil, err
+			}
+
+			// NOTE: over UDP each read is a whole DNS message, which lets us peek at the TTLs the resolver drops
+			if collector, ok := ctx.Value(ttlKey{}).(*ttlCollector); ok && network == "udp" {
+				return &ttlConn{Conn: conn, collector: collector}, nil
+			}
+			return conn, nil
+		},
+	}
+}
+
+type ttlKey struct{}
+
+// ttlCollector keeps the lowest TTL of the answers received during a lookup
+type ttlCollector struct {
+	mu   sync.Mutex
+	min  uint32
+	seen bool
+}
+
+func (c *ttlCollector) observe(records []dns.RR) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, record := range records {
+		if !c.seen || record.Header().Ttl < c.min {
+			c.min = record.Header().Ttl
+			c.seen = true
+		}
+	}
+}
+
+func (c *ttlCollector) ttl() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return time.Duration(c.min) * time.Second
+}
+
+type ttlConn struct {
+	net.Conn
+	collector *ttlCollector
+}
+
+func (c *ttlConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		var m dns.Msg
+		if m.Unpack(b[:n]) == nil {
+			c.collector.observe(m.Answer)
+		}
+	}
+	return n, err
+}
+
+// MinTTL returns the lowest TTL of the records, for how long the answer can be cached
+func MinTTL(records []dns.RR) time.Duration {
+	var collector ttlCollector
+	collector.observe(records)
+	return collector.ttl()
+}
+
+// ObserveLookup starts a span for a net.Resolver lookup, the returned function ending it, recording the
+// lookup metrics, the response code being only exposed through the error, and returning the lowest TTL of the answers
+func ObserveLookup(ctx context.Context, recordType string, domain string) (context.Context, func(error) time.Duration) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "dns.lookup "+recordType, attribute.String("dns.question.type", recordType), attribute.String("dns.question.name", domain))
+
+	collector := &ttlCollector{}
+	ctx = context.WithValue(ctx, ttlKey{}, collector)
+
+	return ctx, func(err error) time.Duration {
+		rcode := dns.RcodeToString[dns.RcodeSuccess]
+
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			rcode = dns.RcodeToString[dns.RcodeNameError]
+		} else if errors.As(err, &dnsErr) && dnsErr.IsTimeout {
+			rcode = "TIMEOUT"
+		} else if err != nil {
+			rcode = dns.RcodeToString[dns.RcodeServerFailure]
+		}
+
+		metrics.ObserveDNS(recordType, rcode, start)
+		span.SetAttributes(attribute.String("dns.rcode", rcode))
+		tracing.End(span, err)
+
+		return collector.ttl()
+	}
+}
+
+// Exchange sends the message to Upstream, recording the metrics and a span of the exchange
+func Exchange(ctx context.Context, m *dns.Msg, recordType string) (*dns.Msg, error) {
+	c := new(dns.Client)
+
+	ctx, span := tracing.Start(ctx, "dns.exchange "+recordType, attribute.String("dns.question.type", recordType), attribute.String("dns.question.name", m.Question[0].Name))
+
+	start := time.Now()
+	result, _, err := c.ExchangeContext(ctx, m, Upstream)
+	if err != nil {
+		metrics.ObserveDNS(recordType, "ERROR", start)
+		tracing.End(span, err)
+		return nil, err
+	}
+	metrics.ObserveDNS(recordType, dns.RcodeToString[result.Rcode], start)
+	span.SetAttributes(attribute.String("dns.rcode", dns.RcodeToString[result.Rcode]))
+	tracing.End(span, nil)
+
+	return result, nil
+}
+
+// Check asks Upstream for the root name servers, any answer meaning it can be reached
+func Check(ctx context.Context) error {
+	m := new(dns.Msg)
+	m.SetQuestion(".", dns.TypeNS)
+
+	c := new(dns.Client)
+	_, _, err := c.ExchangeContext(ctx, m, Upstream)
+	return err
+}
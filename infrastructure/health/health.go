@@ -0,0 +1,157 @@
+package health
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	Up       Status = "up"
+	Degraded Status = "degraded"
+	Down     Status = "down"
+)
+
+// Commit can be set at build time with -ldflags "-X utile.space/api/infrastructure/health.Commit=...",
+// when the binary is built without the VCS information
+var Commit string
+
+var started = time.Now()
+
+// Check reports an error when the dependency it checks cannot be used
+type Check func(ctx context.Context) error
+
+type Checker struct {
+	Name  string
+	Check Check
+	// Critical checks failing take the probe down, the other ones only degrade it.
+	Critical bool
+}
+
+type Result struct {
+	Name     string
+	Status   Status
+	Duration time.Duration
+	Error    string
+}
+
+type Report struct {
+	Status Status
+	Checks []Result
+}
+
+type BuildInfo struct {
+	Version   string
+	Commit    string
+	GoVersion string
+	Uptime    time.Duration
+}
+
+// Build describes the running binary
+func Build() BuildInfo {
+	info := BuildInfo{
+		Version:   os.Getenv("API_VERSION"),
+		Commit:    Commit,
+		GoVersion: runtime.Version(),
+		Uptime:    time.Since(started),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			if setting.Key == "vcs.revision" {
+				info.Commit = setting.Value
+			}
+		}
+	}
+
+	return info
+}
+
+// Probe runs its checkers concurrently, each one being given at most timeout
+type Probe struct {
+	mu       sync.RWMutex
+	checkers []Checker
+	timeout  time.Duration
+}
+
+func NewProbe(timeout time.Duration) *Probe {
+	return &Probe{timeout: timeout}
+}
+
+func (p *Probe) Add(checker Checker) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.checkers = append(p.checkers, checker)
+}
+
+func (p *Probe) Run(ctx context.Context) Report {
+	p.mu.RLock()
+	checkers := p.checkers
+	p.mu.RUnlock()
+
+	report := Report{Status: Up, Checks: make([]Result, len(checkers))}
+
+	var wg sync.WaitGroup
+	for i := range checkers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			report.Checks[i] = p.run(ctx, checkers[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i, result := range report.Checks {
+		if result.Status == Up {
+			continue
+		}
+		if checkers[i].Critical {
+			report.Status = Down
+		} else if report.Status == Up {
+			report.Status = Degraded
+		}
+	}
+
+	return report
+}
+
+func (p *Probe) run(ctx context.Context, checker Checker) Result {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := checker.Check(ctx)
+
+	result := Result{Name: checker.Name, Status: Up, Duration: time.Since(start)}
+	if err != nil {
+		result.Status = Down
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Cached runs check at most once per ttl, for the dependencies which should not be called at every probe
+func Cached(ttl time.Duration, check Check) Check {
+	var mu sync.Mutex
+	var last time.Time
+	var lastErr error
+
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !last.IsZero() && time.Since(last) < ttl {
+			return lastErr
+		}
+
+		lastErr = check(ctx)
+		last = time.Now()
+		return lastErr
+	}
+}
@@ -0,0 +1,80 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ProbeRun(t *testing.T) {
+	up := func(ctx context.Context) error { return nil }
+	failing := func(ctx context.Context) error { return errors.New("unreachable") }
+	hanging := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tt := map[string]struct {
+		checkers []Checker
+		expected Status
+	}{
+		"all up": {
+			checkers: []Checker{{Name: "a", Check: up, Critical: true}, {Name: "b", Check: up}},
+			expected: Up,
+		},
+		"optional check failing": {
+			checkers: []Checker{{Name: "a", Check: up, Critical: true}, {Name: "b", Check: failing}},
+			expected: Degraded,
+		},
+		"critical check failing": {
+			checkers: []Checker{{Name: "a", Check: failing, Critical: true}, {Name: "b", Check: failing}},
+			expected: Down,
+		},
+		"critical check timing out": {
+			checkers: []Checker{{Name: "a", Check: hanging, Critical: true}},
+			expected: Down,
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			probe := NewProbe(10 * time.Millisecond)
+			for _, checker := range tc.checkers {
+				probe.Add(checker)
+			}
+
+			report := probe.Run(context.Background())
+
+			assert.Equal(t, tc.expected, report.Status)
+			assert.Len(t, report.Checks, len(tc.checkers))
+			for i, check := range report.Checks {
+				assert.Equal(t, tc.checkers[i].Name, check.Name)
+			}
+		})
+	}
+}
+
+func Test_Cached(t *testing.T) {
+	calls := 0
+	check := Cached(time.Hour, func(ctx context.Context) error {
+		calls++
+		return errors.New("unreachable")
+	})
+
+	assert.Error(t, check(context.Background()))
+	assert.Error(t, check(context.Background()))
+	assert.Equal(t, 1, calls)
+}
+
+func Test_Build(t *testing.T) {
+	t.Setenv("API_VERSION", "1.2.3")
+
+	build := Build()
+
+	assert.Equal(t, "1.2.3", build.Version)
+	assert.NotEmpty(t, build.GoVersion)
+	assert.Greater(t, build.Uptime, time.Duration(0))
+}
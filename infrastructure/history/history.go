@@ -0,0 +1,138 @@
+package history
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxRolls bounds the rolls listed at once, and the rolls kept by room in memory
+	MaxRolls = 100
+
+	// MaxRooms bounds the rooms whose rolls are kept in memory
+	MaxRooms = 1000
+
+	// MaxAge bounds the age of the rolls kept in a database
+	MaxAge = 30 * 24 * time.Hour
+)
+
+// Roll is a roll made in a room, by the participant with the nickname and the color
+type Roll struct {
+	Room       string    `json:"room"`
+	Nickname   string    `json:"nickname"`
+	Color      string    `json:"color"`
+	Expression string    `json:"expression"`
+	Total      int       `json:"total"`
+	Groups     []Group   `json:"groups"`
+	Time       time.Time `json:"time"`
+}
+
+// Group is a group of dice or a number of a roll, the dice dropped or rerolled being discarded
+type Group struct {
+	Notation  string `json:"notation"`
+	Total     int    `json:"total"`
+	Dice      []int  `json:"dice"`
+	Discarded []int  `json:"discarded,omitempty"`
+}
+
+// Store keeps the rolls of the rooms
+type Store interface {
+	// Record adds a roll to the history of its room
+	Record(ctx context.Context, roll Roll) error
+	// List returns the last rolls of a room, at most limit capped by MaxRolls, from the oldest
+	List(ctx context.Context, room string, limit int) ([]Roll, error)
+	// Forget removes the rolls of a room
+	Forget(ctx context.Context, room string) error
+	Close() error
+}
+
+// OpenStore opens the store described as memory or sqlite:<path to database>, no store meaning memory
+func OpenStore(spec string) (Store, error) {
+	kind, path, _ := strings.Cut(spec, ":")
+
+	switch kind {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "sqlite":
+		return OpenSQLiteStore(path)
+	default:
+		return nil, fmt.Errorf("unknown roll history store %q", kind)
+	}
+}
+
+type roomRolls struct {
+	room  string
+	rolls []Roll
+}
+
+// MemoryStore keeps the last MaxRolls rolls of the last MaxRooms rooms having rolled, until the process exits
+type MemoryStore struct {
+	mu    sync.Mutex
+	rooms map[string]*list.Element
+
+	// Rooms from the last one having rolled, holding *roomRolls.
+	order *list.List
+
+	maxRooms int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{rooms: make(map[string]*list.Element), order: list.New(), maxRooms: MaxRooms}
+}
+
+// Record adds the roll, forgetting the rolls of the room which rolled the longest ago when too many rooms are kept
+func (s *MemoryStore) Record(_ context.Context, roll Roll) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.rooms[roll.Room]
+	if ok {
+		s.order.MoveToFront(e)
+	} else {
+		e = s.order.PushFront(&roomRolls{room: roll.Room})
+		s.rooms[roll.Room] = e
+		if s.order.Len() > s.maxRooms {
+			oldest := s.order.Remove(s.order.Back()).(*roomRolls)
+			delete(s.rooms, oldest.room)
+		}
+	}
+
+	r := e.Value.(*roomRolls)
+	r.rolls = append(r.rolls, roll)
+	if len(r.rolls) > MaxRolls {
+		r.rolls = r.rolls[len(r.rolls)-MaxRolls:]
+	}
+	return nil
+}
+
+func (s *MemoryStore) List(_ context.Context, room string, limit int) ([]Roll, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.rooms[room]
+	if !ok {
+		return []Roll{}, nil
+	}
+	rolls := e.Value.(*roomRolls).rolls
+	limit = max(0, min(limit, MaxRolls, len(rolls)))
+	return append([]Roll{}, rolls[len(rolls)-limit:]...), nil
+}
+
+func (s *MemoryStore) Forget(_ context.Context, room string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.rooms[room]; ok {
+		s.order.Remove(e)
+		delete(s.rooms, room)
+	}
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
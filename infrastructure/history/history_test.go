@@ -0,0 +1,148 @@
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Stores(t *testing.T) {
+	sqlite, err := OpenSQLiteStore(filepath.Join(t.TempDir(), "rolls.db"))
+	assert.NoError(t, err)
+	defer sqlite.Close()
+	sqlite.now = func() time.Time { return time.Date(2026, 10, 19, 21, 0, 0, 0, time.UTC) }
+
+	tt := map[string]struct {
+		store Store
+	}{
+		"memory": {store: NewMemoryStore()},
+		"sqlite": {store: sqlite},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			at := time.Date(2026, 10, 19, 20, 30, 0, 0, time.UTC)
+
+			for i := 1; i <= MaxRolls+2; i++ {
+				assert.NoError(t, tc.store.Record(ctx, Roll{
+					Room:       "ABCD",
+					Nickname:   "Sonny",
+					Color:      "ff5555",
+					Expression: "4d6kh3+" + strconv.Itoa(i),
+					Total:      i,
+					Groups: []Group{
+						{Notation: "4d6kh3", Total: 10, Dice: []int{6, 3, 1}, Discarded: []int{1}},
+						{Notation: strconv.Itoa(i), Total: i, Dice: []int{}},
+					},
+					Time: at.Add(time.Duration(i) * time.Second),
+				}))
+			}
+			assert.NoError(t, tc.store.Record(ctx, Roll{Room: "EFGH", Expression: "d20", Total: 20, Groups: []Group{}, Time: at}))
+
+			rolls, err := tc.store.List(ctx, "ABCD", 2)
+			assert.NoError(t, err)
+			assert.Equal(t, []Roll{
+				{
+					Room:       "ABCD",
+					Nickname:   "Sonny",
+					Color:      "ff5555",
+					Expression: "4d6kh3+101",
+					Total:      101,
+					Groups: []Group{
+						{Notation: "4d6kh3", Total: 10, Dice: []int{6, 3, 1}, Discarded: []int{1}},
+						{Notation: "101", Total: 101, Dice: []int{}},
+					},
+					Time: at.Add(101 * time.Second),
+				},
+				{
+					Room:       "ABCD",
+					Nickname:   "Sonny",
+					Color:      "ff5555",
+					Expression: "4d6kh3+102",
+					Total:      102,
+					Groups: []Group{
+						{Notation: "4d6kh3", Total: 10, Dice: []int{6, 3, 1}, Discarded: []int{1}},
+						{Notation: "102", Total: 102, Dice: []int{}},
+					},
+					Time: at.Add(102 * time.Second),
+				},
+			}, rolls)
+
+			rolls, err = tc.store.List(ctx, "ABCD", 1000)
+			assert.NoError(t, err)
+			assert.Len(t, rolls, MaxRolls)
+			assert.Equal(t, 3, rolls[0].Total)
+
+			rolls, err = tc.store.List(ctx, "IJKL", 10)
+			assert.NoError(t, err)
+			assert.Empty(t, rolls)
+
+			assert.NoError(t, tc.store.Forget(ctx, "ABCD"))
+			rolls, err = tc.store.List(ctx, "ABCD", 10)
+			assert.NoError(t, err)
+			assert.Empty(t, rolls)
+			rolls, err = tc.store.List(ctx, "EFGH", 10)
+			assert.NoError(t, err)
+			assert.Len(t, rolls, 1)
+		})
+	}
+}
+
+func Test_MemoryStoreRooms(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	store.maxRooms = 2
+
+	for _, room := range []string{"ABCD", "EFGH", "ABCD", "IJKL"} {
+		assert.NoError(t, store.Record(ctx, Roll{Room: room, Expression: "d20", Total: 20, Groups: []Group{}}))
+	}
+
+	tt := map[string]struct {
+		room     string
+		expected int
+	}{
+		"rolled again":    {room: "ABCD", expected: 2},
+		"rolled last":     {room: "IJKL", expected: 1},
+		"rolled earliest": {room: "EFGH", expected: 0},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			rolls, err := store.List(ctx, tc.room, MaxRolls)
+			assert.NoError(t, err)
+			assert.Len(t, rolls, tc.expected)
+		})
+	}
+}
+
+func Test_SQLiteStoreRemovals(t *testing.T) {
+	ctx := context.Background()
+	store, err := OpenSQLiteStore(filepath.Join(t.TempDir(), "rolls.db"))
+	assert.NoError(t, err)
+	defer store.Close()
+	now := time.Date(2026, 10, 19, 20, 30, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	assert.NoError(t, store.Record(ctx, Roll{Room: "OLD", Expression: "d20", Total: 1, Groups: []Group{}, Time: now.Add(-MaxAge)}))
+	for i := 0; i < MaxRolls+5; i++ {
+		assert.NoError(t, store.Record(ctx, Roll{Room: "ABCD", Expression: "d20", Total: i, Groups: []Group{}, Time: now}))
+	}
+
+	count := func(room string) int {
+		var n int
+		assert.NoError(t, store.db.QueryRow("SELECT COUNT(*) FROM rolls WHERE room = ?", room).Scan(&n))
+		return n
+	}
+	assert.Equal(t, MaxRolls, count("ABCD"))
+	assert.Equal(t, 1, count("OLD"))
+
+	now = now.Add(time.Second)
+	assert.NoError(t, store.Record(ctx, Roll{Room: "ABCD", Expression: "d20", Total: 20, Groups: []Group{}, Time: now}))
+	assert.Equal(t, MaxRolls, count("ABCD"))
+	assert.Equal(t, 0, count("OLD"))
+}
@@ -0,0 +1,119 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	// NOTE: pure Go driver, the image being built without cgo
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS rolls (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	room        TEXT NOT NULL,
+	nickname    TEXT NOT NULL DEFAULT '',
+	color       TEXT NOT NULL DEFAULT '',
+	expression  TEXT NOT NULL,
+	total       INTEGER NOT NULL,
+	dice_groups TEXT NOT NULL,
+	time        TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS rolls_room ON rolls (room, id);
+CREATE INDEX IF NOT EXISTS rolls_time ON rolls (time);`
+
+// timeLayout writes the times with every digit of the nanoseconds, so that they are sorted as text
+const timeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// SQLiteStore keeps the last MaxRolls rolls of each room in a SQLite database for MaxAge, the groups being stored as JSON
+type SQLiteStore struct {
+	db *sql.DB
+
+	now func() time.Time
+}
+
+func OpenSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: SQLite only supports one writer at a time
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db, now: time.Now}, nil
+}
+
+func (s *SQLiteStore) Record(ctx context.Context, roll Roll) error {
+	groups, err := json.Marshal(roll.Groups)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO rolls (room, nickname, color, expression, total, dice_groups, time) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		roll.Room, roll.Nickname, roll.Color, roll.Expression, roll.Total, string(groups), roll.Time.UTC().Format(timeLayout)); err != nil {
+		return err
+	}
+
+	// NOTE: the rolls of the room before the last MaxRolls ones, and the rolls of every room older than MaxAge, are removed
+	if _, err := tx.ExecContext(ctx, `DELETE FROM rolls WHERE room = ? AND id <= (
+		SELECT id FROM rolls WHERE room = ? ORDER BY id DESC LIMIT 1 OFFSET ?
+	)`, roll.Room, roll.Room, MaxRolls); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM rolls WHERE time < ?", s.now().Add(-MaxAge).UTC().Format(timeLayout)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) List(ctx context.Context, room string, limit int) ([]Roll, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT room, nickname, color, expression, total, dice_groups, time FROM (
+		SELECT * FROM rolls WHERE room = ? ORDER BY id DESC LIMIT ?
+	) ORDER BY id`, room, max(0, min(limit, MaxRolls)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rolls := make([]Roll, 0)
+	for rows.Next() {
+		var roll Roll
+		var groups, at string
+		if err := rows.Scan(&roll.Room, &roll.Nickname, &roll.Color, &roll.Expression, &roll.Total, &groups, &at); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(groups), &roll.Groups); err != nil {
+			return nil, err
+		}
+		if roll.Time, err = time.Parse(time.RFC3339Nano, at); err != nil {
+			return nil, err
+		}
+		rolls = append(rolls, roll)
+	}
+
+	return rolls, rows.Err()
+}
+
+func (s *SQLiteStore) Forget(ctx context.Context, room string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM rolls WHERE room = ?", room)
+	return err
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader is read from the incoming requests and always set on the responses
+const RequestIDHeader = "X-Request-ID"
+
+type contextKey struct{}
+
+// NOTE: client provided request IDs end up in the logs, so only reasonable ones are kept
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// Configure sets the level (LOG_LEVEL, debug by default) and the format (LOG_FORMAT, text or json) of the logger
+func Configure() error {
+	level := log.DebugLevel
+	if value, present := os.LookupEnv("LOG_LEVEL"); present {
+		parsed, err := log.ParseLevel(value)
+		if err != nil {
+			return err
+		}
+		level = parsed
+	}
+	log.SetLevel(level)
+
+	switch format := os.Getenv("LOG_FORMAT"); format {
+	case "", "text":
+		log.SetFormatter(&log.TextFormatter{})
+	case "json":
+		log.SetFormatter(&log.JSONFormatter{})
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+
+	return nil
+}
+
+// Logger returns the base logger, to be given to the long running components like the hubs
+func Logger(component string) *log.Entry {
+	return log.WithField("component", component)
+}
+
+// NewContext returns a copy of ctx carrying the logger
+func NewContext(ctx context.Context, logger *log.Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger of the request, with its request ID, or the base logger
+func FromContext(ctx context.Context) *log.Entry {
+	if logger, ok := ctx.Value(contextKey{}).(*log.Entry); ok {
+		return logger
+	}
+	return log.NewEntry(log.StandardLogger())
+}
+
+// RequestID is a middleware reusing the X-Request-ID of the request or generating one,
+// echoing it in the response and attaching a logger carrying it to the request context
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+
+		logger := log.WithField("request_id", requestID)
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), logger)))
+	})
+}
+
+// AccessLog is a middleware logging one structured line per request once it is served
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m := httpsnoop.CaptureMetrics(next, w, r)
+
+		var route string
+		if current := mux.CurrentRoute(r); current != nil {
+			route, _ = current.GetPathTemplate()
+		}
+
+		FromContext(r.Context()).WithFields(log.Fields{
+			"method":     r.Method,
+			"path":       r.URL.Path,
+			"route":      route,
+			"status":     m.Code,
+			"bytes":      m.Written,
+			"duration":   m.Duration.Seconds(),
+			"remote":     r.RemoteAddr,
+			"user_agent": r.UserAgent(),
+		}).Info("request served")
+	})
+}
@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RequestID(t *testing.T) {
+	tt := map[string]struct {
+		header   string
+		expected string
+	}{
+		"honored": {
+			header:   "f81d4fae-7dec-11d0-a765-00a0c91e6bf6",
+			expected: "f81d4fae-7dec-11d0-a765-00a0c91e6bf6",
+		},
+		"generated": {
+			header: "",
+		},
+		"rejected": {
+			header: "forged\nlog line",
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			var logged interface{}
+			handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				logged = FromContext(r.Context()).Data["request_id"]
+			}))
+
+			request := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+			request.Header.Set(RequestIDHeader, tc.header)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			requestID := recorder.Header().Get(RequestIDHeader)
+			if tc.expected != "" {
+				assert.Equal(t, tc.expected, requestID)
+			} else {
+				assert.Len(t, requestID, 36)
+			}
+			assert.Equal(t, requestID, logged)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "utile"
+
+// Direction labels for the websocket message counters
+const (
+	In  = "in"
+	Out = "out"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests handled, by route template, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests, by route template and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	dnsDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "dns",
+		Name:      "upstream_duration_seconds",
+		Help:      "Latency of the queries sent to the upstream DNS resolver, by record type.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"type"})
+
+	dnsResponses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "dns",
+		Name:      "upstream_responses_total",
+		Help:      "Number of answers from the upstream DNS resolver, by record type and response code.",
+	}, []string{"type", "rcode"})
+
+	mathDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "math",
+		Name:      "computation_duration_seconds",
+		Help:      "Time spent computing mathematical constants, by constant.",
+		Buckets:   prometheus.ExponentialBuckets(.001, 4, 10),
+	}, []string{"constant"})
+
+	websocketMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "websocket",
+		Name:      "messages_total",
+		Help:      "Number of websocket messages, by endpoint and direction (in or out).",
+	}, []string{"endpoint", "direction"})
+
+	websocketDrops = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "websocket",
+		Name:      "send_dropped_total",
+		Help:      "Number of outbound messages dropped because the client send buffer was full, by endpoint.",
+	}, []string{"endpoint"})
+)
+
+// Handler exposes the registered metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Gauge registers a gauge whose value is computed by f at every scrape
+func Gauge(subsystem string, name string, help string, f func() float64) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	}, f)
+}
+
+// ObserveDNS records the latency and response code of an upstream DNS query
+func ObserveDNS(recordType string, rcode string, start time.Time) {
+	dnsDuration.WithLabelValues(recordType).Observe(time.Since(start).Seconds())
+	dnsResponses.WithLabelValues(recordType, rcode).Inc()
+}
+
+// ObserveComputation records the time spent computing the given constant
+func ObserveComputation(constant string, start time.Time) {
+	mathDuration.WithLabelValues(constant).Observe(time.Since(start).Seconds())
+}
+
+// WebsocketMessage counts a message received (In) or sent (Out) on the given websocket endpoint
+func WebsocketMessage(endpoint string, direction string) {
+	websocketMessages.WithLabelValues(endpoint, direction).Inc()
+}
+
+// WebsocketDrop counts a message dropped because the client send buffer was full
+func WebsocketDrop(endpoint string) {
+	websocketDrops.WithLabelValues(endpoint).Inc()
+}
+
+// Instrument is a middleware counting the requests and measuring their latency,
+// labelled by the mux route template rather than the raw path to keep the cardinality bounded
+func Instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		route := "unmatched"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		httpRequests.WithLabelValues(route, r.Method, strconv.Itoa(recorder.status)).Inc()
+		httpDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack is required by the websocket upgraders
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking not supported")
+	}
+	s.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Instrument(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(Instrument)
+	router.HandleFunc("/dns/{domain}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	for _, domain := range []string{"utile.space", "example.com"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/dns/"+domain, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(httpRequests.WithLabelValues("/dns/{domain}", http.MethodGet, "404")))
+}
@@ -0,0 +1,250 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"utile.space/api/infrastructure/logging"
+	"utile.space/api/utils"
+)
+
+// Tier is the token bucket given to each client of a kind: Burst tokens at most, refilled at Rate tokens per second
+type Tier struct {
+	Rate  float64
+	Burst float64
+}
+
+type bucket struct {
+	tier   Tier
+	tokens float64
+	last   time.Time
+}
+
+// Limiter is a token bucket rate limiter keyed by client IP, or by API key for authenticated requests
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+
+	anonymous Tier
+	keyed     Tier
+
+	// Returns the API key authenticating the request, if any.
+	keyOf          func(r *http.Request) (string, bool)
+	trustedProxies []*net.IPNet
+
+	// Cost of each route template, 1 when absent.
+	costs map[string]float64
+
+	now func() time.Time
+}
+
+func NewLimiter(anonymous Tier, keyed Tier, costs map[string]float64) *Limiter {
+	return &Limiter{
+		buckets:   make(map[string]*bucket),
+		anonymous: anonymous,
+		keyed:     keyed,
+		keyOf:     func(r *http.Request) (string, bool) { return "", false },
+		costs:     costs,
+		now:       time.Now,
+	}
+}
+
+// NewLimiterFromEnv reads the tiers from RATE_LIMIT_RATE/RATE_LIMIT_BURST and RATE_LIMIT_KEY_RATE/RATE_LIMIT_KEY_BURST,
+// and the trusted proxies from TRUSTED_PROXIES (comma separated IPs or CIDRs)
+func NewLimiterFromEnv(costs map[string]float64) *Limiter {
+	l := NewLimiter(
+		Tier{Rate: envFloat("RATE_LIMIT_RATE", 1), Burst: envFloat("RATE_LIMIT_BURST", 60)},
+		Tier{Rate: envFloat("RATE_LIMIT_KEY_RATE", 10), Burst: envFloat("RATE_LIMIT_KEY_BURST", 600)},
+		costs,
+	)
+
+	for _, proxy := range splitList(os.Getenv("TRUSTED_PROXIES")) {
+		if err := l.TrustProxy(proxy); err != nil {
+			logging.Logger("ratelimit").Warnf("Ignoring trusted proxy %q: %v", proxy, err)
+		}
+	}
+
+	return l
+}
+
+// IdentifyKeysWith sets how the API key authenticating a request is found, such requests getting the keyed tier
+func (l *Limiter) IdentifyKeysWith(keyOf func(r *http.Request) (string, bool)) {
+	l.keyOf = keyOf
+}
+
+// TrustProxy allows the given IP or CIDR to forward the client IP in X-Forwarded-For
+func (l *Limiter) TrustProxy(proxy string) error {
+	if !strings.Contains(proxy, "/") {
+		if strings.Contains(proxy, ":") {
+			proxy += "/128"
+		} else {
+			proxy += "/32"
+		}
+	}
+
+	_, network, err := net.ParseCIDR(proxy)
+	if err != nil {
+		return err
+	}
+
+	l.trustedProxies = append(l.trustedProxies, network)
+	return nil
+}
+
+func (l *Limiter) isTrusted(ip net.IP) bool {
+	for _, network := range l.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the IP of the remote peer, or the closest untrusted hop of X-Forwarded-For when the peer is a trusted proxy
+func (l *Limiter) ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !l.isTrusted(ip) {
+		return host
+	}
+
+	// NOTE: walking from the right since the left-most hops are set by the client and can be forged
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		if !l.isTrusted(hop) {
+			return hop.String()
+		}
+	}
+
+	return host
+}
+
+func (l *Limiter) identify(r *http.Request) (string, Tier) {
+	if key, ok := l.keyOf(r); ok {
+		return "key:" + key, l.keyed
+	}
+	return "ip:" + l.ClientIP(r), l.anonymous
+}
+
+type contextKey struct{}
+
+// ClientFromContext returns the client of the request as identified by the limiter, "key:<id>" or "ip:<address>",
+// empty when the request did not go through the limiter
+func ClientFromContext(ctx context.Context) string {
+	client, _ := ctx.Value(contextKey{}).(string)
+	return client
+}
+
+func (l *Limiter) cost(r *http.Request) float64 {
+	if cost, ok := l.costs[utils.RouteTemplate(r)]; ok {
+		return cost
+	}
+	return 1
+}
+
+// Take removes cost tokens from the bucket of key if it holds enough of them,
+// returning the tokens left and the time to wait before a refused request could pass
+func (l *Limiter) Take(key string, tier Tier, cost float64) (bool, float64, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tier: tier, tokens: tier.Burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(tier.Burst, b.tokens+now.Sub(b.last).Seconds()*tier.Rate)
+	b.last = now
+
+	if b.tokens < cost {
+		return false, b.tokens, time.Duration((cost - b.tokens) / tier.Rate * float64(time.Second))
+	}
+
+	b.tokens -= cost
+	return true, b.tokens, 0
+}
+
+// Middleware refuses the requests of the clients having exhausted their bucket with a 429 status,
+// and reports the state of the bucket in the RateLimit-* headers
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, tier := l.identify(r)
+		r = r.WithContext(context.WithValue(r.Context(), contextKey{}, key))
+
+		cost := l.cost(r)
+		if cost == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowed, remaining, retryAfter := l.Take(key, tier, cost)
+
+		w.Header().Set("RateLimit-Limit", strconv.FormatFloat(tier.Burst, 'f', 0, 64))
+		w.Header().Set("RateLimit-Remaining", strconv.FormatFloat(math.Floor(remaining), 'f', 0, 64))
+		w.Header().Set("RateLimit-Reset", strconv.FormatFloat(math.Ceil((tier.Burst-remaining)/tier.Rate), 'f', 0, 64))
+
+		if !allowed {
+			logging.FromContext(r.Context()).WithField("client", key).Info("Rate limit exceeded")
+			w.Header().Set("Retry-After", strconv.FormatFloat(math.Ceil(retryAfter.Seconds()), 'f', 0, 64))
+			utils.OutputError(w, r.Header["Accept"], http.StatusTooManyRequests, "Too Many Requests")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Run periodically forgets the buckets which are full again, until ctx is done
+func (l *Limiter) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Minute):
+			l.mu.Lock()
+			now := l.now()
+			for key, b := range l.buckets {
+				if b.tokens+now.Sub(b.last).Seconds()*b.tier.Rate >= b.tier.Burst {
+					delete(l.buckets, key)
+				}
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+func envFloat(name string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(name), 64)
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
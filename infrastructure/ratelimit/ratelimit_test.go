@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ClientIP(t *testing.T) {
+	l := NewLimiter(Tier{Rate: 1, Burst: 1}, Tier{Rate: 1, Burst: 1}, nil)
+	assert.NoError(t, l.TrustProxy("10.0.0.0/8"))
+
+	tt := map[string]struct {
+		remoteAddr    string
+		forwardedFor  string
+		expectedIPStr string
+	}{
+		"direct": {
+			remoteAddr:    "203.0.113.7:5555",
+			expectedIPStr: "203.0.113.7",
+		},
+		"untrusted peer forwarding": {
+			remoteAddr:    "203.0.113.7:5555",
+			forwardedFor:  "198.51.100.1",
+			expectedIPStr: "203.0.113.7",
+		},
+		"trusted proxy": {
+			remoteAddr:    "10.1.2.3:5555",
+			forwardedFor:  "198.51.100.1",
+			expectedIPStr: "198.51.100.1",
+		},
+		"forged left-most hop": {
+			remoteAddr:    "10.1.2.3:5555",
+			forwardedFor:  "1.2.3.4, 198.51.100.1, 10.0.0.2",
+			expectedIPStr: "198.51.100.1",
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/d6", nil)
+			r.RemoteAddr = tc.remoteAddr
+			if tc.forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", tc.forwardedFor)
+			}
+			assert.Equal(t, tc.expectedIPStr, l.ClientIP(r))
+		})
+	}
+}
+
+func Test_Middleware(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewLimiter(Tier{Rate: 1, Burst: 10}, Tier{Rate: 10, Burst: 100}, map[string]float64{"/api/math/pi": 10})
+	l.now = func() time.Time { return now }
+
+	var client string
+	router := mux.NewRouter()
+	router.Use(l.Middleware)
+	router.HandleFunc("/api/math/pi", func(w http.ResponseWriter, r *http.Request) {
+		client = ClientFromContext(r.Context())
+	})
+
+	serve := func() *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/math/pi", nil)
+		r.Header.Set("Accept", "application/json")
+		router.ServeHTTP(recorder, r)
+		return recorder
+	}
+
+	first := serve()
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "10", first.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", first.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "10", first.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "ip:192.0.2.1", client)
+
+	second := serve()
+	assert.Equal(t, http.StatusTooManyRequests, second.Code)
+	assert.Equal(t, "10", second.Header().Get("Retry-After"))
+	assert.Equal(t, `{"status":429,"message":"Too Many Requests"}`, second.Body.String())
+
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, http.StatusOK, serve().Code)
+}
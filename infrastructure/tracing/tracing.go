@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	serviceName = "utile-api"
+	tracerName  = "utile.space/api"
+)
+
+// Exporters selectable through the OTEL_TRACES_EXPORTER environment variable
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Init installs the global tracer provider and the W3C trace context propagator.
+//
+// The exporter is chosen with OTEL_TRACES_EXPORTER (none by default, otlp or stdout),
+// the OTLP exporter itself being configured with the standard OTEL_EXPORTER_OTLP_* variables.
+// The returned function flushes and stops the provider.
+func Init(ctx context.Context) (func(context.Context) error, error) {
+	name, present := os.LookupEnv("OTEL_TRACES_EXPORTER")
+	if !present {
+		name = ExporterNone
+	}
+
+	return Setup(ctx, name, os.Stdout)
+}
+
+// Setup installs the global tracer provider with the given exporter, stdout spans being written to w
+func Setup(ctx context.Context, exporterName string, w io.Writer) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch exporterName {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	default:
+		return nil, fmt.Errorf("unknown traces exporter %q", exporterName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := []attribute.KeyValue{semconv.ServiceName(serviceName)}
+	if version, present := os.LookupEnv("API_VERSION"); present {
+		attributes = append(attributes, semconv.ServiceVersion(version))
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, attributes...)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Middleware starts a server span for each request, named after its mux route template
+// and continuing the trace given in the traceparent header if any
+func Middleware() func(http.Handler) http.Handler {
+	return otelmux.Middleware(serviceName)
+}
+
+// Start starts a child span of the one found in ctx
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End records err on the span, if any, then ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
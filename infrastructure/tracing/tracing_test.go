@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Propagation(t *testing.T) {
+	var output bytes.Buffer
+
+	shutdown, err := Setup(context.Background(), ExporterStdout, &output)
+	assert.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.Use(Middleware())
+	router.HandleFunc("/dns/{domain}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := Start(r.Context(), "dns.lookup a")
+		End(span, nil)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/dns/utile.space", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	assert.NoError(t, shutdown(context.Background()))
+
+	assert.Contains(t, output.String(), "\"Name\":\"/dns/{domain}\"")
+	assert.Contains(t, output.String(), "\"Name\":\"dns.lookup a\"")
+	assert.Contains(t, output.String(), "\"TraceID\":\"4bf92f3577b34da6a3ce929d0e0e4736\"")
+	assert.Contains(t, output.String(), "\"Remote\":true")
+}
+
+func Test_SetupUnknownExporter(t *testing.T) {
+	_, err := Setup(context.Background(), "jaeger", nil)
+	assert.Error(t, err)
+}
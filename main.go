@@ -1,15 +1,29 @@
 package main
 
 import (
-	"encoding/xml"
+	"context"
+	"errors"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 	"utile.space/api/api"
+	v2 "utile.space/api/api/v2"
 	_ "utile.space/api/docs"
+	_ "utile.space/api/docs/v2"
+	"utile.space/api/domain/services/math"
+	"utile.space/api/domain/services/random"
+	"utile.space/api/infrastructure/auth"
+	"utile.space/api/infrastructure/dnsclient"
+	"utile.space/api/infrastructure/health"
+	"utile.space/api/infrastructure/history"
+	"utile.space/api/infrastructure/logging"
+	"utile.space/api/infrastructure/metrics"
+	"utile.space/api/infrastructure/ratelimit"
+	"utile.space/api/infrastructure/tracing"
 	"utile.space/api/utils"
 )
 
@@ -17,33 +31,117 @@ func EmptyResponse(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// @Summary		Healthcheck
-// @Description	Get the status of the API
-// @Tags			health
-// @Produce		json,xml,application/yaml,plain
-// @Success		200	{object}	Health
-// @Router			/status [get]
-func HealthCheck(w http.ResponseWriter, r *http.Request) {
-	var health Health
-	health.Status = "up"
-
-	version, present := os.LookupEnv("API_VERSION")
-	if present {
-		health.Version = version
+// aliasDeprecation is when the versioned routes were released, the unversioned alias being deprecated since
+var aliasDeprecation = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// aliasSunset reads the date the unversioned alias will be removed from API_ALIAS_SUNSET, like 2027-10-19,
+// none being announced by default
+func aliasSunset() time.Time {
+	sunset, err := time.Parse(time.DateOnly, os.Getenv("API_ALIAS_SUNSET"))
+	if err != nil {
+		return time.Time{}
 	}
+	return sunset
+}
 
-	utils.Output(w, r.Header["Accept"], health, health.Status)
+// constantRoute matches the constants computed on demand besides pi and tau, which have their own routes
+const constantRoute = "{constant:(?:e|phi|ln2|catalan|zeta3|sqrt[0-9]+)}"
+
+// services are the dependencies of the handlers, built once for every version
+type services struct {
+	authenticator *auth.Authenticator
+	readiness     *health.Probe
+	liveness      *health.Probe
+	seeds         *random.Seeds
+	decks         *random.Decks
 }
 
-type Health struct {
-	XMLName xml.Name `json:"-" xml:"health" yaml:"-"`
-	Version string   `json:"version,omitempty" xml:"version,omitempty" yaml:"version,omitempty"`
-	Status  string   `json:"status" xml:"status" yaml:"status"`
+// registerShared registers the routes which are the same in every version
+func registerShared(router *mux.Router, s services) {
+	router.HandleFunc("/", EmptyResponse).Methods(http.MethodGet)
+
+	// NOTE: need to use non capturing group with (?:pattern) below because capturing group are not supported
+	router.HandleFunc("/d{dice:(?:100|1[0-9]|[2-9][0-9]?)}", api.RollDice).Methods(http.MethodGet)
+	router.HandleFunc("/roll", api.RollNotation(s.seeds)).Methods(http.MethodGet)
+	router.HandleFunc("/roll/verify", api.VerifyRoll).Methods(http.MethodGet)
+	router.HandleFunc("/roll/distribution", api.RollDistribution).Methods(http.MethodGet)
+	router.HandleFunc("/random/seeds", api.CreateSeed(s.seeds)).Methods(http.MethodPost)
+	router.HandleFunc("/random/seeds/{commitment}/reveal", api.RevealSeed(s.seeds)).Methods(http.MethodPost)
+	router.HandleFunc("/random/coins", api.FlipCoins).Methods(http.MethodGet)
+	router.HandleFunc("/random/decks", api.CreateDeck(s.decks)).Methods(http.MethodPost)
+	router.HandleFunc("/random/decks/{id}/draw", api.DrawCards(s.decks)).Methods(http.MethodPost)
+	router.HandleFunc("/random/shuffle", api.ShuffleItems).Methods(http.MethodPost)
+	router.HandleFunc("/random/pick", api.PickOptions).Methods(http.MethodGet)
+	router.HandleFunc("/random/int", api.RandomIntegers).Methods(http.MethodGet)
+	router.HandleFunc("/random/float", api.RandomFloats).Methods(http.MethodGet)
+	router.HandleFunc("/random/uuid", api.GenerateUUIDs).Methods(http.MethodGet)
+	router.HandleFunc("/links", api.GetLinksPage).Methods(http.MethodGet)
+	router.HandleFunc("/math/pi", api.CalculatePi).Methods(http.MethodGet)
+	router.HandleFunc("/math/tau", api.CalculateTau).Methods(http.MethodGet)
+	router.HandleFunc("/math/ws", api.MathWebsocket).Methods(http.MethodGet)
+	router.HandleFunc("/math/eval", api.EvaluateExpression).Methods(http.MethodGet)
+	router.HandleFunc("/math/prime/{n}", api.CheckPrime).Methods(http.MethodGet)
+	router.HandleFunc("/math/factor/{n}", api.Factorize).Methods(http.MethodGet)
+	router.HandleFunc("/math/gcd", api.GreatestCommonDivisor).Methods(http.MethodGet)
+	router.HandleFunc("/math/lcm", api.LeastCommonMultiple).Methods(http.MethodGet)
+	router.HandleFunc("/math/modinv", api.ModularInverse).Methods(http.MethodGet)
+	router.HandleFunc("/math/modpow", api.ModularPower).Methods(http.MethodGet)
+	router.HandleFunc("/math/convert", api.ConvertNumber).Methods(http.MethodGet)
+	router.HandleFunc("/math/stats", api.DescribeNumbers).Methods(http.MethodPost)
+	router.HandleFunc("/math/{constant}/search", api.SearchDigits).Methods(http.MethodGet)
+	router.HandleFunc("/math/"+constantRoute, api.CalculateConstant).Methods(http.MethodGet)
+	router.HandleFunc("/battleships/ws", api.BattleshipsWebsocket).Methods(http.MethodGet)
+	router.HandleFunc("/battleships/stats", api.BattleshipsStats).Methods(http.MethodGet)
+	router.HandleFunc("/spectrum/ws", api.SpectrumWebsocket).Methods(http.MethodGet)
+
+	router.HandleFunc("/admin/usage", api.ListUsage(s.authenticator)).Methods(http.MethodGet)
+
+	router.HandleFunc("/status", api.HealthCheck).Methods(http.MethodGet)
+	router.HandleFunc("/status/ready", api.Readiness(s.readiness)).Methods(http.MethodGet)
+	router.HandleFunc("/status/live", api.Liveness(s.liveness)).Methods(http.MethodGet)
+}
+
+func registerV1(router *mux.Router, s services) {
+	registerShared(router, s)
+
+	router.HandleFunc("/dns/{domain}", api.DNSResolve).Methods(http.MethodGet)
+	router.HandleFunc("/dns/mx/{domain}", api.MXResolve).Methods(http.MethodGet)
+	router.HandleFunc("/dns/cname/{domain}", api.CNAMEResolve).Methods(http.MethodGet)
+	router.HandleFunc("/dns/txt/{domain}", api.TXTResolve).Methods(http.MethodGet)
+	router.HandleFunc("/dns/ns/{domain}", api.NSResolve).Methods(http.MethodGet)
+	router.HandleFunc("/dns/caa/{domain}", api.CAAResolve).Methods(http.MethodGet)
+	router.HandleFunc("/dns/aaaa/{domain}", api.AAAAResolve).Methods(http.MethodGet)
+	router.HandleFunc("/dns/dmarc/{domain}", api.DMARCResolve).Methods(http.MethodGet)
+	router.HandleFunc("/dns/ptr/{ip}", api.PTRResolve).Methods(http.MethodGet)
+
+	// NOTE: the docs only change with a new deployment
+	router.PathPrefix("/docs/").Handler(utils.Cached(24*time.Hour, httpSwagger.Handler(
+		httpSwagger.DeepLinking(true),
+		httpSwagger.DocExpansion("none"),
+		httpSwagger.DomID("swagger-ui"),
+	))).Methods(http.MethodGet)
 }
 
-func initLogging() {
-	log.SetLevel(log.DebugLevel)
-	//log.SetFormatter(&log.JSONFormatter{})
+func registerV2(router *mux.Router, s services) {
+	registerShared(router, s)
+
+	router.HandleFunc("/dns/{domain}", v2.DNSResolve).Methods(http.MethodGet)
+	router.HandleFunc("/dns/a/{domain}", v2.AResolve).Methods(http.MethodGet)
+	router.HandleFunc("/dns/aaaa/{domain}", v2.AAAAResolve).Methods(http.MethodGet)
+	router.HandleFunc("/dns/mx/{domain}", v2.MXResolve).Methods(http.MethodGet)
+	router.HandleFunc("/dns/cname/{domain}", v2.CNAMEResolve).Methods(http.MethodGet)
+	router.HandleFunc("/dns/txt/{domain}", v2.TXTResolve).Methods(http.MethodGet)
+	router.HandleFunc("/dns/ns/{domain}", v2.NSResolve).Methods(http.MethodGet)
+	router.HandleFunc("/dns/caa/{domain}", v2.CAAResolve).Methods(http.MethodGet)
+	router.HandleFunc("/dns/dmarc/{domain}", v2.DMARCResolve).Methods(http.MethodGet)
+	router.HandleFunc("/dns/ptr/{ip}", v2.PTRResolve).Methods(http.MethodGet)
+
+	router.PathPrefix("/docs/").Handler(utils.Cached(24*time.Hour, httpSwagger.Handler(
+		httpSwagger.InstanceName("v2"),
+		httpSwagger.DeepLinking(true),
+		httpSwagger.DocExpansion("none"),
+		httpSwagger.DomID("swagger-ui"),
+	))).Methods(http.MethodGet)
 }
 
 // @title			utile.space Open API
@@ -56,45 +154,135 @@ func initLogging() {
 // @license.name	utile.space API License
 // @license.url	https://utile.space/api/
 //
-// @BasePath		/api
+// @BasePath		/api/v1
 func main() {
-	initLogging()
+	if err := logging.Configure(); err != nil {
+		log.Fatal(err)
+	}
+	api.RegisterMetrics()
 
-	router := mux.NewRouter()
+	// NOTE: the digits files must be the ones cmd/gendigits wrote, the math websocket serving them as they are
+	if err := math.VerifyAssets(math.AssetsDir); errors.Is(err, math.ErrNoManifest) {
+		log.Warn(err)
+	} else if err != nil {
+		log.Fatal(err)
+	}
+	if err := math.IndexAssets(math.AssetsDir, "pi", "tau"); err != nil {
+		log.Warn(err)
+	}
 
-	router.Use(utils.EnableCors)
+	shutdownTracing, err := tracing.Init(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	apiRouter := router.PathPrefix("/api").Subrouter()
+	keyStore, err := auth.OpenStore(os.Getenv("API_KEYS_STORE"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rollHistory, err := history.OpenStore(os.Getenv("ROLL_HISTORY_STORE"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	api.SetRollHistory(rollHistory)
+
+	// NOTE: scope an API key needs for the routes below, anonymous requests being still allowed unless stated
+	authenticator := auth.NewAuthenticator(keyStore, map[string]auth.Policy{
+		"/api/math/ws":           {Scope: "math:ws", Anonymous: true},
+		"/api/battleships/ws":    {Scope: "games", Anonymous: true},
+		"/api/battleships/stats": {Scope: "games", Anonymous: true},
+		"/api/spectrum/ws":       {Scope: "games", Anonymous: true},
+		"/api/admin/usage":       {Scope: "admin"},
+	})
+
+	// NOTE: cost in tokens of the expensive routes, the others costing 1 token
+	limiter := ratelimit.NewLimiterFromEnv(map[string]float64{
+		"/metrics":                    0,
+		"/api/status":                 0,
+		"/api/status/ready":           0,
+		"/api/status/live":            0,
+		"/api/math/pi":                10,
+		"/api/math/tau":               10,
+		"/api/math/" + constantRoute:  10,
+		"/api/math/eval":              5,
+		"/api/math/prime/{n}":         10,
+		"/api/math/factor/{n}":        20,
+		"/api/math/stats":             5,
+		"/api/roll/distribution":      5,
+		"/api/random/seeds":           5,
+		"/api/random/decks":           5,
+		"/api/random/shuffle":         5,
+		"/api/links":                  5,
+		"/api/math/{constant}/search": 2,
+		"/api/dns/{domain}":           2,
+		"/api/dns/a/{domain}":         2,
+		"/api/dns/mx/{domain}":        2,
+		"/api/dns/cname/{domain}":     2,
+		"/api/dns/txt/{domain}":       2,
+		"/api/dns/ns/{domain}":        2,
+		"/api/dns/caa/{domain}":       2,
+		"/api/dns/aaaa/{domain}":      2,
+		"/api/dns/dmarc/{domain}":     2,
+		"/api/dns/ptr/{ip}":           2,
+	})
+	limiter.IdentifyKeysWith(auth.KeyID)
+	go limiter.Run(context.Background())
+
+	cors := utils.NewCorsFromEnv()
+	api.SetOriginPolicy(cors.CheckOrigin)
+
+	readiness := health.NewProbe(5 * time.Second)
+	readiness.Add(health.Checker{Name: "dns", Check: dnsclient.Check})
+	readiness.Add(health.Checker{Name: "notion", Check: health.Cached(5*time.Minute, api.CheckNotion)})
+	readiness.Add(health.Checker{Name: "assets", Check: func(ctx context.Context) error {
+		return math.CheckAssets("pi", "tau")
+	}, Critical: true})
+
+	// NOTE: after the roll history is set, which the spectrum hub takes when it starts
+	api.StartHubs(context.Background())
+
+	liveness := health.NewProbe(time.Second)
+	liveness.Add(health.Checker{Name: "battleships", Check: api.PingBattleshipsHub, Critical: true})
+	liveness.Add(health.Checker{Name: "spectrum", Check: api.PingSpectrumHub, Critical: true})
+
+	seeds := random.NewSeeds(random.DefaultSeedTTL, random.DefaultSeedRetention, random.DefaultMaxSeeds, random.DefaultMaxClientSeeds)
+	go seeds.Run(context.Background())
+
+	decks := random.NewDecks(random.DefaultDeckTTL, random.DefaultMaxDecks, random.DefaultMaxClientDecks)
+	go decks.Run(context.Background())
+
+	s := services{authenticator: authenticator, readiness: readiness, liveness: liveness, seeds: seeds, decks: decks}
+
+	router := mux.NewRouter()
+
+	router.Use(tracing.Middleware())
+	router.Use(logging.RequestID)
+	router.Use(logging.AccessLog)
+	router.Use(metrics.Instrument)
+	router.Use(authenticator.Middleware)
+	router.Use(limiter.Middleware)
+	// NOTE: after the limiter, the requests it rejects not using up the daily quota of their key
+	router.Use(authenticator.Quota)
+	router.Use(utils.Compress(1024))
+	router.Use(utils.ConditionalGet)
 
 	router.HandleFunc("/", EmptyResponse).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/", EmptyResponse).Methods(http.MethodGet)
+	router.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
 
-	// NOTE: need to use non capturing group with (?:pattern) below because capturing group are not supported
-	apiRouter.HandleFunc("/d{dice:(?:100|1[0-9]|[2-9][0-9]?)}", api.RollDice).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/dns/{domain}", api.DNSResolve).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/dns/mx/{domain}", api.MXResolve).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/dns/cname/{domain}", api.CNAMEResolve).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/dns/txt/{domain}", api.TXTResolve).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/dns/ns/{domain}", api.NSResolve).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/dns/caa/{domain}", api.CAAResolve).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/dns/aaaa/{domain}", api.AAAAResolve).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/dns/dmarc/{domain}", api.DMARCResolve).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/dns/ptr/{ip}", api.PTRResolve).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/links", api.GetLinksPage).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/math/pi", api.CalculatePi).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/math/tau", api.CalculateTau).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/math/ws", api.MathWebsocket).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/battleships/ws", api.BattleshipsWebsocket).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/battleships/stats", api.BattleshipsStats).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/spectrum/ws", api.SpectrumWebsocket).Methods(http.MethodGet)
-
-	apiRouter.HandleFunc("/status", HealthCheck).Methods(http.MethodGet)
-
-	apiRouter.PathPrefix("/docs/").Handler(httpSwagger.Handler(
-		httpSwagger.DeepLinking(true),
-		httpSwagger.DocExpansion("none"),
-		httpSwagger.DomID("swagger-ui"),
-	)).Methods(http.MethodGet)
+	// NOTE: the versioned routers come first, the /api prefix matching them too
+	v1Router := router.PathPrefix("/api/v1").Subrouter()
+	registerV1(v1Router, s)
+
+	v2Router := router.PathPrefix("/api/v2").Subrouter()
+	v2Router.Use(v2.ErrorModel)
+	registerV2(v2Router, s)
+
+	// NOTE: the unversioned routes are kept as an alias of v1 for the existing clients, which can move to the same
+	// routes under /api/v1 without any change
+	apiRouter := router.PathPrefix("/api").Subrouter()
+	apiRouter.Use(utils.Deprecated(aliasDeprecation, aliasSunset(), "/api/v1"))
+	registerV1(apiRouter, s)
 
 	port, present := os.LookupEnv("PORT")
 	if !present {
@@ -102,5 +290,17 @@ func main() {
 	}
 
 	log.Info("Starting server on port ", port)
-	log.Fatal(http.ListenAndServe(":"+port, router))
+	err = http.ListenAndServe(":"+port, cors.Handler(router))
+
+	// NOTE: flushing the spans still batched and closing the stores before exiting
+	if err := shutdownTracing(context.Background()); err != nil {
+		log.Error(err)
+	}
+	if err := keyStore.Close(); err != nil {
+		log.Error(err)
+	}
+	if err := rollHistory.Close(); err != nil {
+		log.Error(err)
+	}
+	log.Fatal(err)
 }
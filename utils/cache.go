@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Year is the max-age given to the responses which never change
+const Year = 365 * 24 * time.Hour
+
+// CacheFor marks the response as cacheable by anyone for maxAge, to be called before writing it
+func CacheFor(w http.ResponseWriter, maxAge time.Duration) {
+	// NOTE: the representation depending on the Accept header, shared caches must keep one by Accept
+	w.Header().Add("Vary", "Accept")
+	if maxAge <= 0 {
+		w.Header().Set("Cache-Control", "no-cache")
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+}
+
+// CacheForever marks the response as never changing
+func CacheForever(w http.ResponseWriter) {
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(Year.Seconds()))+", immutable")
+}
+
+// Cached is CacheFor for the handlers which cannot be changed, like the docs
+func Cached(maxAge time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		CacheFor(w, maxAge)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ConditionalGet is a middleware giving a strong ETag to the successful responses marked as cacheable,
+// answering 304 Not Modified when it matches the If-None-Match of the request
+func ConditionalGet(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (r.Method != http.MethodGet && r.Method != http.MethodHead) || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		bw := &bufferedWriter{ResponseWriter: w}
+		next.ServeHTTP(bw, r)
+
+		if !bw.buffering {
+			return
+		}
+
+		etag := w.Header().Get("ETag")
+		if etag == "" {
+			sum := sha256.Sum256(bw.body.Bytes())
+			etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+			w.Header().Set("ETag", etag)
+		}
+
+		if matchesETag(r.Header.Get("If-None-Match"), etag) {
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(bw.body.Bytes())
+	})
+}
+
+// matchesETag uses the weak comparison, as required for If-None-Match
+func matchesETag(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedWriter holds back the cacheable responses, the other ones being passed through as soon as their status is known
+type bufferedWriter struct {
+	http.ResponseWriter
+	decided   bool
+	buffering bool
+	body      bytes.Buffer
+}
+
+func (b *bufferedWriter) WriteHeader(status int) {
+	if b.decided {
+		return
+	}
+	b.decided = true
+
+	cacheControl := b.Header().Get("Cache-Control")
+	b.buffering = status == http.StatusOK && cacheControl != "" && !strings.Contains(cacheControl, "no-store")
+
+	if !b.buffering {
+		b.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	if !b.decided {
+		b.WriteHeader(http.StatusOK)
+	}
+	if b.buffering {
+		return b.body.Write(p)
+	}
+	return b.ResponseWriter.Write(p)
+}
+
+func (b *bufferedWriter) Flush() {
+	if flusher, ok := b.ResponseWriter.(http.Flusher); ok && !b.buffering {
+		flusher.Flush()
+	}
+}
+
+func (b *bufferedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := b.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking not supported")
+	}
+	b.decided = true
+	return hijacker.Hijack()
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ConditionalGet(t *testing.T) {
+	cached := ConditionalGet(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		CacheFor(w, time.Minute)
+		Output(w, r.Header["Accept"], "3.14", "3.14")
+	}))
+	uncached := ConditionalGet(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Output(w, r.Header["Accept"], "4", "4")
+	}))
+
+	first := httptest.NewRecorder()
+	cached.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/api/math/pi", nil))
+	etag := first.Header().Get("ETag")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "public, max-age=60", first.Header().Get("Cache-Control"))
+	assert.Equal(t, "Accept", first.Header().Get("Vary"))
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+	assert.Equal(t, "3.14", first.Body.String())
+
+	tt := map[string]struct {
+		ifNoneMatch  string
+		expectedCode int
+	}{
+		"matching":      {ifNoneMatch: etag, expectedCode: http.StatusNotModified},
+		"weak matching": {ifNoneMatch: `"other", W/` + etag, expectedCode: http.StatusNotModified},
+		"any":           {ifNoneMatch: "*", expectedCode: http.StatusNotModified},
+		"stale":         {ifNoneMatch: `"other"`, expectedCode: http.StatusOK},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/math/pi", nil)
+			r.Header.Set("If-None-Match", tc.ifNoneMatch)
+			recorder := httptest.NewRecorder()
+			cached.ServeHTTP(recorder, r)
+
+			assert.Equal(t, tc.expectedCode, recorder.Code)
+			assert.Equal(t, etag, recorder.Header().Get("ETag"))
+		})
+	}
+
+	t.Run("forever", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ConditionalGet(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			CacheForever(w)
+			Output(w, r.Header["Accept"], "3.14", "3.14")
+		})).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/math/pi", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "public, max-age=31536000, immutable", recorder.Header().Get("Cache-Control"))
+		assert.Equal(t, "Accept", recorder.Header().Get("Vary"))
+	})
+
+	t.Run("not cacheable", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/d6", nil)
+		r.Header.Set("If-None-Match", "*")
+		recorder := httptest.NewRecorder()
+		uncached.ServeHTTP(recorder, r)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Empty(t, recorder.Header().Get("ETag"))
+		assert.Equal(t, "4", recorder.Body.String())
+	})
+}
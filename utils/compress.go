@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Encodings supported by Compress, by order of preference when the client accepts several equally
+var encodings = []string{"br", "zstd", "gzip"}
+
+var encoderPools = map[string]*sync.Pool{
+	"br": {New: func() interface{} {
+		return brotli.NewWriterLevel(nil, brotli.DefaultCompression)
+	}},
+	"zstd": {New: func() interface{} {
+		encoder, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return encoder
+	}},
+	"gzip": {New: func() interface{} {
+		return gzip.NewWriter(nil)
+	}},
+}
+
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// negotiateEncoding returns the preferred encoding among the ones accepted with the highest quality, empty for identity
+func negotiateEncoding(acceptEncoding string) string {
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		qualities[strings.ToLower(strings.TrimSpace(name))] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, encoding := range encodings {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality, ok = qualities["*"]
+		}
+		if ok && quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+	return best
+}
+
+// Compress is a middleware compressing the responses of at least minSize bytes with brotli, zstd or gzip,
+// as negotiated with Accept-Encoding, leaving the websocket upgrades alone
+func Compress(minSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// NOTE: the ETags of the compressed variants are suffixed, the inner handlers only knowing the plain ones
+			ifNoneMatch := r.Header.Get("If-None-Match")
+			if ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", strings.ReplaceAll(ifNoneMatch, "-"+encoding+`"`, `"`))
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize, ifNoneMatch: ifNoneMatch, status: http.StatusOK}
+			defer cw.Close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+	// If-None-Match as sent, before the suffixes were removed
+	ifNoneMatch string
+
+	status      int
+	wroteHeader bool
+	// Decided to compress or not, and headers sent.
+	started  bool
+	hijacked bool
+	buffer   []byte
+	encoder  encoder
+}
+
+func (c *compressWriter) WriteHeader(status int) {
+	if c.wroteHeader {
+		return
+	}
+	c.wroteHeader = true
+	c.status = status
+
+	switch {
+	case status == http.StatusNotModified:
+		// NOTE: the responses below minSize are sent uncompressed with the plain ETag, which the client then revalidates
+		if !matchesETag(c.ifNoneMatch, c.Header().Get("ETag")) {
+			c.suffixETag()
+		}
+		c.passThrough()
+	case status < http.StatusOK || status == http.StatusNoContent:
+		c.passThrough()
+	case c.Header().Get("Content-Encoding") != "" || !isCompressible(c.Header().Get("Content-Type")):
+		c.passThrough()
+	}
+}
+
+func (c *compressWriter) Write(p []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+
+	if c.started {
+		if c.encoder != nil {
+			return c.encoder.Write(p)
+		}
+		return c.ResponseWriter.Write(p)
+	}
+
+	c.buffer = append(c.buffer, p...)
+	if len(c.buffer) >= c.minSize {
+		if err := c.startEncoding(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (c *compressWriter) passThrough() {
+	c.started = true
+	c.ResponseWriter.WriteHeader(c.status)
+}
+
+func (c *compressWriter) suffixETag() {
+	if etag := c.Header().Get("ETag"); strings.HasSuffix(etag, `"`) {
+		c.Header().Set("ETag", strings.TrimSuffix(etag, `"`)+"-"+c.encoding+`"`)
+	}
+}
+
+func (c *compressWriter) startEncoding() error {
+	c.started = true
+
+	// NOTE: net/http does not sniff the content type of encoded bodies
+	if c.Header().Get("Content-Type") == "" {
+		c.Header().Set("Content-Type", http.DetectContentType(c.buffer))
+	}
+	c.Header().Set("Content-Encoding", c.encoding)
+	c.Header().Del("Content-Length")
+	c.suffixETag()
+	c.ResponseWriter.WriteHeader(c.status)
+
+	c.encoder = encoderPools[c.encoding].Get().(encoder)
+	c.encoder.Reset(c.ResponseWriter)
+
+	_, err := c.encoder.Write(c.buffer)
+	c.buffer = nil
+	return err
+}
+
+// Close writes the responses too small to be compressed, or ends the compressed stream
+func (c *compressWriter) Close() {
+	if c.hijacked {
+		return
+	}
+
+	if !c.started {
+		if !c.wroteHeader && len(c.buffer) == 0 {
+			return
+		}
+		c.passThrough()
+		_, _ = c.ResponseWriter.Write(c.buffer)
+		return
+	}
+
+	if c.encoder != nil {
+		_ = c.encoder.Close()
+		c.encoder.Reset(nil)
+		encoderPools[c.encoding].Put(c.encoder)
+		c.encoder = nil
+	}
+}
+
+func (c *compressWriter) Flush() {
+	if !c.started && c.wroteHeader {
+		if err := c.startEncoding(); err != nil {
+			return
+		}
+	}
+	if c.encoder != nil {
+		_ = c.encoder.Flush()
+	}
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (c *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := c.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking not supported")
+	}
+	c.hijacked = true
+	return hijacker.Hijack()
+}
+
+// isCompressible leaves out the formats which are already compressed
+func isCompressible(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+
+	switch {
+	case mediaType == "image/svg+xml":
+		return true
+	case strings.HasPrefix(mediaType, "image/"), strings.HasPrefix(mediaType, "video/"), strings.HasPrefix(mediaType, "audio/"):
+		return false
+	case mediaType == "application/zip", mediaType == "application/gzip", mediaType == "application/zstd":
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_negotiateEncoding(t *testing.T) {
+	tt := map[string]struct {
+		acceptEncoding string
+		expected       string
+	}{
+		"none":           {acceptEncoding: "", expected: ""},
+		"identity":       {acceptEncoding: "identity", expected: ""},
+		"gzip":           {acceptEncoding: "gzip, deflate", expected: "gzip"},
+		"browser":        {acceptEncoding: "gzip, deflate, br, zstd", expected: "br"},
+		"quality":        {acceptEncoding: "br;q=0.5, gzip;q=0.8", expected: "gzip"},
+		"refused":        {acceptEncoding: "br;q=0, zstd", expected: "zstd"},
+		"wildcard":       {acceptEncoding: "*", expected: "br"},
+		"wildcard minus": {acceptEncoding: "*, br;q=0", expected: "zstd"},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, negotiateEncoding(tc.acceptEncoding))
+		})
+	}
+}
+
+func Test_Compress(t *testing.T) {
+	pi := "3." + strings.Repeat("1415926535", 1000)
+
+	handler := Compress(1024)(ConditionalGet(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		CacheFor(w, time.Minute)
+		Output(w, r.Header["Accept"], pi, r.URL.Query().Get("digits"))
+	})))
+
+	decoders := map[string]func(io.Reader) io.Reader{
+		"br": func(r io.Reader) io.Reader {
+			return brotli.NewReader(r)
+		},
+		"zstd": func(r io.Reader) io.Reader {
+			decoder, err := zstd.NewReader(r)
+			assert.NoError(t, err)
+			return decoder
+		},
+		"gzip": func(r io.Reader) io.Reader {
+			decoder, err := gzip.NewReader(r)
+			assert.NoError(t, err)
+			return decoder
+		},
+	}
+
+	for encoding, decode := range decoders {
+		t.Run(encoding, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/math/pi?digits="+pi, nil)
+			r.Header.Set("Accept-Encoding", encoding)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, r)
+
+			assert.Equal(t, encoding, recorder.Header().Get("Content-Encoding"))
+			assert.Equal(t, "text/plain; charset=utf-8", recorder.Header().Get("Content-Type"))
+			assert.Less(t, recorder.Body.Len(), len(pi))
+
+			decoded, err := io.ReadAll(decode(recorder.Body))
+			assert.NoError(t, err)
+			assert.Equal(t, pi, string(decoded))
+
+			etag := recorder.Header().Get("ETag")
+			assert.True(t, strings.HasSuffix(etag, "-"+encoding+`"`))
+
+			r.Header.Set("If-None-Match", etag)
+			recorder = httptest.NewRecorder()
+			handler.ServeHTTP(recorder, r)
+			assert.Equal(t, http.StatusNotModified, recorder.Code)
+			assert.Equal(t, etag, recorder.Header().Get("ETag"))
+		})
+	}
+
+	t.Run("too small", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/math/pi?digits=3.14", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, r)
+
+		assert.Empty(t, recorder.Header().Get("Content-Encoding"))
+		assert.Equal(t, "3.14", recorder.Body.String())
+
+		etag := recorder.Header().Get("ETag")
+		assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+
+		r.Header.Set("If-None-Match", etag)
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, r)
+		assert.Equal(t, http.StatusNotModified, recorder.Code)
+		assert.Equal(t, etag, recorder.Header().Get("ETag"))
+	})
+}
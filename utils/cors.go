@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CorsConfig is the cross-origin policy of the API
+type CorsConfig struct {
+	// Origins allowed, "*" for any, patterns like https://*.utile.space matching any subdomain.
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// Seconds the browsers can cache the preflight answers.
+	MaxAge int
+}
+
+// Cors applies a CorsConfig to the requests, answering the preflight requests itself
+type Cors struct {
+	config    CorsConfig
+	anyOrigin bool
+	origins   []*regexp.Regexp
+}
+
+func NewCors(config CorsConfig) *Cors {
+	c := &Cors{config: config}
+
+	for _, origin := range config.AllowedOrigins {
+		if origin == "*" {
+			c.anyOrigin = true
+			continue
+		}
+		pattern := strings.ReplaceAll(regexp.QuoteMeta(strings.ToLower(origin)), `\*`, `[^/]*`)
+		c.origins = append(c.origins, regexp.MustCompile("^"+pattern+"$"))
+	}
+
+	return c
+}
+
+// NewCorsFromEnv reads the policy from CORS_ALLOWED_ORIGINS, CORS_ALLOWED_METHODS, CORS_ALLOWED_HEADERS (comma separated),
+// CORS_ALLOW_CREDENTIALS and CORS_MAX_AGE, any origin being allowed by default
+func NewCorsFromEnv() *Cors {
+	config := CorsConfig{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodOptions},
+		AllowedHeaders: []string{"Accept", "Content-Type", "X-API-Key", "X-Request-ID", "traceparent", "tracestate"},
+		ExposedHeaders: []string{"X-Request-ID", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
+		MaxAge:         600,
+	}
+
+	if value := os.Getenv("CORS_ALLOWED_ORIGINS"); value != "" {
+		config.AllowedOrigins = splitList(value)
+	}
+	if value := os.Getenv("CORS_ALLOWED_METHODS"); value != "" {
+		config.AllowedMethods = splitList(value)
+	}
+	if value := os.Getenv("CORS_ALLOWED_HEADERS"); value != "" {
+		config.AllowedHeaders = splitList(value)
+	}
+	if value, err := strconv.ParseBool(os.Getenv("CORS_ALLOW_CREDENTIALS")); err == nil {
+		config.AllowCredentials = value
+	}
+	if value, err := strconv.Atoi(os.Getenv("CORS_MAX_AGE")); err == nil {
+		config.MaxAge = value
+	}
+
+	return NewCors(config)
+}
+
+// IsOriginAllowed tells whether the given Origin header value matches the policy
+func (c *Cors) IsOriginAllowed(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	for _, pattern := range c.origins {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOrigin is meant for the websocket upgraders: requests without Origin do not come from a browser and are accepted
+func (c *Cors) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || c.IsOriginAllowed(origin)
+}
+
+// Handler wraps the whole router since mux does not run its middlewares for the OPTIONS requests, which match no route
+func (c *Cors) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		w.Header().Add("Vary", "Origin")
+
+		if origin == "" || !c.IsOriginAllowed(origin) {
+			if preflight {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if c.anyOrigin && !c.config.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			// NOTE: the wildcard is not allowed along with credentials, so the origin is echoed
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if c.config.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if len(c.config.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(c.config.ExposedHeaders, ", "))
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(c.config.AllowedMethods, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(c.config.AllowedHeaders, ", "))
+		if c.config.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(c.config.MaxAge))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
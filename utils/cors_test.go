@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Cors(t *testing.T) {
+	cors := NewCors(CorsConfig{
+		AllowedOrigins:   []string{"https://utile.space", "https://*.utile.space"},
+		AllowedMethods:   []string{http.MethodGet, http.MethodPost},
+		AllowedHeaders:   []string{"Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	})
+
+	served := false
+	handler := cors.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served = true
+	}))
+
+	tt := map[string]struct {
+		method         string
+		origin         string
+		requestMethod  string
+		expectedOrigin string
+		expectedServed bool
+		expectedMaxAge string
+	}{
+		"same origin": {
+			method:         http.MethodGet,
+			expectedServed: true,
+		},
+		"allowed": {
+			method:         http.MethodGet,
+			origin:         "https://utile.space",
+			expectedOrigin: "https://utile.space",
+			expectedServed: true,
+		},
+		"allowed subdomain": {
+			method:         http.MethodGet,
+			origin:         "https://www.utile.space",
+			expectedOrigin: "https://www.utile.space",
+			expectedServed: true,
+		},
+		"refused": {
+			method:         http.MethodGet,
+			origin:         "https://evil.example",
+			expectedServed: true,
+		},
+		"preflight": {
+			method:         http.MethodOptions,
+			origin:         "https://utile.space",
+			requestMethod:  http.MethodPost,
+			expectedOrigin: "https://utile.space",
+			expectedMaxAge: "600",
+		},
+		"refused preflight": {
+			method:        http.MethodOptions,
+			origin:        "https://utile.space.evil.example",
+			requestMethod: http.MethodPost,
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			served = false
+
+			r := httptest.NewRequest(tc.method, "/api/d6", nil)
+			if tc.origin != "" {
+				r.Header.Set("Origin", tc.origin)
+			}
+			if tc.requestMethod != "" {
+				r.Header.Set("Access-Control-Request-Method", tc.requestMethod)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, r)
+
+			assert.Equal(t, tc.expectedServed, served)
+			assert.Equal(t, tc.expectedOrigin, recorder.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tc.expectedMaxAge, recorder.Header().Get("Access-Control-Max-Age"))
+			assert.Equal(t, tc.origin == "" || tc.expectedOrigin != "", cors.CheckOrigin(r))
+		})
+	}
+}
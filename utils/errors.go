@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+// ErrorResult is the body of the error responses, negotiated like the other results
+type ErrorResult struct {
+	XMLName xml.Name `json:"-" xml:"error" yaml:"-"`
+	Status  int      `json:"status" xml:"status" yaml:"status"`
+	Message string   `json:"message" xml:"message" yaml:"message"`
+}
+
+// OutputError writes the status code then the error in the format requested by the Accept header
+func OutputError(w http.ResponseWriter, accept []string, status int, message string) {
+	w.WriteHeader(status)
+	Output(w, accept, ErrorResult{Status: status, Message: message}, message)
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// apiVersion matches the version segment of the API routes, /api/v1/dns/{domain} being the same route as /api/dns/{domain}
+var apiVersion = regexp.MustCompile(`^/api/v[0-9]+(/|$)`)
+
+// RouteTemplate returns the template of the route matched without its API version, so that the policies
+// keyed by route apply to every version, empty when no route matched
+func RouteTemplate(r *http.Request) string {
+	current := mux.CurrentRoute(r)
+	if current == nil {
+		return ""
+	}
+	template, err := current.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return apiVersion.ReplaceAllString(template, "/api$1")
+}
+
+// Deprecated is a middleware announcing the deprecation of the routes (RFC 9745) and their sunset (RFC 8594)
+// when not zero, linking to the same path under successor, like /api/v2
+func Deprecated(deprecation time.Time, sunset time.Time, successor string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(deprecation.Unix(), 10))
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+
+			path := apiVersion.ReplaceAllString(r.URL.Path, "/api$1")
+			if rest, found := strings.CutPrefix(path, "/api"); found {
+				w.Header().Add("Link", "<"+successor+rest+`>; rel="successor-version"`)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
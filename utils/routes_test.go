@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RouteTemplate(t *testing.T) {
+	tt := map[string]struct {
+		path     string
+		expected string
+	}{
+		"unversioned": {path: "/api/dns/example.com", expected: "/api/dns/{domain}"},
+		"v1":          {path: "/api/v1/dns/example.com", expected: "/api/dns/{domain}"},
+		"v2":          {path: "/api/v2/dns/example.com", expected: "/api/dns/{domain}"},
+		"no route":    {path: "/other", expected: ""},
+	}
+
+	var template string
+	router := mux.NewRouter()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		template = RouteTemplate(r)
+	}
+	router.HandleFunc("/api/v1/dns/{domain}", handler)
+	router.HandleFunc("/api/v2/dns/{domain}", handler)
+	router.HandleFunc("/api/dns/{domain}", handler)
+	router.NotFoundHandler = http.HandlerFunc(handler)
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			template = "unset"
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.path, nil))
+			assert.Equal(t, tc.expected, template)
+		})
+	}
+}
+
+func Test_Deprecated(t *testing.T) {
+	deprecation := time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, time.October, 19, 0, 0, 0, 0, time.UTC)
+	handler := Deprecated(deprecation, sunset, "/api/v2")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, path := range []string{"/api/v1/dns/example.com", "/api/dns/example.com"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, "@1792368000", w.Header().Get("Deprecation"))
+		assert.Equal(t, "Tue, 19 Oct 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+		assert.Equal(t, `</api/v2/dns/example.com>; rel="successor-version"`, w.Header().Get("Link"))
+	}
+
+	w := httptest.NewRecorder()
+	Deprecated(deprecation, time.Time{}, "/api/v2")(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/", nil))
+	assert.Empty(t, w.Header().Get("Sunset"))
+}
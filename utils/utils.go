@@ -1,24 +1,17 @@
 package utils
 
 import (
+	"crypto/rand"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
-	"math/rand"
+	"math/big"
 	"net/http"
 	"slices"
 
 	"gopkg.in/yaml.v2"
 )
 
-func EnableCors(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-
-		next.ServeHTTP(w, r)
-	})
-}
-
 func Output(w http.ResponseWriter, accept []string, v interface{}, plain string) {
 	fmt.Fprint(w, computeOutput(accept, v, plain))
 }
@@ -46,8 +39,12 @@ func GenerateRandomString(length int) string {
 	randString := make([]byte, length)
 
 	for i := 0; i < length; i++ {
-		// Choose a random character from the allowedChars
-		randString[i] = allowedChars[rand.Intn(len(allowedChars))]
+		// Choose a random character from the allowedChars, unpredictable as the strings are used as passwords
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(allowedChars))))
+		if err != nil {
+			panic(fmt.Sprintf("crypto/rand failed: %v", err))
+		}
+		randString[i] = allowedChars[n.Int64()]
 	}
 
 	return "OSR-" + string(randString)